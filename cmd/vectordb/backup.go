@@ -0,0 +1,136 @@
+package main
+
+import (
+	"VectorDatabase/internal/backup"
+	"VectorDatabase/internal/index"
+	"VectorDatabase/internal/store"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// s3Flags override the S3 settings taken from the AWS_* environment variables
+func s3Flags(fs *flag.FlagSet) *backup.S3Config {
+	cfg := backup.S3ConfigFromEnv()
+	fs.StringVar(&cfg.Endpoint, "endpoint", cfg.Endpoint, "S3 endpoint URL, e.g. http://localhost:9000 for MinIO (default $AWS_ENDPOINT_URL or AWS)")
+	fs.StringVar(&cfg.Region, "region", cfg.Region, "S3 region (default $AWS_REGION or us-east-1)")
+	return &cfg
+}
+
+// splitList splits a comma separated flag value, nil when it is empty
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func runBackup(args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	data := fs.String("data", "data", "data directory holding the collections")
+	to := fs.String("to", "", "backup target: a directory or s3://bucket/prefix (required)")
+	only := fs.String("collections", "", "comma separated collections to back up (default: all)")
+	incremental := fs.Bool("incremental", false, "only upload what changed since the latest backup on the target")
+	s3 := s3Flags(fs)
+	fs.Parse(args)
+	if *to == "" {
+		return errors.New("-to is required")
+	}
+	target, err := backup.OpenTarget(*to, *s3)
+	if err != nil {
+		return err
+	}
+	names := splitList(*only)
+	if names == nil {
+		entries, err := os.ReadDir(*data)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if e.IsDir() && collectionExists(filepath.Join(*data, e.Name())) {
+				names = append(names, e.Name())
+			}
+		}
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no collections in %s", *data)
+	}
+	collections := make(map[string]backup.Checkpointer, len(names))
+	var opened []*index.SegmentedIndex
+	defer func() {
+		for _, idx := range opened {
+			idx.Close()
+		}
+	}()
+	for _, name := range names {
+		idx, err := openCollection(filepath.Join(*data, name))
+		if errors.Is(err, store.ErrLocked) {
+			// the owner (e.g. vectordb serve) keeps writing its WAL and segments, backing up under it would
+			// delete its in-flight files and truncate its WAL
+			return fmt.Errorf("collection %s is in use, stop the process that has it open before backing it up", name)
+		}
+		if err != nil {
+			return err
+		}
+		opened = append(opened, idx)
+		collections[name] = idx
+	}
+	m, err := backup.Create(context.Background(), target, collections, backup.Options{Incremental: *incremental})
+	if err != nil {
+		return err
+	}
+	kind := "full"
+	if m.Parent != "" {
+		kind = "incremental (parent " + m.Parent + ")"
+	}
+	fmt.Printf("created %s backup %s of %d collections\n", kind, m.ID, len(m.Collections))
+	return nil
+}
+
+func runRestore(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	data := fs.String("data", "data", "data directory to restore the collections into")
+	from := fs.String("from", "", "backup target: a directory or s3://bucket/prefix (required)")
+	id := fs.String("backup", "", "backup id to restore (default: the latest)")
+	only := fs.String("collections", "", "comma separated collections to restore (default: all)")
+	list := fs.Bool("list", false, "list the backups on the target instead of restoring")
+	s3 := s3Flags(fs)
+	fs.Parse(args)
+	if *from == "" {
+		return errors.New("-from is required")
+	}
+	target, err := backup.OpenTarget(*from, *s3)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	if *list {
+		ids, err := backup.List(ctx, target)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			m, err := backup.ReadManifest(ctx, target, id)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s  %d collections  parent=%s\n", m.ID, len(m.Collections), m.Parent)
+		}
+		return nil
+	}
+	if *id == "" {
+		if *id, err = backup.Latest(ctx, target); err != nil {
+			return err
+		}
+	}
+	m, err := backup.Restore(ctx, target, *id, *data, splitList(*only))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("restored backup %s into %s\n", m.ID, *data)
+	return nil
+}
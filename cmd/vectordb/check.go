@@ -0,0 +1,67 @@
+package main
+
+import (
+	"VectorDatabase/internal/index"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func runCheck(args []string) error {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	data := fs.String("data", "data", "data directory holding the collections")
+	only := fs.String("collections", "", "comma separated collections to check (default: all)")
+	truncate := fs.Bool("truncate-wal", false, "cut a WAL off before its first bad frame")
+	rebuild := fs.Bool("rebuild", false, "rebuild damaged collections from every vector that is still valid")
+	fs.Parse(args)
+	names := splitList(*only)
+	if names == nil {
+		entries, err := os.ReadDir(*data)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if e.IsDir() && collectionExists(filepath.Join(*data, e.Name())) {
+				names = append(names, e.Name())
+			}
+		}
+	}
+	opts := index.CheckOptions{TruncateWAL: *truncate, Rebuild: *rebuild}
+	damaged := 0
+	for _, name := range names {
+		dir := filepath.Join(*data, name)
+		report, err := index.CheckCollection(dir, opts)
+		if err == nil && len(report.Repairs) > 0 {
+			for _, repair := range report.Repairs {
+				fmt.Printf("%s: %s\n", name, repair)
+			}
+			report, err = index.CheckCollection(dir, index.CheckOptions{})
+		}
+		if report != nil {
+			for _, issue := range report.Issues {
+				fmt.Printf("%s: %s\n", name, issue)
+			}
+			for _, orphan := range report.Orphans {
+				fmt.Printf("%s: %s is left over from an interrupted flush, removed on the next open\n", name, orphan)
+			}
+		}
+		switch {
+		case err != nil:
+			fmt.Printf("%s: %v\n", name, err)
+			damaged++
+		case !report.OK():
+			damaged++
+		default:
+			fmt.Printf("%s: ok, %d vectors in %d segments and %d wal records\n", name, report.Vectors, report.Segments, report.WALRecords)
+		}
+	}
+	if damaged > 0 {
+		return fmt.Errorf("%d of %d collections damaged", damaged, len(names))
+	}
+	if len(names) == 0 {
+		return errors.New("no collections in " + *data)
+	}
+	return nil
+}
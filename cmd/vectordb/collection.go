@@ -0,0 +1,78 @@
+package main
+
+import (
+	"VectorDatabase/internal/index"
+	"VectorDatabase/internal/store"
+	"VectorDatabase/internal/types"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// collectionFlags are the flags every command working on a collection shares
+type collectionFlags struct {
+	data       string
+	collection string
+	model      string
+}
+
+func (c *collectionFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.data, "data", "data", "data directory holding the collections")
+	fs.StringVar(&c.collection, "collection", "", "collection name (required)")
+}
+
+// registerCreate adds the flags of commands that create the collection when it doesn't exist
+func (c *collectionFlags) registerCreate(fs *flag.FlagSet) {
+	c.register(fs)
+	fs.StringVar(&c.model, "model", string(types.ExternalModel), "embedding model of a new collection, see $VECTORDB_MODELS")
+}
+
+func (c *collectionFlags) dir() (string, error) {
+	if c.collection == "" {
+		return "", errors.New("-collection is required")
+	}
+	return filepath.Join(c.data, c.collection), nil
+}
+
+func collectionExists(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, store.ManifestFileName))
+	return err == nil
+}
+
+// openCollection opens an existing collection with the config it was created with
+func openCollection(dir string) (*index.SegmentedIndex, error) {
+	if !collectionExists(dir) {
+		return nil, errors.New("collection doesn't exist: " + dir)
+	}
+	cfg, err := index.LoadSegmentedConfig(dir)
+	if err != nil {
+		return nil, err
+	}
+	return index.OpenSegmentedIndex(dir, cfg, index.SegmentedOptions{})
+}
+
+// openOrCreateCollection creates a linear collection of dimension dim for model when dir holds none,
+// with the data type and metric of the model (text and cosine when it leaves them open)
+func openOrCreateCollection(dir string, dim int, model types.ModelType) (*index.SegmentedIndex, error) {
+	if collectionExists(dir) {
+		return openCollection(dir)
+	}
+	info, ok := types.LookupModel(model)
+	if !ok {
+		return nil, fmt.Errorf("unknown model %q", model)
+	}
+	dataType, metric := types.Text, types.Cosine
+	if info.DataType != 0 {
+		dataType = info.DataType
+	}
+	if info.Metric != 0 {
+		metric = info.Metric
+	}
+	cfg, err := index.NewIndexConfig(types.LinearIndex, model, dataType, metric, dim)
+	if err != nil {
+		return nil, err
+	}
+	return index.OpenSegmentedIndex(dir, cfg, index.SegmentedOptions{})
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"VectorDatabase/internal/dataio"
+	"VectorDatabase/internal/types"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+)
+
+// peekReader hands out a record read ahead of time (to learn the dimension) before the rest
+type peekReader struct {
+	dataio.RecordReader
+	peeked []dataio.Record
+	errs   []error
+}
+
+// peekDimension reads up to the first good record and returns its dimension, 0 when there is none
+func (p *peekReader) peekDimension() (int, error) {
+	for {
+		rec, err := p.RecordReader.Next()
+		var rowErr *dataio.RowError
+		switch {
+		case errors.As(err, &rowErr):
+			p.errs = append(p.errs, err)
+		case err == io.EOF:
+			return 0, nil
+		case err != nil:
+			return 0, err
+		default:
+			p.peeked = append(p.peeked, rec)
+			return len(rec.Values), nil
+		}
+	}
+}
+
+func (p *peekReader) Next() (dataio.Record, error) {
+	if len(p.errs) > 0 {
+		err := p.errs[0]
+		p.errs = p.errs[1:]
+		return dataio.Record{}, err
+	}
+	if len(p.peeked) > 0 {
+		rec := p.peeked[0]
+		p.peeked = p.peeked[1:]
+		return rec, nil
+	}
+	return p.RecordReader.Next()
+}
+
+func runLoad(args []string) error {
+	fs := flag.NewFlagSet("load", flag.ExitOnError)
+	var coll collectionFlags
+	coll.registerCreate(fs)
+	in := fs.String("in", "", "JSONL, CSV, Arrow IPC or Parquet file to read (required)")
+	formatName := fs.String("format", "", "jsonl, csv, arrow or parquet (default: from the file extension)")
+	idColumn := fs.String("id-column", "id", "column (JSON field) holding the id")
+	vecColumn := fs.String("vector-column", "embedding", "column (JSON field) holding the embedding")
+	mdColumns := fs.String("metadata-columns", "", "comma separated metadata columns (default: every other column)")
+	rejects := fs.String("rejects", "", "write rejected rows as JSON lines to this file")
+	batch := fs.Int("batch", 256, "rows added per batch")
+	fs.Parse(args)
+	dir, err := coll.dir()
+	if err != nil {
+		return err
+	}
+	if *in == "" {
+		return errors.New("-in is required")
+	}
+	format := tableFormat(*formatName, *in)
+	if format == "" {
+		format = *formatName
+	}
+	if format == "" {
+		format = filepath.Ext(*in)
+	}
+	format = strings.ToLower(strings.TrimPrefix(format, "."))
+	mapping := dataio.ColumnMapping{ID: *idColumn, Vector: *vecColumn}
+	if *mdColumns != "" {
+		mapping.Metadata = strings.Split(*mdColumns, ",")
+	}
+
+	f, err := os.Open(*in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var r dataio.RecordReader
+	switch format {
+	case "jsonl", "ndjson":
+		r = dataio.NewJSONLReader(f, mapping)
+	case "csv":
+		if r, err = dataio.NewCSVReader(f, mapping); err != nil {
+			return err
+		}
+	case "arrow", "parquet":
+		var ar *dataio.ArrowReader
+		if format == "arrow" {
+			ar, err = dataio.NewIPCReader(f, mapping)
+		} else {
+			ar, err = dataio.NewParquetReader(f, mapping)
+		}
+		if err != nil {
+			return err
+		}
+		defer ar.Close()
+		r = ar
+	default:
+		return fmt.Errorf("unknown dump format %q, expected jsonl, csv, arrow or parquet", format)
+	}
+	peek := &peekReader{RecordReader: r}
+	dim, err := peek.peekDimension()
+	if err != nil {
+		return err
+	}
+	if dim == 0 && !collectionExists(dir) {
+		return errors.New("no valid row to learn the dimension from")
+	}
+	opts := dataio.LoadOptions{BatchSize: *batch}
+	if *rejects != "" {
+		rf, err := os.Create(*rejects)
+		if err != nil {
+			return err
+		}
+		defer rf.Close()
+		opts.Rejects = rf
+	}
+	idx, err := openOrCreateCollection(dir, dim, types.ModelType(coll.model))
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	stats, err := dataio.Load(ctx, idx, peek, opts)
+	if err == nil {
+		err = idx.Flush()
+	}
+	if err := errors.Join(err, idx.Close()); err != nil {
+		return err
+	}
+	fmt.Printf("loaded %d rows into %s: %d added, %d already present, %d rejected\n",
+		stats.Rows, coll.collection, stats.Added, stats.Existing, stats.Rejected)
+	return nil
+}
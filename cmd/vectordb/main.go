@@ -1,7 +1,59 @@
 package main
 
-import "fmt"
+import (
+	"VectorDatabase/internal/types"
+	"fmt"
+	"os"
+)
+
+// command is a vectordb subcommand, run gets the arguments after its name
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = []command{
+	{"import", "load vectors from an fvecs/ivecs/bvecs/npy file into a collection", runImport},
+	{"export", "write a collection to an fvecs/npy, Arrow IPC or Parquet file", runExport},
+	{"load", "bulk load a JSONL, CSV, Arrow IPC or Parquet file with id, embedding and metadata columns", runLoad},
+	{"backup", "back up collections to a directory or an S3 compatible bucket", runBackup},
+	{"restore", "restore collections from a backup, or list the backups of a target", runRestore},
+	{"check", "verify the files of collections, optionally truncate a torn WAL or rebuild", runCheck},
+	{"serve", "serve the asynchronous ingestion job API of a collection over HTTP", runServe},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: vectordb <command> [flags]")
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(os.Stderr, "\nrun vectordb <command> -h for the flags of a command")
+}
 
 func main() {
-	fmt.Println("success")
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	// models to onboard without a rebuild, a JSON list of types.ModelInfo
+	if path := os.Getenv("VECTORDB_MODELS"); path != "" {
+		if err := types.DefaultModels.LoadFile(path); err != nil {
+			fmt.Fprintf(os.Stderr, "vectordb: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			if err := c.run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "vectordb %s: %v\n", c.name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "vectordb: unknown command %q\n", os.Args[1])
+	usage()
+	os.Exit(2)
 }
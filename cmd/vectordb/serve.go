@@ -0,0 +1,93 @@
+package main
+
+import (
+	"VectorDatabase/internal/api"
+	"VectorDatabase/internal/embedder"
+	"VectorDatabase/internal/ingest"
+	"VectorDatabase/internal/types"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+)
+
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	var coll collectionFlags
+	coll.register(fs)
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+	embedURL := fs.String("embed-url", "", "OpenAI compatible embeddings API root, e.g. http://localhost:11434/v1 (default: a local hashing embedder); the key is read from $VECTORDB_EMBED_API_KEY")
+	embedModel := fs.String("embed-model", "", "model name of the embeddings API, registered through $VECTORDB_MODELS")
+	dim := fs.Int("dim", 256, "embedding dimension")
+	batch := fs.Int("batch", 0, "most texts embedded per call (default 32)")
+	fs.Parse(args)
+	dir, err := coll.dir()
+	if err != nil {
+		return err
+	}
+	e, err := serveEmbedder(*embedURL, *embedModel, *dim)
+	if err != nil {
+		return err
+	}
+	idx, err := openOrCreateCollection(dir, e.Dimension(), types.ModelType(e.Name()))
+	if err != nil {
+		return err
+	}
+	ing, err := ingest.NewIngester(e, idx.Config(), idx, nil)
+	if err != nil {
+		idx.Close()
+		return err
+	}
+	pool, err := ingest.NewPool(ing, ingest.PoolConfig{BatchSize: *batch})
+	if err != nil {
+		idx.Close()
+		return err
+	}
+	jobs, err := ingest.NewJobManager(pool, ingest.JobConfig{})
+	if err != nil {
+		pool.Close()
+		idx.Close()
+		return err
+	}
+
+	srv := &http.Server{Addr: *addr, Handler: api.NewJobHandler(jobs)}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.ListenAndServe() }()
+	fmt.Printf("serving ingestion jobs for %s on %s\n", coll.collection, *addr)
+	select {
+	case err = <-serveErr:
+	case <-ctx.Done():
+		shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err = srv.Shutdown(shutdown)
+		cancel()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		err = nil
+	}
+	// jobs still running are cancelled, what they inserted so far is kept
+	err = errors.Join(err, jobs.Close(), pool.Close(), idx.Flush())
+	return errors.Join(err, idx.Close())
+}
+
+// serveEmbedder is an HTTPEmbedder for url, or a local hashing embedder registered on the fly
+func serveEmbedder(url, model string, dim int) (embedder.Embedder, error) {
+	if url != "" {
+		return embedder.NewHTTPEmbedder(embedder.HTTPConfig{
+			BaseURL:   url,
+			APIKey:    os.Getenv("VECTORDB_EMBED_API_KEY"),
+			Model:     model,
+			Dimension: dim,
+		})
+	}
+	e, err := embedder.NewHashingEmbedder(dim)
+	if err != nil {
+		return nil, err
+	}
+	return e, types.RegisterModel(embedder.Info(e, "1"))
+}
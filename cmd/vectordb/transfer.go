@@ -0,0 +1,184 @@
+package main
+
+import (
+	"VectorDatabase/internal/dataio"
+	"VectorDatabase/internal/types"
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// tableFormat is "arrow" or "parquet" when -format (or else the file extension) names a table format
+func tableFormat(name, path string) string {
+	if name == "" {
+		name = filepath.Ext(path)
+	}
+	switch strings.ToLower(strings.TrimPrefix(name, ".")) {
+	case "arrow", "arrows", "ipc":
+		return "arrow"
+	case "parquet":
+		return "parquet"
+	}
+	return ""
+}
+
+// format from -format, or from the file extension when it is empty
+func fileFormat(name, path string) (dataio.Format, error) {
+	if name != "" {
+		return dataio.ParseFormat(name)
+	}
+	return dataio.FormatFromPath(path)
+}
+
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	var coll collectionFlags
+	coll.registerCreate(fs)
+	in := fs.String("in", "", "vector file to read (required)")
+	formatName := fs.String("format", "", "fvecs, ivecs, bvecs or npy (default: from the file extension)")
+	prefix := fs.String("id-prefix", "", "prefix of the ids, the row number (from 0) is appended")
+	fs.Parse(args)
+	dir, err := coll.dir()
+	if err != nil {
+		return err
+	}
+	if *in == "" {
+		return errors.New("-in is required")
+	}
+	format, err := fileFormat(*formatName, *in)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(*in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r, err := dataio.NewReader(f, format)
+	if err != nil {
+		return err
+	}
+	idx, err := openOrCreateCollection(dir, r.Dimension(), types.ModelType(coll.model))
+	if err != nil {
+		return err
+	}
+	if idx.Dimension() != r.Dimension() {
+		idx.Close()
+		return fmt.Errorf("file dimension %d doesn't match collection dimension %d", r.Dimension(), idx.Dimension())
+	}
+	rows, err := dataio.Import(idx, r, *prefix)
+	if err == nil {
+		err = idx.Flush()
+	}
+	if err := errors.Join(err, idx.Close()); err != nil {
+		return err
+	}
+	fmt.Printf("imported %d vectors into %s\n", rows, coll.collection)
+	return nil
+}
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	var coll collectionFlags
+	coll.register(fs)
+	out := fs.String("out", "", "file to write (required)")
+	formatName := fs.String("format", "", "fvecs, npy, arrow or parquet (default: from the file extension)")
+	idsPath := fs.String("ids", "", "also write the id of every row, one per line, to this file")
+	half := fs.Bool("float16", false, "write npy as float16 instead of float32")
+	fs.Parse(args)
+	dir, err := coll.dir()
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		return errors.New("-out is required")
+	}
+	if table := tableFormat(*formatName, *out); table != "" {
+		return exportTable(dir, coll.collection, *out, table)
+	}
+	format, err := fileFormat(*formatName, *out)
+	if err != nil {
+		return err
+	}
+	if format == dataio.FormatIvecs || format == dataio.FormatBvecs {
+		// stored vectors are normalized, their values are never the integers these formats hold
+		return fmt.Errorf("can't export to %s: collections hold normalized float vectors, use fvecs or npy", format)
+	}
+	if *half && format != dataio.FormatNpy {
+		return errors.New("-float16 only applies to npy")
+	}
+	idx, err := openCollection(dir)
+	if err != nil {
+		return err
+	}
+	defer idx.Close()
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var w dataio.VectorWriter
+	if format == dataio.FormatNpy {
+		w, err = dataio.NewNpyWriter(f, idx.Dimension(), idx.Size(), *half)
+	} else {
+		w, err = dataio.NewWriter(f, format, idx.Dimension(), idx.Size())
+	}
+	if err != nil {
+		return err
+	}
+	var ids func(string) error
+	if *idsPath != "" {
+		idf, err := os.Create(*idsPath)
+		if err != nil {
+			return err
+		}
+		defer idf.Close()
+		bw := bufio.NewWriter(idf)
+		defer bw.Flush()
+		ids = func(id string) error {
+			_, err := fmt.Fprintln(bw, id)
+			return err
+		}
+	}
+	rows, err := dataio.Export(idx, w, ids)
+	if err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	fmt.Printf("exported %d vectors from %s\n", rows, coll.collection)
+	return nil
+}
+
+// exportTable writes ids, vectors and metadata as an Arrow IPC stream or a Parquet file
+func exportTable(dir, collection, out, table string) error {
+	idx, err := openCollection(dir)
+	if err != nil {
+		return err
+	}
+	defer idx.Close()
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	export := dataio.ExportIPC
+	if table == "parquet" {
+		export = dataio.ExportParquet
+	}
+	rows, err := export(idx, f, dataio.ColumnMapping{})
+	if err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	fmt.Printf("exported %d vectors from %s\n", rows, collection)
+	return nil
+}
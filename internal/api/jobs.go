@@ -0,0 +1,117 @@
+package api
+
+import (
+	"VectorDatabase/internal/ingest"
+	"VectorDatabase/internal/types"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// maxJobBody bounds the body of a job submit
+const maxJobBody = 256 << 20
+
+// jobItem is one item of a job submit: text to embed, or a vector embedded elsewhere
+type jobItem struct {
+	Text           *string                `json:"text"`
+	Vector         []float32              `json:"vector"`
+	DataType       types.DataType         `json:"data_type"`
+	Metric         types.SimilarityMetric `json:"metric"`
+	Model          string                 `json:"model"`
+	ID             string                 `json:"id"`
+	IdempotencyKey string                 `json:"idempotency_key"`
+}
+
+type submitRequest struct {
+	Items []jobItem `json:"items"`
+}
+
+// NewJobHandler serves asynchronous bulk ingestion on top of m:
+//
+//	POST   /jobs       {"items": [{"text": "...", "id": "doc-1"}, {"vector": [...], "data_type": "text", "metric": "cosine", "model": "m"}]}
+//	                   202 {"id": "<job id>"}, the job runs in the background
+//	GET    /jobs       every retained job
+//	GET    /jobs/{id}  progress: state and accepted, embedded, indexed, failed counts
+//	DELETE /jobs/{id}  cancels the job, 202 with its status
+func NewJobHandler(m *ingest.JobManager) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /jobs", func(w http.ResponseWriter, r *http.Request) {
+		var req submitRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxJobBody)).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid job: %w", err))
+			return
+		}
+		items := make([]ingest.JobItem, len(req.Items))
+		for i, item := range req.Items {
+			if (item.Text == nil) == (item.Vector == nil) {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("item %d: needs either text or vector", i))
+				return
+			}
+			items[i] = ingest.JobItem{
+				Vector:         item.Vector,
+				DataType:       item.DataType,
+				Metric:         item.Metric,
+				Model:          item.Model,
+				ExternalID:     item.ID,
+				IdempotencyKey: item.IdempotencyKey,
+			}
+			if item.Text != nil {
+				items[i].Input = *item.Text
+			}
+		}
+		id, err := m.Submit(items)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		w.Header().Set("Location", "/jobs/"+id)
+		writeJSON(w, http.StatusAccepted, map[string]string{"id": id})
+	})
+	mux.HandleFunc("GET /jobs", func(w http.ResponseWriter, r *http.Request) {
+		jobs := m.Jobs()
+		slices.SortFunc(jobs, func(a, b ingest.JobStatus) int { return strings.Compare(a.ID, b.ID) })
+		writeJSON(w, http.StatusOK, map[string][]ingest.JobStatus{"jobs": jobs})
+	})
+	mux.HandleFunc("GET /jobs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		status, err := m.Status(r.PathValue("id"))
+		if err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+		writeJSON(w, http.StatusOK, status)
+	})
+	mux.HandleFunc("DELETE /jobs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if err := m.Cancel(id); err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+		status, err := m.Status(id)
+		if err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+		writeJSON(w, http.StatusAccepted, status)
+	})
+	return mux
+}
+
+func statusOf(err error) int {
+	if errors.Is(err, ingest.ErrJobNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func writeJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"VectorDatabase/internal/embedder"
+	"VectorDatabase/internal/index"
+	"VectorDatabase/internal/ingest"
+	"VectorDatabase/internal/types"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestServer serves the job API over an Ingester into a fresh linear index
+func newTestServer(t *testing.T) (*httptest.Server, *index.LinearIndex) {
+	t.Helper()
+	e, err := embedder.NewHashingEmbedder(8)
+	if err != nil {
+		t.Fatalf("NewHashingEmbedder failed: %v", err)
+	}
+	if err := types.RegisterModel(embedder.Info(e, "1")); err != nil {
+		t.Fatalf("failed to register model: %v", err)
+	}
+	cfg, err := index.NewIndexConfig(types.LinearIndex, types.ModelType(e.Name()), e.DataType(), e.Metric(), e.Dimension())
+	if err != nil {
+		t.Fatalf("NewIndexConfig failed: %v", err)
+	}
+	idx, err := index.NewLinearIndex(cfg)
+	if err != nil {
+		t.Fatalf("NewLinearIndex failed: %v", err)
+	}
+	ing, err := ingest.NewIngester(e, cfg, idx, nil)
+	if err != nil {
+		t.Fatalf("NewIngester failed: %v", err)
+	}
+	m, err := ingest.NewJobManager(ing, ingest.JobConfig{})
+	if err != nil {
+		t.Fatalf("NewJobManager failed: %v", err)
+	}
+	srv := httptest.NewServer(NewJobHandler(m))
+	t.Cleanup(func() {
+		srv.Close()
+		m.Close()
+	})
+	return srv, idx
+}
+
+func do(t *testing.T, method, url, body string, out any) int {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("bad response body: %v", err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// Contract: POST /jobs answers 202 with a job id at once, GET /jobs/{id} reports the progress until the
+// job is done and GET /jobs lists it.
+func TestJobHandler_SubmitAndPoll(t *testing.T) {
+	srv, idx := newTestServer(t)
+	body := `{"items": [{"text": "alpha", "id": "a"}, {"text": "beta"},
+		{"vector": [1, 2, 3, 4, 5, 6, 7, 8], "data_type": "text", "metric": "cosine", "model": "hashing-bow-8"}, {"text": ""}]}`
+	var submitted map[string]string
+	if code := do(t, http.MethodPost, srv.URL+"/jobs", body, &submitted); code != http.StatusAccepted || submitted["id"] == "" {
+		t.Fatalf("expected 202 with an id, got %d %v", code, submitted)
+	}
+	var status ingest.JobStatus
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if code := do(t, http.MethodGet, srv.URL+"/jobs/"+submitted["id"], "", &status); code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", code)
+		}
+		if status.State != ingest.JobRunning {
+			break
+		}
+	}
+	if status.State != ingest.JobDone || status.Accepted != 4 || status.Indexed != 3 || status.Failed != 1 || len(status.Errors) != 1 {
+		t.Errorf("unexpected status %+v", status)
+	}
+	if _, ok := idx.Get("a"); !ok || idx.Size() != 3 {
+		t.Errorf("expected 3 vectors with id a, got %d", idx.Size())
+	}
+	var list struct{ Jobs []ingest.JobStatus }
+	if code := do(t, http.MethodGet, srv.URL+"/jobs", "", &list); code != http.StatusOK || len(list.Jobs) != 1 {
+		t.Errorf("expected 1 job listed, got %d %+v", code, list)
+	}
+}
+
+// Contract: malformed jobs are 400, unknown jobs 404, DELETE cancels with 202.
+func TestJobHandler_Errors(t *testing.T) {
+	srv, _ := newTestServer(t)
+	tests := []struct {
+		name, method, path, body string
+		want                     int
+	}{
+		{"bad json", http.MethodPost, "/jobs", `{"items": [`, http.StatusBadRequest},
+		{"no items", http.MethodPost, "/jobs", `{"items": []}`, http.StatusBadRequest},
+		{"text and vector", http.MethodPost, "/jobs", `{"items": [{"text": "x", "vector": [1]}]}`, http.StatusBadRequest},
+		{"neither", http.MethodPost, "/jobs", `{"items": [{"id": "x"}]}`, http.StatusBadRequest},
+		{"bad metric", http.MethodPost, "/jobs", `{"items": [{"vector": [1], "metric": "nope"}]}`, http.StatusBadRequest},
+		{"unknown status", http.MethodGet, "/jobs/missing", "", http.StatusNotFound},
+		{"unknown cancel", http.MethodDelete, "/jobs/missing", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out map[string]any
+			if code := do(t, tt.method, srv.URL+tt.path, tt.body, &out); code != tt.want || out["error"] == nil {
+				t.Errorf("expected %d with an error, got %d %v", tt.want, code, out)
+			}
+		})
+	}
+	var submitted map[string]string
+	do(t, http.MethodPost, srv.URL+"/jobs", `{"items": [{"text": "x"}]}`, &submitted)
+	var status ingest.JobStatus
+	if code := do(t, http.MethodDelete, srv.URL+"/jobs/"+submitted["id"], "", &status); code != http.StatusAccepted || status.ID != submitted["id"] {
+		t.Errorf("expected 202 with the status, got %d %+v", code, status)
+	}
+}
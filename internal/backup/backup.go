@@ -0,0 +1,337 @@
+// Package backup writes consistent, versioned archives of collections to a Target and restores them.
+//
+// A backup lives under backups/<id>/: one object per collection file and a manifest.json listing
+// every file with its size and SHA-256, written last so a backup without a manifest is incomplete.
+// An incremental backup only uploads segment files its parent backup doesn't have, its manifest
+// points at the parent's objects for the rest, so every manifest describes a complete restore.
+package backup
+
+import (
+	"VectorDatabase/internal/store"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	manifestVersion = 1
+	manifestName    = "manifest.json"
+	rootPrefix      = "backups/"
+	// idLayout sorts lexicographically in time order
+	idLayout = "20060102T150405.000000000Z"
+)
+
+// FileEntry is one file of a backed up collection
+type FileEntry struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	// Object is the target key holding the content, in an older backup when the file was unchanged
+	Object string `json:"object"`
+}
+
+// CollectionEntry is the state of one collection as of its WAL position LSN
+type CollectionEntry struct {
+	Name  string      `json:"name"`
+	LSN   uint64      `json:"lsn"`
+	Files []FileEntry `json:"files"`
+}
+
+// Manifest describes one backup
+type Manifest struct {
+	Version     int               `json:"version"`
+	ID          string            `json:"id"`
+	Created     time.Time         `json:"created"`
+	Parent      string            `json:"parent,omitempty"`
+	Collections []CollectionEntry `json:"collections"`
+}
+
+// Collection returns the entry of the named collection
+func (m *Manifest) Collection(name string) (CollectionEntry, bool) {
+	for _, c := range m.Collections {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return CollectionEntry{}, false
+}
+
+// Checkpointer is a collection that can hand out a consistent on-disk state while it stays online,
+// *index.SegmentedIndex implements it
+type Checkpointer interface {
+	Checkpoint(fn func(dir string, m store.Manifest) error) error
+}
+
+// Options configure Create
+type Options struct {
+	// Incremental reuses the files of the latest backup on the target that didn't change since
+	Incremental bool
+	// Now is the clock the backup id comes from, default time.Now
+	Now func() time.Time
+}
+
+func manifestKey(id string) string {
+	return rootPrefix + id + "/" + manifestName
+}
+
+// List returns the ids of the complete backups on target, oldest first
+func List(ctx context.Context, target Target) ([]string, error) {
+	keys, err := target.List(ctx, rootPrefix)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, key := range keys {
+		id, rest, ok := strings.Cut(strings.TrimPrefix(key, rootPrefix), "/")
+		if ok && rest == manifestName {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// Latest returns the id of the newest complete backup, fs.ErrNotExist when target holds none
+func Latest(ctx context.Context, target Target) (string, error) {
+	ids, err := List(ctx, target)
+	if err != nil {
+		return "", err
+	}
+	if len(ids) == 0 {
+		return "", fmt.Errorf("no backups: %w", fs.ErrNotExist)
+	}
+	return ids[len(ids)-1], nil
+}
+
+// ReadManifest loads the manifest of backup id
+func ReadManifest(ctx context.Context, target Target, id string) (Manifest, error) {
+	r, err := target.Get(ctx, manifestKey(id))
+	if err != nil {
+		return Manifest{}, err
+	}
+	defer r.Close()
+	var m Manifest
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return Manifest{}, fmt.Errorf("backup %s: invalid manifest: %w", id, err)
+	}
+	if m.Version != manifestVersion {
+		return Manifest{}, fmt.Errorf("backup %s: unsupported manifest version %d", id, m.Version)
+	}
+	return m, nil
+}
+
+// Create backs up collections (by name) to target and returns the manifest of the new backup.
+// Each collection is checkpointed in turn, so it reflects every write acknowledged before its
+// checkpoint; writes keep going meanwhile
+func Create(ctx context.Context, target Target, collections map[string]Checkpointer, opts Options) (Manifest, error) {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	now := opts.Now().UTC()
+	m := Manifest{Version: manifestVersion, ID: now.Format(idLayout), Created: now}
+	var parent Manifest
+	if opts.Incremental {
+		id, err := Latest(ctx, target)
+		switch {
+		case err == nil:
+			if parent, err = ReadManifest(ctx, target, id); err != nil {
+				return Manifest{}, err
+			}
+			m.Parent = id
+		case !errors.Is(err, fs.ErrNotExist):
+			return Manifest{}, err
+		}
+	}
+	if m.ID <= m.Parent {
+		return Manifest{}, fmt.Errorf("backup id %s isn't newer than the latest backup %s", m.ID, m.Parent)
+	}
+	names := make([]string, 0, len(collections))
+	for name := range collections {
+		if !validCollectionName(name) {
+			return Manifest{}, fmt.Errorf("invalid collection name %q", name)
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		prev, _ := parent.Collection(name)
+		var entry CollectionEntry
+		err := collections[name].Checkpoint(func(dir string, sm store.Manifest) error {
+			var err error
+			entry, err = backupCollection(ctx, target, m.ID, name, dir, sm, prev)
+			return err
+		})
+		if err != nil {
+			return Manifest{}, fmt.Errorf("collection %s: %w", name, err)
+		}
+		m.Collections = append(m.Collections, entry)
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return Manifest{}, err
+	}
+	if err := putBytes(ctx, target, manifestKey(m.ID), data); err != nil {
+		return Manifest{}, err
+	}
+	return m, nil
+}
+
+// backupCollection uploads the segment files of sm and its MANIFEST. Segment files are immutable
+// (flushes and compactions write new ones), so one that prev lists already is reused
+func backupCollection(ctx context.Context, target Target, id, name, dir string, sm store.Manifest, prev CollectionEntry) (CollectionEntry, error) {
+	entry := CollectionEntry{Name: name, LSN: sm.FlushedLSN}
+	for _, seg := range sm.Segments {
+		if err := ctx.Err(); err != nil {
+			return CollectionEntry{}, err
+		}
+		if seg.MaxLSN <= prev.LSN {
+			if i := slices.IndexFunc(prev.Files, func(f FileEntry) bool { return f.Name == seg.File }); i >= 0 {
+				entry.Files = append(entry.Files, prev.Files[i])
+				continue
+			}
+		}
+		f, err := putFile(ctx, target, rootPrefix+id+"/"+name+"/"+seg.File, filepath.Join(dir, seg.File))
+		if err != nil {
+			return CollectionEntry{}, err
+		}
+		entry.Files = append(entry.Files, f)
+	}
+	// the live MANIFEST may already be newer than sm, so it is encoded rather than copied
+	data, err := store.EncodeManifest(sm)
+	if err != nil {
+		return CollectionEntry{}, err
+	}
+	key := rootPrefix + id + "/" + name + "/" + store.ManifestFileName
+	if err := putBytes(ctx, target, key, data); err != nil {
+		return CollectionEntry{}, err
+	}
+	sum := sha256.Sum256(data)
+	entry.Files = append(entry.Files, FileEntry{Name: store.ManifestFileName, Size: int64(len(data)), SHA256: hex.EncodeToString(sum[:]), Object: key})
+	return entry, nil
+}
+
+func putBytes(ctx context.Context, target Target, key string, data []byte) error {
+	sum := sha256.Sum256(data)
+	return target.Put(ctx, key, bytes.NewReader(data), int64(len(data)), hex.EncodeToString(sum[:]))
+}
+
+// putFile hashes the file first since S3 wants the digest before the body
+func putFile(ctx context.Context, target Target, key, path string) (FileEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return FileEntry{}, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return FileEntry{}, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return FileEntry{}, err
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+	if err := target.Put(ctx, key, io.LimitReader(f, size), size, sum); err != nil {
+		return FileEntry{}, err
+	}
+	return FileEntry{Name: filepath.Base(path), Size: size, SHA256: sum, Object: key}, nil
+}
+
+// Restore writes the named collections of backup id (all when names is empty) below dataDir.
+// Existing collection directories are never overwritten; every file is checked against its
+// checksum and a collection only appears once all of its files are in place
+func Restore(ctx context.Context, target Target, id, dataDir string, names []string) (Manifest, error) {
+	m, err := ReadManifest(ctx, target, id)
+	if err != nil {
+		return Manifest{}, err
+	}
+	var entries []CollectionEntry
+	if len(names) == 0 {
+		entries = m.Collections
+	}
+	for _, name := range names {
+		c, ok := m.Collection(name)
+		if !ok {
+			return Manifest{}, fmt.Errorf("backup %s has no collection %s", id, name)
+		}
+		entries = append(entries, c)
+	}
+	for _, c := range entries {
+		// the name comes from the manifest, a tampered one must not reach outside dataDir
+		if !validCollectionName(c.Name) {
+			return Manifest{}, fmt.Errorf("backup %s: invalid collection name %q", id, c.Name)
+		}
+	}
+	for _, c := range entries {
+		if _, err := os.Stat(filepath.Join(dataDir, c.Name)); !errors.Is(err, fs.ErrNotExist) {
+			return Manifest{}, fmt.Errorf("collection %s already exists in %s", c.Name, dataDir)
+		}
+	}
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return Manifest{}, err
+	}
+	for _, c := range entries {
+		if err := restoreCollection(ctx, target, dataDir, c); err != nil {
+			return Manifest{}, fmt.Errorf("collection %s: %w", c.Name, err)
+		}
+	}
+	return m, nil
+}
+
+// validCollectionName accepts names that are a single directory inside the data directory
+func validCollectionName(name string) bool {
+	return filepath.IsLocal(name) && !strings.ContainsAny(name, `/\`)
+}
+
+func restoreCollection(ctx context.Context, target Target, dataDir string, c CollectionEntry) error {
+	tmp := filepath.Join(dataDir, c.Name+".restore")
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	if err := os.Mkdir(tmp, 0o755); err != nil {
+		return err
+	}
+	for _, f := range c.Files {
+		if err := fetchFile(ctx, target, f, tmp); err != nil {
+			os.RemoveAll(tmp)
+			return err
+		}
+	}
+	return os.Rename(tmp, filepath.Join(dataDir, c.Name))
+}
+
+func fetchFile(ctx context.Context, target Target, entry FileEntry, dir string) error {
+	if entry.Name != path.Base(entry.Name) || !filepath.IsLocal(entry.Name) {
+		return fmt.Errorf("invalid file name %q", entry.Name)
+	}
+	r, err := target.Get(ctx, entry.Object)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	out, err := os.Create(filepath.Join(dir, entry.Name))
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(out, h), r)
+	if err := errors.Join(err, out.Sync(), out.Close()); err != nil {
+		return err
+	}
+	if n != entry.Size || hex.EncodeToString(h.Sum(nil)) != entry.SHA256 {
+		return fmt.Errorf("%w: %s doesn't match its checksum", store.ErrCorrupt, entry.Object)
+	}
+	return nil
+}
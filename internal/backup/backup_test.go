@@ -0,0 +1,198 @@
+package backup
+
+import (
+	"VectorDatabase/internal/index"
+	"VectorDatabase/internal/store"
+	"VectorDatabase/internal/types"
+	v "VectorDatabase/internal/vector"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Helper to open a collection of 2-d vectors that flushes every 3 writes
+func setupCollection(t *testing.T, dir string) *index.SegmentedIndex {
+	cfg, _ := index.NewIndexConfig(types.LinearIndex, types.Testmodel, types.Text, types.Cosine, 2)
+	si, err := index.OpenSegmentedIndex(dir, cfg, index.SegmentedOptions{FlushThreshold: 3, CompactionThreshold: 100})
+	if err != nil {
+		t.Fatalf("failed to open collection: %v", err)
+	}
+	return si
+}
+
+func addVectors(t *testing.T, si *index.SegmentedIndex, from, to int) {
+	for i := from; i < to; i++ {
+		vec, _ := v.NewVector([]float32{float32(i), 1}, 2)
+		if _, err := si.AddWithMetadata(fmt.Sprintf("vec-%d", i), vec, index.Metadata{"n": float64(i)}); err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+	}
+}
+
+// clock returns a time one second later on every call, so backup ids always increase
+func clock() func() time.Time {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	return func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+}
+
+// Post-condition: a restored backup opens as a collection holding every vector written before the backup.
+func TestBackup_RestoreFull(t *testing.T) {
+	ctx := context.Background()
+	si := setupCollection(t, filepath.Join(t.TempDir(), "docs"))
+	defer si.Close()
+	// 7 writes: two flushed segments and one unflushed vector the checkpoint has to flush
+	addVectors(t, si, 0, 7)
+	target := LocalTarget{Dir: t.TempDir()}
+	m, err := Create(ctx, target, map[string]Checkpointer{"docs": si}, Options{Now: clock()})
+	if err != nil {
+		t.Fatalf("backup failed: %v", err)
+	}
+	// writes after the backup are not part of it
+	addVectors(t, si, 7, 9)
+
+	if ids, _ := List(ctx, target); len(ids) != 1 || ids[0] != m.ID {
+		t.Fatalf("expected backup %s to be listed, got %v", m.ID, ids)
+	}
+	dataDir := t.TempDir()
+	if _, err := Restore(ctx, target, m.ID, dataDir, nil); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	restored := setupCollection(t, filepath.Join(dataDir, "docs"))
+	defer restored.Close()
+	if restored.Size() != 7 {
+		t.Errorf("expected 7 restored vectors, got %d", restored.Size())
+	}
+	if md, ok := restored.Metadata("vec-6"); !ok || md["n"] != float64(6) {
+		t.Errorf("metadata not restored: %v", md)
+	}
+	if _, ok := restored.Get("vec-7"); ok {
+		t.Error("vector written after the backup was restored")
+	}
+	if _, err := Restore(ctx, target, m.ID, dataDir, nil); err == nil {
+		t.Error("expected restore over an existing collection to fail")
+	}
+}
+
+// Contract: an incremental backup only uploads segments its parent doesn't hold and still restores completely.
+func TestBackup_Incremental(t *testing.T) {
+	ctx := context.Background()
+	si := setupCollection(t, filepath.Join(t.TempDir(), "docs"))
+	defer si.Close()
+	addVectors(t, si, 0, 6)
+	target := LocalTarget{Dir: t.TempDir()}
+	now := clock()
+	full, err := Create(ctx, target, map[string]Checkpointer{"docs": si}, Options{Incremental: true, Now: now})
+	if err != nil {
+		t.Fatalf("first backup failed: %v", err)
+	}
+	if full.Parent != "" {
+		t.Errorf("first backup has parent %s", full.Parent)
+	}
+	addVectors(t, si, 6, 9)
+	si.Delete("vec-0")
+	incr, err := Create(ctx, target, map[string]Checkpointer{"docs": si}, Options{Incremental: true, Now: now})
+	if err != nil {
+		t.Fatalf("incremental backup failed: %v", err)
+	}
+	if incr.Parent != full.ID {
+		t.Errorf("expected parent %s, got %s", full.ID, incr.Parent)
+	}
+	c, _ := incr.Collection("docs")
+	reused, uploaded := 0, 0
+	for _, f := range c.Files {
+		switch {
+		case strings.HasPrefix(f.Object, rootPrefix+full.ID+"/"):
+			reused++
+		case f.Name != store.ManifestFileName:
+			uploaded++
+		}
+	}
+	if reused != 2 || uploaded != 2 {
+		t.Errorf("expected 2 reused and 2 new segments, got %d and %d: %+v", reused, uploaded, c.Files)
+	}
+	prev, _ := full.Collection("docs")
+	if c.LSN <= prev.LSN {
+		t.Errorf("expected the WAL position to advance, got %d after %d", c.LSN, prev.LSN)
+	}
+
+	dataDir := t.TempDir()
+	if _, err := Restore(ctx, target, incr.ID, dataDir, []string{"docs"}); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	restored := setupCollection(t, filepath.Join(dataDir, "docs"))
+	defer restored.Close()
+	if restored.Size() != 8 {
+		t.Errorf("expected 8 restored vectors, got %d", restored.Size())
+	}
+	if _, ok := restored.Get("vec-0"); ok {
+		t.Error("deleted vector came back")
+	}
+}
+
+// Contract: restore refuses a file whose content doesn't match the manifest and leaves no collection behind.
+func TestBackup_ChecksumMismatch(t *testing.T) {
+	ctx := context.Background()
+	si := setupCollection(t, filepath.Join(t.TempDir(), "docs"))
+	defer si.Close()
+	addVectors(t, si, 0, 3)
+	target := LocalTarget{Dir: t.TempDir()}
+	m, err := Create(ctx, target, map[string]Checkpointer{"docs": si}, Options{})
+	if err != nil {
+		t.Fatalf("backup failed: %v", err)
+	}
+	c, _ := m.Collection("docs")
+	path := filepath.Join(target.Dir, filepath.FromSlash(c.Files[0].Object))
+	data, _ := os.ReadFile(path)
+	data[len(data)/2] ^= 0xff
+	os.WriteFile(path, data, 0o644)
+
+	dataDir := t.TempDir()
+	_, err = Restore(ctx, target, m.ID, dataDir, nil)
+	if !errors.Is(err, store.ErrCorrupt) {
+		t.Errorf("expected store.ErrCorrupt, got %v", err)
+	}
+	if entries, _ := os.ReadDir(dataDir); len(entries) != 0 {
+		t.Errorf("failed restore left %v behind", entries)
+	}
+	if _, err := Restore(ctx, target, m.ID, t.TempDir(), []string{"other"}); err == nil {
+		t.Error("expected restoring an unknown collection to fail")
+	}
+}
+
+// Contract: a manifest naming a collection outside the data directory is rejected before anything is written.
+func TestBackup_TamperedCollectionName(t *testing.T) {
+	ctx := context.Background()
+	si := setupCollection(t, filepath.Join(t.TempDir(), "docs"))
+	defer si.Close()
+	addVectors(t, si, 0, 3)
+	target := LocalTarget{Dir: t.TempDir()}
+	m, err := Create(ctx, target, map[string]Checkpointer{"docs": si}, Options{})
+	if err != nil {
+		t.Fatalf("backup failed: %v", err)
+	}
+	path := filepath.Join(target.Dir, filepath.FromSlash(manifestKey(m.ID)))
+	for _, name := range []string{"../escaped", "a/b", `a\b`, "", "/abs"} {
+		t.Run(name, func(t *testing.T) {
+			m.Collections[0].Name = name
+			data, _ := json.Marshal(m)
+			os.WriteFile(path, data, 0o644)
+			parent := t.TempDir()
+			dataDir := filepath.Join(parent, "data")
+			if _, err := Restore(ctx, target, m.ID, dataDir, nil); err == nil {
+				t.Error("expected the tampered name to be rejected")
+			}
+			if entries, _ := os.ReadDir(parent); len(entries) != 0 {
+				t.Errorf("rejected restore wrote %v", entries)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Target is where backups are stored, keys are slash separated paths relative to the target root
+type Target interface {
+	// Put stores size bytes from r under key, sha256 is the hex digest of the content
+	Put(ctx context.Context, key string, r io.Reader, size int64, sha256 string) error
+	// Get opens the object under key, a missing key is reported as fs.ErrNotExist
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// List returns every key below prefix, sorted
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// LocalTarget stores objects as files below Dir
+type LocalTarget struct {
+	Dir string
+}
+
+func (l LocalTarget) path(key string) (string, error) {
+	clean := filepath.FromSlash(key)
+	if !filepath.IsLocal(clean) {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return filepath.Join(l.Dir, clean), nil
+}
+
+// Put writes to a temp file and renames it, so a crashed backup never leaves a partial object
+func (l LocalTarget) Put(ctx context.Context, key string, r io.Reader, size int64, _ string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(f, r)
+	if err == nil && n != size {
+		err = fmt.Errorf("object %s: wrote %d bytes, expected %d", key, n, size)
+	}
+	if err := errors.Join(err, f.Sync(), f.Close()); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (l LocalTarget) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+func (l LocalTarget) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	err := filepath.WalkDir(l.Dir, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil || d.IsDir() || strings.HasSuffix(path, ".tmp") {
+			return err
+		}
+		rel, err := filepath.Rel(l.Dir, path)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	slices.Sort(keys)
+	return keys, err
+}
+
+// S3Config are the connection settings of an S3 compatible target
+type S3Config struct {
+	// Endpoint is the base URL, e.g. http://localhost:9000 for MinIO, default https://s3.<region>.amazonaws.com
+	Endpoint  string
+	Region    string
+	AccessKey string
+	SecretKey string
+}
+
+// S3ConfigFromEnv reads AWS_ENDPOINT_URL, AWS_REGION, AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY
+func S3ConfigFromEnv() S3Config {
+	return S3Config{
+		Endpoint:  os.Getenv("AWS_ENDPOINT_URL"),
+		Region:    os.Getenv("AWS_REGION"),
+		AccessKey: os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+	}
+}
+
+// OpenTarget opens "s3://bucket/prefix" as an S3 target with cfg, anything else (a path or a file:// URL)
+// as a LocalTarget
+func OpenTarget(location string, cfg S3Config) (Target, error) {
+	u, err := url.Parse(location)
+	if err != nil || u.Scheme == "" || len(u.Scheme) == 1 {
+		// plain path, a one letter scheme is a windows drive
+		return LocalTarget{Dir: location}, nil
+	}
+	switch u.Scheme {
+	case "file":
+		return LocalTarget{Dir: filepath.FromSlash(u.Path)}, nil
+	case "s3":
+		if u.Host == "" {
+			return nil, errors.New("s3 target needs a bucket: s3://bucket/prefix")
+		}
+		s3, err := NewS3Target(cfg, u.Host, strings.Trim(u.Path, "/"))
+		if err != nil {
+			return nil, err
+		}
+		return s3, nil
+	default:
+		return nil, fmt.Errorf("unsupported backup target scheme %q", u.Scheme)
+	}
+}
@@ -0,0 +1,88 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// Contract: OpenTarget maps s3:// URLs to S3 targets and paths or file:// URLs to local targets.
+func TestOpenTarget(t *testing.T) {
+	creds := S3Config{AccessKey: "key", SecretKey: "secret"}
+	tests := []struct {
+		location string
+		cfg      S3Config
+		want     string // "local", "s3" or "error"
+	}{
+		{"/var/backups", S3Config{}, "local"},
+		{"relative/dir", S3Config{}, "local"},
+		{"file:///var/backups", S3Config{}, "local"},
+		{"s3://bucket/some/prefix", creds, "s3"},
+		{"s3://bucket", creds, "s3"},
+		{"s3://bucket/prefix", S3Config{}, "error"},
+		{"s3:///prefix", creds, "error"},
+		{"ftp://host/dir", S3Config{}, "error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.location, func(t *testing.T) {
+			target, err := OpenTarget(tt.location, tt.cfg)
+			got := "error"
+			switch target.(type) {
+			case LocalTarget:
+				got = "local"
+			case *S3Target:
+				got = "s3"
+			}
+			if got != tt.want {
+				t.Errorf("got %s (err %v), want %s", got, err, tt.want)
+			}
+		})
+	}
+	target, _ := OpenTarget("s3://bucket/a/b/", creds)
+	if s3 := target.(*S3Target); s3.bucket != "bucket" || s3.prefix != "a/b" || s3.cfg.Region != "us-east-1" {
+		t.Errorf("unexpected s3 target %+v", s3)
+	}
+}
+
+// Contract: a local target stores objects as files, lists them sorted and rejects keys escaping its directory.
+func TestLocalTarget(t *testing.T) {
+	dir := t.TempDir()
+	target := LocalTarget{Dir: dir}
+	ctx := context.Background()
+	for _, key := range []string{"b/2", "a/1", "b/1"} {
+		if err := putBytes(ctx, target, key, []byte(key)); err != nil {
+			t.Fatalf("put %s failed: %v", key, err)
+		}
+	}
+	keys, err := target.List(ctx, "b/")
+	if err != nil || !slices.Equal(keys, []string{"b/1", "b/2"}) {
+		t.Errorf("unexpected list %v (err %v)", keys, err)
+	}
+	r, err := target.Get(ctx, "a/1")
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if string(data) != "a/1" {
+		t.Errorf("unexpected content %q", data)
+	}
+	if _, err := target.Get(ctx, "a/missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+	if err := putBytes(ctx, target, "../escape", nil); err == nil {
+		t.Error("expected a key outside the target to be rejected")
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "escape")); err == nil {
+		t.Error("object written outside the target directory")
+	}
+	empty, err := LocalTarget{Dir: filepath.Join(dir, "none")}.List(ctx, "")
+	if err != nil || len(empty) != 0 {
+		t.Errorf("expected an empty list for a missing directory, got %v (err %v)", empty, err)
+	}
+}
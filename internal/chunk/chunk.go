@@ -0,0 +1,258 @@
+package chunk
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Chunk is a piece of a document, Text is document[Start:End] (byte offsets)
+type Chunk struct {
+	Text  string
+	Start int
+	End   int
+	// Heading is the path of Markdown headings the chunk is under ("Install > Linux"), empty for the
+	// other chunkers and text before the first heading
+	Heading string
+}
+
+// Chunker splits a document into chunks in document order, whitespace between chunks is dropped
+type Chunker interface {
+	Chunk(text string) []Chunk
+}
+
+// span is a [start, end) byte range of the document
+type span struct{ start, end int }
+
+func newChunk(text string, s span) Chunk {
+	return Chunk{Text: text[s.start:s.end], Start: s.start, End: s.end}
+}
+
+// TokenChunker cuts windows of size whitespace separated tokens, consecutive windows share overlap tokens
+type TokenChunker struct {
+	size    int
+	overlap int
+}
+
+func NewTokenChunker(size, overlap int) (*TokenChunker, error) {
+	if size <= 0 {
+		return nil, errors.New("chunk size must be a positive integer")
+	}
+	if overlap < 0 || overlap >= size {
+		return nil, errors.New("overlap must be at least zero and smaller than the chunk size")
+	}
+	return &TokenChunker{size: size, overlap: overlap}, nil
+}
+
+func (c *TokenChunker) Chunk(text string) []Chunk {
+	tokens := fields(text)
+	var chunks []Chunk
+	for first := 0; first < len(tokens); first += c.size - c.overlap {
+		last := min(first+c.size, len(tokens)) - 1
+		chunks = append(chunks, newChunk(text, span{tokens[first].start, tokens[last].end}))
+		if last == len(tokens)-1 {
+			break
+		}
+	}
+	return chunks
+}
+
+// fields finds the runs of non space runes
+func fields(text string) []span {
+	var spans []span
+	start := -1
+	for i, r := range text {
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				spans = append(spans, span{start, i})
+				start = -1
+			}
+		} else if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, span{start, len(text)})
+	}
+	return spans
+}
+
+// SentenceChunker packs whole sentences into chunks of up to maxChars bytes without crossing a paragraph
+// (blank line) boundary, a longer sentence is a chunk of its own. With maxChars 0 every paragraph is
+// one chunk
+type SentenceChunker struct {
+	maxChars int
+}
+
+func NewSentenceChunker(maxChars int) (*SentenceChunker, error) {
+	if maxChars < 0 {
+		return nil, errors.New("max chunk length can't be negative")
+	}
+	return &SentenceChunker{maxChars: maxChars}, nil
+}
+
+func (c *SentenceChunker) Chunk(text string) []Chunk {
+	var chunks []Chunk
+	for _, para := range paragraphs(text) {
+		if c.maxChars == 0 {
+			chunks = append(chunks, newChunk(text, para))
+			continue
+		}
+		current := span{-1, -1}
+		for _, sentence := range sentences(text, para) {
+			if current.start >= 0 && sentence.end-current.start <= c.maxChars {
+				current.end = sentence.end
+				continue
+			}
+			if current.start >= 0 {
+				chunks = append(chunks, newChunk(text, current))
+			}
+			current = sentence
+		}
+		if current.start >= 0 {
+			chunks = append(chunks, newChunk(text, current))
+		}
+	}
+	return chunks
+}
+
+var blankLine = regexp.MustCompile(`\n[ \t\r]*\n`)
+
+// paragraphs splits text at blank lines, trimmed of surrounding whitespace
+func paragraphs(text string) []span {
+	var spans []span
+	start := 0
+	for _, sep := range blankLine.FindAllStringIndex(text, -1) {
+		spans = appendTrimmed(spans, text, span{start, sep[0]})
+		start = sep[1]
+	}
+	return appendTrimmed(spans, text, span{start, len(text)})
+}
+
+// sentences splits a paragraph after ., ! or ? (and closing quotes or brackets) followed by whitespace
+func sentences(text string, para span) []span {
+	var spans []span
+	start := para.start
+	for i := para.start; i < para.end; {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+		if r != '.' && r != '!' && r != '?' {
+			continue
+		}
+		for i < para.end {
+			closing, size := utf8.DecodeRuneInString(text[i:])
+			if !strings.ContainsRune(`"')]”’`, closing) {
+				break
+			}
+			i += size
+		}
+		if i == para.end {
+			break
+		}
+		if next, _ := utf8.DecodeRuneInString(text[i:]); unicode.IsSpace(next) {
+			spans = appendTrimmed(spans, text, span{start, i})
+			start = i
+		}
+	}
+	return appendTrimmed(spans, text, span{start, para.end})
+}
+
+// appendTrimmed appends s without leading and trailing whitespace, unless nothing is left
+func appendTrimmed(spans []span, text string, s span) []span {
+	inner := text[s.start:s.end]
+	trimmed := strings.TrimLeftFunc(inner, unicode.IsSpace)
+	s.start += len(inner) - len(trimmed)
+	trimmed = strings.TrimRightFunc(trimmed, unicode.IsSpace)
+	if trimmed == "" {
+		return spans
+	}
+	s.end = s.start + len(trimmed)
+	return append(spans, s)
+}
+
+// MarkdownChunker makes a chunk of every section, from a heading to the next one. Sections longer than
+// maxChars are split further like the SentenceChunker does, 0 never splits. Headings in fenced code blocks
+// don't count
+type MarkdownChunker struct {
+	sentences *SentenceChunker
+}
+
+func NewMarkdownChunker(maxChars int) (*MarkdownChunker, error) {
+	sentences, err := NewSentenceChunker(maxChars)
+	if err != nil {
+		return nil, err
+	}
+	return &MarkdownChunker{sentences: sentences}, nil
+}
+
+var (
+	atxHeading = regexp.MustCompile(`^ {0,3}(#{1,6})[ \t]+(.*?)(?:[ \t]+#+)?[ \t]*$`)
+	codeFence  = regexp.MustCompile("^ {0,3}(```|~~~)")
+)
+
+func (c *MarkdownChunker) Chunk(text string) []Chunk {
+	var chunks []Chunk
+	var path []string // headings by level, path[i] is the level i+1 heading
+	section, heading := 0, ""
+	flush := func(end int) {
+		body := span{section, end}
+		if c.sentences.maxChars == 0 || end-section <= c.sentences.maxChars {
+			if trimmed := appendTrimmed(nil, text, body); len(trimmed) > 0 {
+				ch := newChunk(text, trimmed[0])
+				ch.Heading = heading
+				chunks = append(chunks, ch)
+			}
+			return
+		}
+		for _, ch := range c.sentences.Chunk(text[section:end]) {
+			ch.Start += section
+			ch.End += section
+			ch.Heading = heading
+			chunks = append(chunks, ch)
+		}
+	}
+	fence := ""
+	for lineStart := 0; lineStart < len(text); {
+		lineEnd := len(text)
+		if nl := strings.IndexByte(text[lineStart:], '\n'); nl >= 0 {
+			lineEnd = lineStart + nl
+		}
+		line := strings.TrimSuffix(text[lineStart:lineEnd], "\r")
+		if m := codeFence.FindStringSubmatch(line); m != nil {
+			if fence == "" {
+				fence = m[1]
+			} else if m[1] == fence {
+				fence = ""
+			}
+		} else if m := atxHeading.FindStringSubmatch(line); m != nil && fence == "" {
+			flush(lineStart)
+			level := len(m[1])
+			path = path[:min(level-1, len(path))]
+			for len(path) < level-1 {
+				// a skipped level, like ### under #
+				path = append(path, "")
+			}
+			path = append(path, m[2])
+			section, heading = lineStart, joinHeadings(path)
+		}
+		lineStart = lineEnd + 1
+	}
+	flush(len(text))
+	return chunks
+}
+
+func joinHeadings(path []string) string {
+	var parts []string
+	for _, h := range path {
+		if h != "" {
+			parts = append(parts, h)
+		}
+	}
+	return strings.Join(parts, " > ")
+}
+
+var _ Chunker = (*TokenChunker)(nil)
+var _ Chunker = (*SentenceChunker)(nil)
+var _ Chunker = (*MarkdownChunker)(nil)
@@ -0,0 +1,132 @@
+package chunk
+
+import (
+	"strings"
+	"testing"
+)
+
+func texts(chunks []Chunk) []string {
+	out := make([]string, len(chunks))
+	for i, ch := range chunks {
+		out[i] = ch.Text
+	}
+	return out
+}
+
+// checkOffsets verifies the invariant every chunker keeps: Text is document[Start:End], in document order
+func checkOffsets(t *testing.T, doc string, chunks []Chunk) {
+	t.Helper()
+	for i, ch := range chunks {
+		if ch.Start < 0 || ch.End > len(doc) || doc[ch.Start:ch.End] != ch.Text {
+			t.Fatalf("chunk %d: offsets [%d, %d) don't match %q", i, ch.Start, ch.End, ch.Text)
+		}
+		if i > 0 && ch.Start <= chunks[i-1].Start {
+			t.Fatalf("chunk %d out of order", i)
+		}
+	}
+}
+
+// Contract: the token chunker cuts windows of size tokens, each starting size-overlap tokens after the
+// previous one, and the last window ends at the last token.
+func TestTokenChunker(t *testing.T) {
+	doc := "one two  three\nfour five six seven"
+	tests := []struct {
+		name          string
+		size, overlap int
+		want          []string
+	}{
+		{"no overlap", 3, 0, []string{"one two  three", "four five six", "seven"}},
+		{"overlap", 3, 1, []string{"one two  three", "three\nfour five", "five six seven"}},
+		{"larger than doc", 10, 2, []string{doc}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewTokenChunker(tt.size, tt.overlap)
+			if err != nil {
+				t.Fatalf("NewTokenChunker failed: %v", err)
+			}
+			chunks := c.Chunk(doc)
+			checkOffsets(t, doc, chunks)
+			if got := texts(chunks); strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+	if _, err := NewTokenChunker(3, 3); err == nil {
+		t.Error("expected overlap equal to the size to fail")
+	}
+	c, _ := NewTokenChunker(3, 0)
+	if chunks := c.Chunk(" \n\t "); len(chunks) != 0 {
+		t.Errorf("expected no chunks for blank text, got %q", texts(chunks))
+	}
+}
+
+// Contract: the sentence chunker packs sentences up to maxChars, never across paragraphs, keeps closing
+// quotes and brackets with their sentence, and makes a chunk per paragraph with maxChars 0.
+func TestSentenceChunker(t *testing.T) {
+	doc := "First one. Second one! Third?\n\n  A new paragraph starts (here.) \"Quoted.\" Done"
+	tests := []struct {
+		name     string
+		maxChars int
+		want     []string
+	}{
+		{"paragraphs", 0, []string{"First one. Second one! Third?", "A new paragraph starts (here.) \"Quoted.\" Done"}},
+		{"one sentence each", 1, []string{"First one.", "Second one!", "Third?", "A new paragraph starts (here.)", "\"Quoted.\"", "Done"}},
+		{"packed", 22, []string{"First one. Second one!", "Third?", "A new paragraph starts (here.)", "\"Quoted.\" Done"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := NewSentenceChunker(tt.maxChars)
+			chunks := c.Chunk(doc)
+			checkOffsets(t, doc, chunks)
+			if got := texts(chunks); strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+	if _, err := NewSentenceChunker(-1); err == nil {
+		t.Error("expected a negative length to fail")
+	}
+}
+
+// Contract: the Markdown chunker makes a chunk per section with its heading path, ignores headings in
+// code fences, and splits sections longer than maxChars keeping the heading.
+func TestMarkdownChunker(t *testing.T) {
+	doc := "Intro text.\n# Guide\nWelcome.\n## Install\n```sh\n# not a heading\n```\n### Linux ###\nUse apt. Then run it.\n# FAQ\n"
+	c, _ := NewMarkdownChunker(0)
+	chunks := c.Chunk(doc)
+	checkOffsets(t, doc, chunks)
+	want := []struct{ heading, prefix string }{
+		{"", "Intro text."},
+		{"Guide", "# Guide"},
+		{"Guide > Install", "## Install\n```sh\n# not a heading"},
+		{"Guide > Install > Linux", "### Linux ###"},
+		{"FAQ", "# FAQ"},
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("expected %d chunks, got %q", len(want), texts(chunks))
+	}
+	for i, w := range want {
+		if chunks[i].Heading != w.heading || !strings.HasPrefix(chunks[i].Text, w.prefix) {
+			t.Errorf("chunk %d: expected %q under %q, got %q under %q", i, w.prefix, w.heading, chunks[i].Text, chunks[i].Heading)
+		}
+	}
+
+	skipped, _ := NewMarkdownChunker(0)
+	if got := skipped.Chunk("# Top\n### Deep\ntext")[1].Heading; got != "Top > Deep" {
+		t.Errorf("expected a skipped level to be left out of the path, got %q", got)
+	}
+
+	split, _ := NewMarkdownChunker(25)
+	chunks = split.Chunk(doc)
+	checkOffsets(t, doc, chunks)
+	var linux []string
+	for _, ch := range chunks {
+		if ch.Heading == "Guide > Install > Linux" {
+			linux = append(linux, ch.Text)
+		}
+	}
+	if strings.Join(linux, "|") != "### Linux ###\nUse apt.|Then run it." {
+		t.Errorf("expected the long section split by sentence, got %q", linux)
+	}
+}
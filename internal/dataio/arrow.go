@@ -0,0 +1,392 @@
+package dataio
+
+import (
+	"VectorDatabase/internal/index"
+	v "VectorDatabase/internal/vector"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+)
+
+// Arrow tables of a collection have an "id" string column, an "embedding" FixedSizeList<float32>
+// column and one nullable column per metadata field: utf8 for strings, float64 for numbers and bool
+// for booleans; a field holding mixed or nested values becomes a utf8 column of JSON text
+const arrowBatchRows = 1024
+
+// ExportSource is a collection Arrow and Parquet exports can read, metadata is exported when it
+// also implements index.MetadataSource
+type ExportSource interface {
+	index.RangeIndex
+	Get(id string) (*v.Vector, bool)
+	Dimension() int
+}
+
+// rangeIDs lists the ids of src in Range order. Vectors and metadata are read outside Range, fn can't
+// call back into an index holding its lock
+func rangeIDs(src ExportSource) []string {
+	var ids []string
+	src.Range(func(id string, _ *v.Vector) bool {
+		ids = append(ids, id)
+		return true
+	})
+	return ids
+}
+
+// collectionSchema scans the metadata of ids once to give every field a column type
+func collectionSchema(src ExportSource, ids []string, mapping ColumnMapping) (*arrow.Schema, []string) {
+	kinds := make(map[string]arrow.DataType)
+	if source, ok := src.(index.MetadataSource); ok {
+		for _, id := range ids {
+			md, _ := source.Metadata(id)
+			for field, val := range md {
+				if field == mapping.ID || field == mapping.Vector || val == nil {
+					continue
+				}
+				typ := arrowTypeOf(val)
+				if seen, ok := kinds[field]; ok && !arrow.TypeEqual(seen, typ) {
+					typ = arrow.BinaryTypes.String
+				}
+				kinds[field] = typ
+			}
+		}
+	}
+	fields := []arrow.Field{
+		{Name: mapping.ID, Type: arrow.BinaryTypes.String},
+		{Name: mapping.Vector, Type: arrow.FixedSizeListOf(int32(src.Dimension()), arrow.PrimitiveTypes.Float32)},
+	}
+	columns := slices.Sorted(maps.Keys(kinds))
+	for _, name := range columns {
+		fields = append(fields, arrow.Field{Name: name, Type: kinds[name], Nullable: true})
+	}
+	return arrow.NewSchema(fields, nil), columns
+}
+
+func arrowTypeOf(val any) arrow.DataType {
+	switch val.(type) {
+	case float64:
+		return arrow.PrimitiveTypes.Float64
+	case bool:
+		return arrow.FixedWidthTypes.Boolean
+	default:
+		return arrow.BinaryTypes.String
+	}
+}
+
+// exportArrow builds record batches of the vectors of ids and hands them to sink, ids deleted since
+// they were listed are left out
+func exportArrow(src ExportSource, ids []string, schema *arrow.Schema, columns []string, sink func(arrow.RecordBatch) error) (int, error) {
+	source, _ := src.(index.MetadataSource)
+	b := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer b.Release()
+	idCol := b.Field(0).(*array.StringBuilder)
+	vecs := b.Field(1).(*array.FixedSizeListBuilder)
+	values := vecs.ValueBuilder().(*array.Float32Builder)
+	pending, rows := 0, 0
+	var err error
+	write := func() error {
+		rec := b.NewRecordBatch()
+		defer rec.Release()
+		rows += pending
+		pending = 0
+		return sink(rec)
+	}
+	for _, id := range ids {
+		vec, ok := src.Get(id)
+		if !ok {
+			continue
+		}
+		idCol.Append(id)
+		vecs.Append(true)
+		values.AppendValues(vec.Values(), nil)
+		var md index.Metadata
+		if source != nil {
+			md, _ = source.Metadata(id)
+		}
+		for i, name := range columns {
+			appendMetadata(b.Field(i+2), md[name])
+		}
+		pending++
+		if pending == arrowBatchRows {
+			if err = write(); err != nil {
+				break
+			}
+		}
+	}
+	if err == nil && (pending > 0 || rows == 0) {
+		err = write()
+	}
+	return rows, err
+}
+
+// appendMetadata appends val to the column of its field, a value not of the column type (the metadata
+// changed since the schema was built) is null
+func appendMetadata(b array.Builder, val any) {
+	if val == nil {
+		b.AppendNull()
+		return
+	}
+	switch b := b.(type) {
+	case *array.Float64Builder:
+		if f, ok := val.(float64); ok {
+			b.Append(f)
+		} else {
+			b.AppendNull()
+		}
+	case *array.BooleanBuilder:
+		if flag, ok := val.(bool); ok {
+			b.Append(flag)
+		} else {
+			b.AppendNull()
+		}
+	case *array.StringBuilder:
+		if s, ok := val.(string); ok {
+			b.Append(s)
+			return
+		}
+		data, err := json.Marshal(val)
+		if err != nil {
+			data = []byte(fmt.Sprint(val))
+		}
+		b.Append(string(data))
+	}
+}
+
+// ExportIPC writes the collection as an Arrow IPC stream and returns the number of rows
+func ExportIPC(src ExportSource, w io.Writer, mapping ColumnMapping) (int, error) {
+	mapping = mapping.withDefaults()
+	ids := rangeIDs(src)
+	schema, columns := collectionSchema(src, ids, mapping)
+	iw := ipc.NewWriter(w, ipc.WithSchema(schema), ipc.WithAllocator(memory.DefaultAllocator))
+	rows, err := exportArrow(src, ids, schema, columns, iw.Write)
+	return rows, errors.Join(err, iw.Close())
+}
+
+// hides Close, the parquet writer closes its sink otherwise
+type nopCloseWriter struct{ io.Writer }
+
+// ExportParquet writes the collection as a snappy compressed Parquet file (with the Arrow schema
+// embedded, so the embedding column reads back as a FixedSizeList) and returns the number of rows
+func ExportParquet(src ExportSource, w io.Writer, mapping ColumnMapping) (int, error) {
+	mapping = mapping.withDefaults()
+	ids := rangeIDs(src)
+	schema, columns := collectionSchema(src, ids, mapping)
+	props := parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Snappy))
+	fw, err := pqarrow.NewFileWriter(schema, nopCloseWriter{w}, props, pqarrow.NewArrowWriterProperties(pqarrow.WithStoreSchema()))
+	if err != nil {
+		return 0, err
+	}
+	rows, err := exportArrow(src, ids, schema, columns, fw.Write)
+	return rows, errors.Join(err, fw.Close())
+}
+
+// ArrowReader maps the rows of Arrow record batches to Records, the embedding column may be a
+// FixedSizeList or List of float16, float32 or float64 and the id column a string or integer column
+type ArrowReader struct {
+	rr      array.RecordReader
+	closer  func() error
+	mapping ColumnMapping
+	schema  *arrow.Schema
+	idCol   int
+	vecCol  int
+	mdCols  []int
+	cur     arrow.RecordBatch
+	row     int
+	line    int
+}
+
+func newArrowReader(rr array.RecordReader, closer func() error, mapping ColumnMapping) (*ArrowReader, error) {
+	mapping = mapping.withDefaults()
+	schema := rr.Schema()
+	ar := &ArrowReader{rr: rr, closer: closer, mapping: mapping, schema: schema}
+	ar.idCol, ar.vecCol = fieldIndex(schema, mapping.ID), fieldIndex(schema, mapping.Vector)
+	if ar.idCol < 0 || ar.vecCol < 0 {
+		rr.Release()
+		return nil, fmt.Errorf("arrow schema needs the columns %q and %q", mapping.ID, mapping.Vector)
+	}
+	for i, f := range schema.Fields() {
+		if i == ar.idCol || i == ar.vecCol {
+			continue
+		}
+		if len(mapping.Metadata) == 0 || slices.Contains(mapping.Metadata, f.Name) {
+			ar.mdCols = append(ar.mdCols, i)
+		}
+	}
+	for _, name := range mapping.Metadata {
+		if fieldIndex(schema, name) < 0 {
+			rr.Release()
+			return nil, fmt.Errorf("metadata column %q not in arrow schema", name)
+		}
+	}
+	return ar, nil
+}
+
+func fieldIndex(schema *arrow.Schema, name string) int {
+	if idx := schema.FieldIndices(name); len(idx) > 0 {
+		return idx[0]
+	}
+	return -1
+}
+
+// NewIPCReader reads an Arrow IPC stream
+func NewIPCReader(r io.Reader, mapping ColumnMapping) (*ArrowReader, error) {
+	ir, err := ipc.NewReader(r, ipc.WithAllocator(memory.DefaultAllocator))
+	if err != nil {
+		return nil, err
+	}
+	return newArrowReader(ir, nil, mapping)
+}
+
+// NewParquetReader reads a Parquet file batch by batch
+func NewParquetReader(r parquet.ReaderAtSeeker, mapping ColumnMapping) (*ArrowReader, error) {
+	pf, err := file.NewParquetReader(r)
+	if err != nil {
+		return nil, err
+	}
+	fr, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{BatchSize: arrowBatchRows}, memory.DefaultAllocator)
+	if err != nil {
+		pf.Close()
+		return nil, err
+	}
+	rr, err := fr.GetRecordReader(context.Background(), nil, nil)
+	if err != nil {
+		pf.Close()
+		return nil, err
+	}
+	return newArrowReader(rr, pf.Close, mapping)
+}
+
+func (ar *ArrowReader) Next() (Record, error) {
+	for ar.cur == nil || ar.row >= int(ar.cur.NumRows()) {
+		if !ar.rr.Next() {
+			if err := ar.rr.Err(); err != nil {
+				return Record{}, err
+			}
+			return Record{}, io.EOF
+		}
+		ar.cur, ar.row = ar.rr.RecordBatch(), 0
+	}
+	i := ar.row
+	ar.row++
+	ar.line++
+	rec, err := ar.record(i)
+	if err != nil {
+		return Record{}, &RowError{Line: ar.line, Raw: ar.raw(i), Err: err}
+	}
+	rec.Line, rec.raw = ar.line, ar.raw(i)
+	return rec, nil
+}
+
+func (ar *ArrowReader) record(i int) (Record, error) {
+	var rec Record
+	idArr := ar.cur.Column(ar.idCol)
+	if idArr.IsNull(i) {
+		return Record{}, errors.New("null id")
+	}
+	switch ids := idArr.(type) {
+	case *array.String:
+		rec.ID = ids.Value(i)
+	case *array.LargeString:
+		rec.ID = ids.Value(i)
+	default:
+		if !arrow.IsInteger(idArr.DataType().ID()) {
+			return Record{}, fmt.Errorf("id column has unsupported type %s", idArr.DataType())
+		}
+		rec.ID = idArr.ValueStr(i)
+	}
+	if rec.ID == "" {
+		return Record{}, errors.New("empty id")
+	}
+	vecArr := ar.cur.Column(ar.vecCol)
+	list, ok := vecArr.(array.ListLike)
+	if !ok {
+		return Record{}, fmt.Errorf("embedding column has unsupported type %s", vecArr.DataType())
+	}
+	if list.IsNull(i) {
+		return Record{}, errors.New("null embedding")
+	}
+	start, end := list.ValueOffsets(i)
+	rec.Values = make([]float32, 0, end-start)
+	switch vals := list.ListValues().(type) {
+	case *array.Float32:
+		rec.Values = append(rec.Values, vals.Float32Values()[start:end]...)
+	case *array.Float64:
+		for _, f := range vals.Float64Values()[start:end] {
+			rec.Values = append(rec.Values, float32(f))
+		}
+	case *array.Float16:
+		for _, f := range vals.Values()[start:end] {
+			rec.Values = append(rec.Values, f.Float32())
+		}
+	default:
+		return Record{}, fmt.Errorf("embedding values have unsupported type %s", vals.DataType())
+	}
+	for _, c := range ar.mdCols {
+		col := ar.cur.Column(c)
+		if col.IsNull(i) {
+			continue
+		}
+		if rec.Metadata == nil {
+			rec.Metadata = index.Metadata{}
+		}
+		rec.Metadata[ar.schema.Field(c).Name] = metadataValue(col, i)
+	}
+	return rec, nil
+}
+
+// metadataValue maps a cell to the plain values metadata holds
+func metadataValue(col arrow.Array, i int) any {
+	switch col := col.(type) {
+	case *array.String:
+		return col.Value(i)
+	case *array.LargeString:
+		return col.Value(i)
+	case *array.Boolean:
+		return col.Value(i)
+	case *array.Float64:
+		return col.Value(i)
+	case *array.Float32:
+		return float64(col.Value(i))
+	}
+	if arrow.IsInteger(col.DataType().ID()) {
+		if f, err := strconv.ParseFloat(col.ValueStr(i), 64); err == nil {
+			return f
+		}
+	}
+	return col.ValueStr(i)
+}
+
+// raw renders row i as a JSON object for the rejects file
+func (ar *ArrowReader) raw(i int) string {
+	row := make(map[string]any, ar.cur.NumCols())
+	for c, f := range ar.schema.Fields() {
+		row[f.Name] = ar.cur.Column(c).GetOneForMarshal(i)
+	}
+	data, err := json.Marshal(row)
+	if err != nil {
+		return fmt.Sprint(row)
+	}
+	return string(data)
+}
+
+// Close releases the batches and the underlying file reader
+func (ar *ArrowReader) Close() error {
+	ar.rr.Release()
+	if ar.closer != nil {
+		return ar.closer()
+	}
+	return nil
+}
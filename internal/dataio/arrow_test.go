@@ -0,0 +1,192 @@
+package dataio
+
+import (
+	"VectorDatabase/internal/index"
+	v "VectorDatabase/internal/vector"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+)
+
+// Post-condition: a collection exported as Arrow IPC or Parquet loads back with vectors and metadata.
+func TestArrowRoundTrip(t *testing.T) {
+	src := setupIndex(t, 2)
+	src.AddWithMetadata("a", vec2(3, 4), map[string]any{"lang": "en", "views": 10.0, "draft": true})
+	src.AddWithMetadata("b", vec2(0, 1), map[string]any{"lang": "de", "tags": []any{"x"}})
+	src.Add("c", vec2(1, 0))
+
+	formats := []struct {
+		name   string
+		export func(*bytes.Buffer) (int, error)
+		reader func(*bytes.Buffer) (*ArrowReader, error)
+	}{
+		{"IPC",
+			func(buf *bytes.Buffer) (int, error) { return ExportIPC(src, buf, ColumnMapping{}) },
+			func(buf *bytes.Buffer) (*ArrowReader, error) { return NewIPCReader(buf, ColumnMapping{}) }},
+		{"Parquet",
+			func(buf *bytes.Buffer) (int, error) { return ExportParquet(src, buf, ColumnMapping{}) },
+			func(buf *bytes.Buffer) (*ArrowReader, error) {
+				return NewParquetReader(bytes.NewReader(buf.Bytes()), ColumnMapping{})
+			}},
+	}
+	for _, f := range formats {
+		t.Run(f.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if n, err := f.export(&buf); n != 3 || err != nil {
+				t.Fatalf("export: expected 3 rows, got %d (%v)", n, err)
+			}
+			r, err := f.reader(&buf)
+			if err != nil {
+				t.Fatalf("reader failed: %v", err)
+			}
+			defer r.Close()
+			if typ := r.schema.Field(1).Type; typ.ID() != arrow.FIXED_SIZE_LIST {
+				t.Errorf("expected a FixedSizeList embedding column, got %s", typ)
+			}
+			dst := setupIndex(t, 2)
+			stats, err := Load(context.Background(), dst, r, LoadOptions{})
+			if err != nil || stats.Added != 3 {
+				t.Fatalf("load: expected 3 added, got %+v (%v)", stats, err)
+			}
+			vec, _ := dst.Get("a")
+			if vals := vec.Values(); vals[0] != 0.6 || vals[1] != 0.8 {
+				t.Errorf("unexpected vector %v", vals)
+			}
+			md, _ := dst.Metadata("a")
+			if md["lang"] != "en" || md["views"] != 10.0 || md["draft"] != true {
+				t.Errorf("unexpected metadata %v", md)
+			}
+			if md, _ := dst.Metadata("b"); md["tags"] != `["x"]` {
+				t.Errorf("expected nested metadata as json text, got %v", md["tags"])
+			}
+			if md, _ := dst.Metadata("c"); len(md) != 0 {
+				t.Errorf("expected nulls to be left out, got %v", md)
+			}
+		})
+	}
+}
+
+// guardedSource fails the test when the exporter reads a vector or metadata from inside Range, where
+// an index holds its read lock and a waiting writer would deadlock the second RLock
+type guardedSource struct {
+	*index.LinearIndex
+	t       *testing.T
+	inRange bool
+}
+
+func (g *guardedSource) Range(fn func(id string, vec *v.Vector) bool) {
+	g.inRange = true
+	defer func() { g.inRange = false }()
+	g.LinearIndex.Range(fn)
+}
+
+func (g *guardedSource) Get(id string) (*v.Vector, bool) {
+	if g.inRange {
+		g.t.Errorf("Get(%q) called inside Range", id)
+	}
+	return g.LinearIndex.Get(id)
+}
+
+func (g *guardedSource) Metadata(id string) (index.Metadata, bool) {
+	if g.inRange {
+		g.t.Errorf("Metadata(%q) called inside Range", id)
+	}
+	return g.LinearIndex.Metadata(id)
+}
+
+// Invariant: exports never call back into the source from inside Range.
+func TestArrowExport_OutsideRange(t *testing.T) {
+	src := &guardedSource{LinearIndex: setupIndex(t, 2), t: t}
+	src.AddWithMetadata("a", vec2(3, 4), map[string]any{"lang": "en"})
+	src.AddWithMetadata("b", vec2(0, 1), map[string]any{"views": 2.0})
+	var buf bytes.Buffer
+	if n, err := ExportIPC(src, &buf, ColumnMapping{}); n != 2 || err != nil {
+		t.Errorf("IPC export: expected 2 rows, got %d (%v)", n, err)
+	}
+	buf.Reset()
+	if n, err := ExportParquet(src, &buf, ColumnMapping{}); n != 2 || err != nil {
+		t.Errorf("Parquet export: expected 2 rows, got %d (%v)", n, err)
+	}
+}
+
+// Contract: foreign tables with a List<float64> embedding and integer ids load, bad rows are rejected.
+func TestArrowReader_ForeignSchema(t *testing.T) {
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "doc_id", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "vec", Type: arrow.ListOf(arrow.PrimitiveTypes.Float64), Nullable: true},
+		{Name: "score", Type: arrow.PrimitiveTypes.Int32, Nullable: true},
+	}, nil)
+	b := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer b.Release()
+	b.Field(0).(*array.Int64Builder).AppendValues([]int64{1, 2, 3}, nil)
+	lb := b.Field(1).(*array.ListBuilder)
+	vb := lb.ValueBuilder().(*array.Float64Builder)
+	lb.Append(true)
+	vb.AppendValues([]float64{1, 0}, nil)
+	lb.AppendNull()
+	lb.Append(true)
+	vb.AppendValues([]float64{0, 1, 0}, nil)
+	b.Field(2).(*array.Int32Builder).AppendValues([]int32{5, 6, 7}, nil)
+	rec := b.NewRecordBatch()
+	defer rec.Release()
+	var buf bytes.Buffer
+	w := ipc.NewWriter(&buf, ipc.WithSchema(schema))
+	w.Write(rec)
+	w.Close()
+
+	r, err := NewIPCReader(&buf, ColumnMapping{ID: "doc_id", Vector: "vec"})
+	if err != nil {
+		t.Fatalf("reader failed: %v", err)
+	}
+	defer r.Close()
+	dst := setupIndex(t, 2)
+	var rejects bytes.Buffer
+	stats, err := Load(context.Background(), dst, r, LoadOptions{Rejects: &rejects})
+	if err != nil || stats.Added != 1 || stats.Rejected != 2 {
+		t.Fatalf("expected 1 added and 2 rejected, got %+v (%v)", stats, err)
+	}
+	if md, _ := dst.Metadata("1"); md["score"] != 5.0 {
+		t.Errorf("expected the integer column as float64, got %v", md)
+	}
+	if !strings.Contains(rejects.String(), "null embedding") {
+		t.Errorf("expected the null embedding rejected, got %s", rejects.String())
+	}
+
+	if _, err := NewIPCReader(bytes.NewReader(nil), ColumnMapping{}); err == nil {
+		t.Error("expected error for an empty stream")
+	}
+}
+
+// Invariant: a metadata value not of its column type (changed after the schema was built) is exported
+// as null instead of panicking.
+func TestAppendMetadata_TypeMismatch(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  arrow.DataType
+		val  any
+		null bool
+	}{
+		{"float column", arrow.PrimitiveTypes.Float64, "ten", true},
+		{"bool column", arrow.FixedWidthTypes.Boolean, 1.0, true},
+		{"string column", arrow.BinaryTypes.String, 1.5, false},
+		{"matching float", arrow.PrimitiveTypes.Float64, 2.5, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := array.NewBuilder(memory.DefaultAllocator, tt.typ)
+			defer b.Release()
+			appendMetadata(b, tt.val)
+			arr := b.NewArray()
+			defer arr.Release()
+			if arr.Len() != 1 || arr.IsNull(0) != tt.null {
+				t.Errorf("expected null %v, got %v", tt.null, arr)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package dataio
+
+import (
+	"VectorDatabase/internal/index"
+	v "VectorDatabase/internal/vector"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Format is a dense vector file format
+type Format int
+
+const (
+	// little endian records of int32 dimension + dimension * float32
+	FormatFvecs Format = iota + 1
+	// int32 dimension + dimension * int32, used for ground truth neighbor lists
+	FormatIvecs
+	// int32 dimension + dimension * uint8
+	FormatBvecs
+	// NumPy .npy, a 2-D float32 or float16 array in C order
+	FormatNpy
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatFvecs:
+		return "fvecs"
+	case FormatIvecs:
+		return "ivecs"
+	case FormatBvecs:
+		return "bvecs"
+	case FormatNpy:
+		return "npy"
+	default:
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+}
+
+// ParseFormat accepts a format name with or without the leading dot
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimPrefix(name, ".")) {
+	case "fvecs":
+		return FormatFvecs, nil
+	case "ivecs":
+		return FormatIvecs, nil
+	case "bvecs":
+		return FormatBvecs, nil
+	case "npy":
+		return FormatNpy, nil
+	default:
+		return 0, fmt.Errorf("unknown vector file format %q", name)
+	}
+}
+
+// FormatFromPath picks the format from the file extension
+func FormatFromPath(path string) (Format, error) {
+	return ParseFormat(filepath.Ext(path))
+}
+
+// VectorReader streams the rows of a vector file
+type VectorReader interface {
+	Dimension() int
+	// Next returns the next row, io.EOF after the last one
+	Next() ([]float32, error)
+}
+
+// VectorWriter writes rows to a vector file, Close flushes buffered rows but doesn't close the
+// underlying writer
+type VectorWriter interface {
+	Write(values []float32) error
+	Close() error
+}
+
+// NewReader reads the header (or first record) of r to learn the dimension
+func NewReader(r io.Reader, format Format) (VectorReader, error) {
+	switch format {
+	case FormatFvecs, FormatIvecs, FormatBvecs:
+		return newVecsReader(r, format)
+	case FormatNpy:
+		return newNpyReader(r)
+	default:
+		return nil, errors.New("unsupported vector file format")
+	}
+}
+
+// NewWriter writes rows of dim values, rows is only needed up front by npy (float32), whose header
+// holds the array shape
+func NewWriter(w io.Writer, format Format, dim, rows int) (VectorWriter, error) {
+	switch format {
+	case FormatFvecs, FormatIvecs, FormatBvecs:
+		return newVecsWriter(w, format, dim)
+	case FormatNpy:
+		return NewNpyWriter(w, dim, rows, false)
+	default:
+		return nil, errors.New("unsupported vector file format")
+	}
+}
+
+// Import adds every row of r to idx under the id prefix + row number (from 0) and returns
+// the number of rows read. Rows whose id already exists are skipped
+func Import(idx index.VectorIndex, r VectorReader, idPrefix string) (int, error) {
+	rows := 0
+	for {
+		values, err := r.Next()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return rows, fmt.Errorf("row %d: %w", rows, err)
+		}
+		vec, err := v.NewVector(values, r.Dimension())
+		if err != nil {
+			return rows, fmt.Errorf("row %d: %w", rows, err)
+		}
+		if _, err := idx.Add(fmt.Sprintf("%s%d", idPrefix, rows), vec); err != nil {
+			return rows, fmt.Errorf("row %d: %w", rows, err)
+		}
+		rows++
+	}
+}
+
+// Export writes every vector of idx to w in Range order and hands each id to ids (when not nil),
+// so the row order can be mapped back to ids. It returns the number of rows written
+func Export(idx index.RangeIndex, w VectorWriter, ids func(id string) error) (int, error) {
+	rows := 0
+	var err error
+	idx.Range(func(id string, vec *v.Vector) bool {
+		if err = w.Write(vec.Values()); err != nil {
+			err = fmt.Errorf("vector %s: %w", id, err)
+			return false
+		}
+		if ids != nil {
+			if err = ids(id); err != nil {
+				return false
+			}
+		}
+		rows++
+		return true
+	})
+	if err != nil {
+		return rows, err
+	}
+	return rows, w.Close()
+}
@@ -0,0 +1,84 @@
+package dataio
+
+import (
+	"VectorDatabase/internal/index"
+	"VectorDatabase/internal/types"
+	v "VectorDatabase/internal/vector"
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func setupIndex(t *testing.T, dim int) *index.LinearIndex {
+	cfg, _ := index.NewIndexConfig(types.LinearIndex, types.Testmodel, types.Text, types.Cosine, dim)
+	li, err := index.NewLinearIndex(cfg)
+	if err != nil {
+		t.Fatalf("failed to create index: %v", err)
+	}
+	return li
+}
+
+// Post-condition: exporting an index and importing the file rebuilds the same vectors.
+func TestImportExport(t *testing.T) {
+	src := setupIndex(t, 2)
+	r, _ := NewReader(bytes.NewReader(fvecsFile([][]float32{{3, 4}, {0, 1}, {1, 0}})), FormatFvecs)
+	if n, err := Import(src, r, "doc-"); n != 3 || err != nil {
+		t.Fatalf("import: expected 3 rows, got %d (%v)", n, err)
+	}
+	if _, ok := src.Get("doc-0"); !ok {
+		t.Fatal("expected ids from prefix and row number")
+	}
+
+	for _, format := range []Format{FormatFvecs, FormatNpy} {
+		var buf bytes.Buffer
+		w, _ := NewWriter(&buf, format, 2, src.Size())
+		var ids []string
+		n, err := Export(src, w, func(id string) error {
+			ids = append(ids, id)
+			return nil
+		})
+		if n != 3 || err != nil || len(ids) != 3 {
+			t.Fatalf("%s export: expected 3 rows, got %d (%v)", format, n, err)
+		}
+		dst := setupIndex(t, 2)
+		r, err := NewReader(&buf, format)
+		if err != nil {
+			t.Fatalf("%s reader failed: %v", format, err)
+		}
+		Import(dst, r, "row-")
+		for i, id := range ids {
+			want, _ := src.Get(id)
+			got, _ := dst.Get(fmt.Sprintf("row-%d", i))
+			if got == nil || got.Values()[0] != want.Values()[0] || got.Values()[1] != want.Values()[1] {
+				t.Errorf("%s: row %d doesn't match %s", format, i, id)
+			}
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := FormatFromPath("/data/sift_base.fvecs"); f != FormatFvecs || err != nil {
+		t.Errorf("expected fvecs, got %v %v", f, err)
+	}
+	if f, _ := ParseFormat(".NPY"); f != FormatNpy {
+		t.Errorf("expected npy, got %v", f)
+	}
+	if _, err := ParseFormat("csv"); err == nil {
+		t.Error("expected error for an unknown format")
+	}
+}
+
+func fvecsFile(rows [][]float32) []byte {
+	var buf bytes.Buffer
+	w, _ := NewWriter(&buf, FormatFvecs, len(rows[0]), 0)
+	for _, row := range rows {
+		w.Write(row)
+	}
+	w.Close()
+	return buf.Bytes()
+}
+
+func vec2(x, y float32) *v.Vector {
+	vec, _ := v.NewVector([]float32{x, y}, 2)
+	return vec
+}
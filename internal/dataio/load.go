@@ -0,0 +1,169 @@
+package dataio
+
+import (
+	"VectorDatabase/internal/index"
+	"VectorDatabase/internal/store"
+	v "VectorDatabase/internal/vector"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+const defaultBatchSize = 256
+
+// LoadOptions configure Load, zero values pick the defaults
+type LoadOptions struct {
+	// rows validated and added together, default 256
+	BatchSize int
+	// Dimension every vector must have, default the index dimension (or the first row's)
+	Dimension int
+	// Rejects receives every bad row as a JSON line {"line":..,"error":..,"row":..}, nil drops them
+	Rejects io.Writer
+}
+
+// LoadStats counts what happened to the rows of a Load, Rows = Added + Existing + Rejected
+type LoadStats struct {
+	Rows     int
+	Added    int
+	Existing int
+	Rejected int
+}
+
+type rejectedRow struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+	Row   string `json:"row"`
+}
+
+type dimensioned interface {
+	Dimension() int
+}
+
+// Load streams the rows of r into idx in batches. Rows the reader can't map, with invalid vectors
+// (NaN, Inf, zero, wrong dimension), ids the store can't hold (empty, over store.MaxIDLen), metadata
+// that can't be stored or a duplicate id inside the dump are rejected and loading goes on; any other
+// error from the index stops it. Metadata needs idx to be an index.MetadataIndex
+func Load(ctx context.Context, idx index.VectorIndex, r RecordReader, opts LoadOptions) (LoadStats, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.Dimension <= 0 {
+		if d, ok := idx.(dimensioned); ok {
+			opts.Dimension = d.Dimension()
+		}
+	}
+	l := &loader{idx: idx, opts: opts, seen: make(map[string]struct{})}
+	l.mdIdx, _ = idx.(index.MetadataIndex)
+	batch := make([]Record, 0, opts.BatchSize)
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		var rowErr *RowError
+		if errors.As(err, &rowErr) {
+			l.stats.Rows++
+			if err := l.reject(rowErr.Line, rowErr.Raw, rowErr.Err); err != nil {
+				return l.stats, err
+			}
+			continue
+		}
+		if err != nil {
+			return l.stats, err
+		}
+		l.stats.Rows++
+		batch = append(batch, rec)
+		if len(batch) == opts.BatchSize {
+			if err := l.flush(ctx, batch); err != nil {
+				return l.stats, err
+			}
+			batch = batch[:0]
+		}
+	}
+	return l.stats, l.flush(ctx, batch)
+}
+
+type loader struct {
+	idx   index.VectorIndex
+	mdIdx index.MetadataIndex
+	opts  LoadOptions
+	stats LoadStats
+	seen  map[string]struct{}
+}
+
+func (l *loader) reject(line int, raw string, cause error) error {
+	l.stats.Rejected++
+	if l.opts.Rejects == nil {
+		return nil
+	}
+	data, err := json.Marshal(rejectedRow{Line: line, Error: cause.Error(), Row: raw})
+	if err != nil {
+		return err
+	}
+	_, err = l.opts.Rejects.Write(append(data, '\n'))
+	return err
+}
+
+// flush validates a batch and adds the valid rows
+func (l *loader) flush(ctx context.Context, batch []Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for _, rec := range batch {
+		vec, err := l.validate(rec)
+		if err != nil {
+			if err := l.reject(rec.Line, rec.raw, err); err != nil {
+				return err
+			}
+			continue
+		}
+		var exists bool
+		if len(rec.Metadata) > 0 {
+			if l.mdIdx == nil {
+				return errors.New("index doesn't store metadata, map no metadata columns")
+			}
+			exists, err = l.mdIdx.AddWithMetadata(rec.ID, vec, rec.Metadata)
+		} else {
+			exists, err = l.idx.Add(rec.ID, vec)
+		}
+		if err != nil {
+			return fmt.Errorf("line %d (%s): %w", rec.Line, rec.ID, err)
+		}
+		if exists {
+			l.stats.Existing++
+		} else {
+			l.stats.Added++
+		}
+	}
+	return nil
+}
+
+func (l *loader) validate(rec Record) (*v.Vector, error) {
+	if err := store.CheckID(rec.ID); err != nil {
+		return nil, err
+	}
+	if _, dup := l.seen[rec.ID]; dup {
+		return nil, fmt.Errorf("duplicate id %q in input", rec.ID)
+	}
+	for field, val := range rec.Metadata {
+		// stored metadata is JSON, which has no NaN or Inf
+		if f, ok := val.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+			return nil, fmt.Errorf("metadata field %q: %v can't be stored", field, f)
+		}
+	}
+	if l.opts.Dimension <= 0 {
+		l.opts.Dimension = len(rec.Values)
+	}
+	if len(rec.Values) != l.opts.Dimension {
+		return nil, fmt.Errorf("vector has dimension %d, expected %d", len(rec.Values), l.opts.Dimension)
+	}
+	vec, err := v.NewVector(rec.Values, l.opts.Dimension)
+	if err != nil {
+		return nil, err
+	}
+	l.seen[rec.ID] = struct{}{}
+	return vec, nil
+}
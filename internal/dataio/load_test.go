@@ -0,0 +1,110 @@
+package dataio
+
+import (
+	"VectorDatabase/internal/index"
+	"VectorDatabase/internal/store"
+	"VectorDatabase/internal/types"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"strings"
+	"testing"
+)
+
+// Invariant: valid rows land in the index with their metadata, invalid ones end up in the rejects file.
+func TestLoad(t *testing.T) {
+	input := `id,embedding,lang
+a,1 0,en
+b,NaN 1,en
+c,0 1,de
+d,1 1 1,de
+a,0 1,fr
+e,0 0,en
+f,"[1,`
+	r, _ := NewCSVReader(strings.NewReader(input), ColumnMapping{})
+	idx := setupIndex(t, 2)
+	idx.Add("c", vec2(1, 1))
+	var rejects bytes.Buffer
+	stats, err := Load(context.Background(), idx, r, LoadOptions{BatchSize: 2, Rejects: &rejects})
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	want := LoadStats{Rows: 7, Added: 1, Existing: 1, Rejected: 5}
+	if stats != want {
+		t.Errorf("expected %+v, got %+v", want, stats)
+	}
+	if md, _ := idx.Metadata("a"); md["lang"] != "en" {
+		t.Errorf("metadata not loaded: %v", md)
+	}
+
+	lines := strings.Split(strings.TrimSpace(rejects.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("expected 5 rejected rows, got %d:\n%s", len(lines), rejects.String())
+	}
+	var first rejectedRow
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("rejects line isn't json: %v", err)
+	}
+	if first.Line != 2 || first.Row != "b,NaN 1,en" || !strings.Contains(first.Error, "NaN") {
+		t.Errorf("unexpected reject: %+v", first)
+	}
+}
+
+// sliceReader hands out fixed records, the way a reader of a typed format (Arrow) maps them
+type sliceReader struct {
+	records []Record
+}
+
+func (r *sliceReader) Next() (Record, error) {
+	if len(r.records) == 0 {
+		return Record{}, io.EOF
+	}
+	rec := r.records[0]
+	r.records = r.records[1:]
+	return rec, nil
+}
+
+// Contract: rows whose id or metadata the index can't store are rejected into a persistent collection,
+// the load goes on with the next row.
+func TestLoad_RejectsRowsTheIndexRefuses(t *testing.T) {
+	cfg, _ := index.NewIndexConfig(types.LinearIndex, types.Testmodel, types.Text, types.Cosine, 2)
+	si, err := index.OpenSegmentedIndex(t.TempDir(), cfg, index.SegmentedOptions{})
+	if err != nil {
+		t.Fatalf("failed to open segmented index: %v", err)
+	}
+	defer si.Close()
+	r := &sliceReader{records: []Record{
+		{Line: 1, ID: "a", Values: []float32{1, 0}},
+		{Line: 2, ID: strings.Repeat("x", store.MaxIDLen+1), Values: []float32{1, 0}},
+		{Line: 3, ID: "b", Values: []float32{0, 1}, Metadata: index.Metadata{"score": math.NaN()}},
+		{Line: 4, ID: "c", Values: []float32{0, 1}, Metadata: index.Metadata{"score": 1.0}},
+	}}
+	var rejects bytes.Buffer
+	stats, err := Load(context.Background(), si, r, LoadOptions{Rejects: &rejects})
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if want := (LoadStats{Rows: 4, Added: 2, Rejected: 2}); stats != want {
+		t.Errorf("expected %+v, got %+v", want, stats)
+	}
+	for _, want := range []string{`"line":2`, "id too long", `"line":3`, "score"} {
+		if !strings.Contains(rejects.String(), want) {
+			t.Errorf("expected %q in the rejects, got %s", want, rejects.String())
+		}
+	}
+	if si.Size() != 2 {
+		t.Errorf("expected 2 vectors, got %d", si.Size())
+	}
+}
+
+// Contract: a cancelled context stops the load before the next batch.
+func TestLoad_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := NewJSONLReader(strings.NewReader(`{"id": "a", "embedding": [1, 0]}`), ColumnMapping{})
+	if _, err := Load(ctx, setupIndex(t, 2), r, LoadOptions{}); err == nil {
+		t.Error("expected the context error")
+	}
+}
@@ -0,0 +1,274 @@
+package dataio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// .npy layout: magic "\x93NUMPY" | major u8 | minor u8 | header length (u16 for 1.0, u32 for 2.0/3.0) |
+// header, a Python dict literal padded with spaces and '\n' to a multiple of 64 bytes | raw array data
+const npyMagic = "\x93NUMPY"
+
+var (
+	npyDescr   = regexp.MustCompile(`'descr'\s*:\s*'([^']*)'`)
+	npyFortran = regexp.MustCompile(`'fortran_order'\s*:\s*(True|False)`)
+	npyShape   = regexp.MustCompile(`'shape'\s*:\s*\(([^)]*)\)`)
+)
+
+type npyReader struct {
+	r     *bufio.Reader
+	order binary.ByteOrder
+	// bytes per value, 4 (float32) or 2 (float16)
+	width int
+	rows  int
+	dim   int
+	row   int
+	buf   []byte
+}
+
+func newNpyReader(r io.Reader) (*npyReader, error) {
+	br := bufio.NewReader(r)
+	pre := make([]byte, 8)
+	if _, err := io.ReadFull(br, pre); err != nil {
+		return nil, fmt.Errorf("npy preamble: %w", err)
+	}
+	if string(pre[:6]) != npyMagic {
+		return nil, errors.New("not a npy file")
+	}
+	var headerLen int
+	switch pre[6] {
+	case 1:
+		var n [2]byte
+		if _, err := io.ReadFull(br, n[:]); err != nil {
+			return nil, fmt.Errorf("npy header: %w", err)
+		}
+		headerLen = int(binary.LittleEndian.Uint16(n[:]))
+	case 2, 3:
+		var n [4]byte
+		if _, err := io.ReadFull(br, n[:]); err != nil {
+			return nil, fmt.Errorf("npy header: %w", err)
+		}
+		headerLen = int(binary.LittleEndian.Uint32(n[:]))
+	default:
+		return nil, fmt.Errorf("unsupported npy version %d.%d", pre[6], pre[7])
+	}
+	if headerLen > 1<<20 {
+		return nil, errors.New("npy header too large")
+	}
+	header := make([]byte, headerLen)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("npy header: %w", err)
+	}
+	nr := &npyReader{r: br}
+	if err := nr.parseHeader(string(header)); err != nil {
+		return nil, err
+	}
+	nr.buf = make([]byte, nr.dim*nr.width)
+	return nr, nil
+}
+
+func (nr *npyReader) parseHeader(header string) error {
+	descr := npyDescr.FindStringSubmatch(header)
+	fortran := npyFortran.FindStringSubmatch(header)
+	shape := npyShape.FindStringSubmatch(header)
+	if descr == nil || fortran == nil || shape == nil {
+		return fmt.Errorf("invalid npy header %q", header)
+	}
+	switch descr[1] {
+	case "<f4", "=f4":
+		nr.order, nr.width = binary.LittleEndian, 4
+	case ">f4":
+		nr.order, nr.width = binary.BigEndian, 4
+	case "<f2", "=f2":
+		nr.order, nr.width = binary.LittleEndian, 2
+	case ">f2":
+		nr.order, nr.width = binary.BigEndian, 2
+	default:
+		return fmt.Errorf("unsupported npy dtype %q, expected float32 or float16", descr[1])
+	}
+	if fortran[1] == "True" {
+		return errors.New("fortran ordered npy arrays are not supported, save in C order")
+	}
+	var dims []int
+	for _, part := range strings.Split(shape[1], ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid npy shape (%s)", shape[1])
+		}
+		dims = append(dims, n)
+	}
+	if len(dims) != 2 || dims[1] == 0 {
+		return fmt.Errorf("expected a 2-D npy array (rows, dimension), got shape (%s)", shape[1])
+	}
+	nr.rows, nr.dim = dims[0], dims[1]
+	return nil
+}
+
+func (nr *npyReader) Dimension() int {
+	return nr.dim
+}
+
+// Rows is the number of rows announced by the header
+func (nr *npyReader) Rows() int {
+	return nr.rows
+}
+
+func (nr *npyReader) Next() ([]float32, error) {
+	if nr.row == nr.rows {
+		return nil, io.EOF
+	}
+	if _, err := io.ReadFull(nr.r, nr.buf); err != nil {
+		return nil, fmt.Errorf("npy row %d: %w", nr.row, io.ErrUnexpectedEOF)
+	}
+	values := make([]float32, nr.dim)
+	for i := range values {
+		if nr.width == 4 {
+			values[i] = math.Float32frombits(nr.order.Uint32(nr.buf[i*4:]))
+		} else {
+			values[i] = halfToFloat32(nr.order.Uint16(nr.buf[i*2:]))
+		}
+	}
+	nr.row++
+	return values, nil
+}
+
+type npyWriter struct {
+	w    *bufio.Writer
+	dim  int
+	rows int
+	half bool
+	row  int
+	buf  []byte
+}
+
+// NewNpyWriter writes a little endian (rows, dim) float32 array, or float16 when half is set.
+// The shape is part of the header, so exactly rows rows must be written before Close
+func NewNpyWriter(w io.Writer, dim, rows int, half bool) (VectorWriter, error) {
+	if dim <= 0 || rows < 0 {
+		return nil, errors.New("invalid npy shape")
+	}
+	descr := "<f4"
+	if half {
+		descr = "<f2"
+	}
+	header := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': (%d, %d), }", descr, rows, dim)
+	// preamble + header + '\n' padded to a multiple of 64
+	pad := 64 - (10+len(header)+1)%64
+	if pad == 64 {
+		pad = 0
+	}
+	header += strings.Repeat(" ", pad) + "\n"
+	bw := bufio.NewWriter(w)
+	pre := append([]byte(npyMagic), 1, 0)
+	pre = binary.LittleEndian.AppendUint16(pre, uint16(len(header)))
+	if _, err := bw.Write(pre); err != nil {
+		return nil, err
+	}
+	if _, err := bw.WriteString(header); err != nil {
+		return nil, err
+	}
+	return &npyWriter{w: bw, dim: dim, rows: rows, half: half}, nil
+}
+
+func (nw *npyWriter) Write(values []float32) error {
+	if len(values) != nw.dim {
+		return errors.New("dimension mismatch")
+	}
+	if nw.row == nw.rows {
+		return fmt.Errorf("npy header announced %d rows", nw.rows)
+	}
+	buf := nw.buf[:0]
+	for _, val := range values {
+		if nw.half {
+			buf = binary.LittleEndian.AppendUint16(buf, float32ToHalf(val))
+		} else {
+			buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(val))
+		}
+	}
+	nw.buf = buf
+	nw.row++
+	_, err := nw.w.Write(buf)
+	return err
+}
+
+func (nw *npyWriter) Close() error {
+	if err := nw.w.Flush(); err != nil {
+		return err
+	}
+	if nw.row != nw.rows {
+		return fmt.Errorf("wrote %d rows, npy header announced %d", nw.row, nw.rows)
+	}
+	return nil
+}
+
+// halfToFloat32 widens an IEEE 754 binary16 value
+func halfToFloat32(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h) & 0x3ff
+	switch {
+	case exp == 0 && mant == 0:
+		return math.Float32frombits(sign)
+	case exp == 0:
+		// subnormal, normalize the mantissa
+		e := uint32(127 - 15 + 1)
+		for mant&0x400 == 0 {
+			mant <<= 1
+			e--
+		}
+		return math.Float32frombits(sign | e<<23 | (mant&0x3ff)<<13)
+	case exp == 0x1f:
+		return math.Float32frombits(sign | 0xff<<23 | mant<<13)
+	default:
+		return math.Float32frombits(sign | (exp+127-15)<<23 | mant<<13)
+	}
+}
+
+// float32ToHalf narrows to binary16 rounding to nearest even, out of range values become infinity
+func float32ToHalf(f float32) uint16 {
+	b := math.Float32bits(f)
+	sign := uint16(b>>16) & 0x8000
+	exp := int(b>>23&0xff) - 127 + 15
+	mant := b & 0x7fffff
+	switch {
+	case b&0x7fffffff == 0:
+		return sign
+	case b>>23&0xff == 0xff:
+		if mant != 0 {
+			return sign | 0x7e00
+		}
+		return sign | 0x7c00
+	case exp >= 0x1f:
+		return sign | 0x7c00
+	case exp <= 0:
+		if exp < -10 {
+			return sign
+		}
+		mant |= 0x800000
+		shift := uint(14 - exp)
+		h := mant >> shift
+		rem, half := mant&(1<<shift-1), uint32(1)<<(shift-1)
+		if rem > half || (rem == half && h&1 == 1) {
+			h++
+		}
+		return sign | uint16(h)
+	default:
+		h := uint32(exp)<<10 | mant>>13
+		rem := mant & 0x1fff
+		if rem > 0x1000 || (rem == 0x1000 && h&1 == 1) {
+			h++
+		}
+		return sign | uint16(h)
+	}
+}
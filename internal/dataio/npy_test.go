@@ -0,0 +1,118 @@
+package dataio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"strings"
+	"testing"
+)
+
+// Invariant: float32 and float16 arrays round trip, the header is 64 byte aligned like numpy writes it.
+func TestNpy_RoundTrip(t *testing.T) {
+	rows := [][]float32{{1, -2.5, 0.125}, {3, 0, 65504}}
+	for _, half := range []bool{false, true} {
+		var buf bytes.Buffer
+		w, err := NewNpyWriter(&buf, 3, len(rows), half)
+		if err != nil {
+			t.Fatalf("writer failed: %v", err)
+		}
+		for _, row := range rows {
+			if err := w.Write(row); err != nil {
+				t.Fatalf("write failed: %v", err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("close failed: %v", err)
+		}
+		headerLen := int(binary.LittleEndian.Uint16(buf.Bytes()[8:]))
+		if (10+headerLen)%64 != 0 {
+			t.Errorf("header not aligned: %d bytes", 10+headerLen)
+		}
+
+		r, err := NewReader(&buf, FormatNpy)
+		if err != nil {
+			t.Fatalf("reader failed: %v", err)
+		}
+		if r.Dimension() != 3 {
+			t.Fatalf("expected dimension 3, got %d", r.Dimension())
+		}
+		for i, want := range rows {
+			got, err := r.Next()
+			if err != nil {
+				t.Fatalf("row %d: %v", i, err)
+			}
+			for j := range want {
+				if got[j] != want[j] {
+					t.Errorf("half=%v row %d: expected %v, got %v", half, i, want, got)
+					break
+				}
+			}
+		}
+		if _, err := r.Next(); err != io.EOF {
+			t.Errorf("expected io.EOF after the last row, got %v", err)
+		}
+	}
+}
+
+// Contract: unsupported arrays are rejected with a clear error.
+func TestNpy_RejectsUnsupported(t *testing.T) {
+	header := func(dict string) []byte {
+		dict += strings.Repeat(" ", 64-(10+len(dict)+1)%64) + "\n"
+		out := append([]byte(npyMagic), 1, 0)
+		out = binary.LittleEndian.AppendUint16(out, uint16(len(dict)))
+		return append(out, dict...)
+	}
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"Not Npy", []byte("hello world")},
+		{"Float64", header("{'descr': '<f8', 'fortran_order': False, 'shape': (1, 2), }")},
+		{"Fortran Order", header("{'descr': '<f4', 'fortran_order': True, 'shape': (1, 2), }")},
+		{"One Dimensional", header("{'descr': '<f4', 'fortran_order': False, 'shape': (4,), }")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewReader(bytes.NewReader(tt.data), FormatNpy); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+
+	w, _ := NewNpyWriter(io.Discard, 2, 2, false)
+	w.Write([]float32{1, 2})
+	if err := w.Close(); err == nil {
+		t.Error("expected error closing with fewer rows than the header announced")
+	}
+}
+
+// Invariant: float16 conversion rounds to nearest even and handles subnormals and overflow.
+func TestFloat16Conversion(t *testing.T) {
+	tests := []struct {
+		in   float32
+		want uint16
+	}{
+		{0, 0x0000},
+		{1, 0x3c00},
+		{-2, 0xc000},
+		{65504, 0x7bff},
+		{1e6, 0x7c00},
+		{float32(math.Inf(-1)), 0xfc00},
+		{5.960464477539063e-08, 0x0001}, // smallest subnormal
+		{6.103515625e-05, 0x0400},       // smallest normal
+		{1 + 1.0/2048, 0x3c00},          // tie rounds to even
+		{1 + 3.0/2048, 0x3c02},
+	}
+	for _, tt := range tests {
+		if got := float32ToHalf(tt.in); got != tt.want {
+			t.Errorf("float32ToHalf(%v) = %#04x, expected %#04x", tt.in, got, tt.want)
+		}
+	}
+	for h := 0; h < 0x7c00; h++ {
+		if back := float32ToHalf(halfToFloat32(uint16(h))); back != uint16(h) {
+			t.Fatalf("half %#04x doesn't round trip, got %#04x", h, back)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package dataio
+
+import (
+	"VectorDatabase/internal/index"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Record is one row of a tabular dump mapped to an id, a vector and metadata
+type Record struct {
+	ID       string
+	Values   []float32
+	Metadata index.Metadata
+	// 1-based line (JSONL) or record number after the header (CSV)
+	Line int
+	// the row as read, for the rejects file
+	raw string
+}
+
+// RowError is a row that can't be mapped to a Record, readers keep going after it
+type RowError struct {
+	// 1-based line (JSONL) or record number after the header (CSV)
+	Line int
+	// the row as read, for the rejects file
+	Raw string
+	Err error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// RecordReader streams the rows of a dump. Next returns a *RowError for a bad row (the next call
+// continues with the following row), io.EOF after the last one and any other error when reading fails
+type RecordReader interface {
+	Next() (Record, error)
+}
+
+// ColumnMapping names the columns (JSON fields) holding the id, the vector and the metadata
+type ColumnMapping struct {
+	// default "id"
+	ID string
+	// default "embedding"
+	Vector string
+	// columns copied into the metadata, empty copies every column except id and vector
+	Metadata []string
+}
+
+func (m ColumnMapping) withDefaults() ColumnMapping {
+	if m.ID == "" {
+		m.ID = "id"
+	}
+	if m.Vector == "" {
+		m.Vector = "embedding"
+	}
+	return m
+}
+
+func (m ColumnMapping) isMetadata(column string) bool {
+	if len(m.Metadata) == 0 {
+		return column != m.ID && column != m.Vector
+	}
+	return slices.Contains(m.Metadata, column)
+}
+
+type jsonlReader struct {
+	r       *bufio.Reader
+	mapping ColumnMapping
+	line    int
+}
+
+// NewJSONLReader reads one JSON object per line, the vector field must be an array of numbers
+// and the id a string or a number. Blank lines are skipped
+func NewJSONLReader(r io.Reader, mapping ColumnMapping) RecordReader {
+	return &jsonlReader{r: bufio.NewReader(r), mapping: mapping.withDefaults()}
+}
+
+func (jr *jsonlReader) Next() (Record, error) {
+	for {
+		line, err := jr.r.ReadBytes('\n')
+		if len(line) == 0 && err != nil {
+			return Record{}, err
+		}
+		if err != nil && err != io.EOF {
+			return Record{}, err
+		}
+		jr.line++
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		rec, rowErr := jr.parse(line)
+		if rowErr != nil {
+			return Record{}, &RowError{Line: jr.line, Raw: string(line), Err: rowErr}
+		}
+		rec.Line, rec.raw = jr.line, string(line)
+		return rec, nil
+	}
+}
+
+func (jr *jsonlReader) parse(line []byte) (Record, error) {
+	var row map[string]any
+	if err := json.Unmarshal(line, &row); err != nil {
+		return Record{}, fmt.Errorf("invalid json: %w", err)
+	}
+	var rec Record
+	switch id := row[jr.mapping.ID].(type) {
+	case string:
+		rec.ID = id
+	case float64:
+		rec.ID = strconv.FormatFloat(id, 'f', -1, 64)
+	case nil:
+		return Record{}, fmt.Errorf("missing id field %q", jr.mapping.ID)
+	default:
+		return Record{}, fmt.Errorf("id field %q must be a string or a number", jr.mapping.ID)
+	}
+	raw, ok := row[jr.mapping.Vector].([]any)
+	if !ok {
+		return Record{}, fmt.Errorf("vector field %q must be an array of numbers", jr.mapping.Vector)
+	}
+	rec.Values = make([]float32, len(raw))
+	for i, val := range raw {
+		f, ok := val.(float64)
+		if !ok {
+			return Record{}, fmt.Errorf("vector value %d is not a number", i)
+		}
+		rec.Values[i] = float32(f)
+	}
+	for column, val := range row {
+		if jr.mapping.isMetadata(column) {
+			if rec.Metadata == nil {
+				rec.Metadata = index.Metadata{}
+			}
+			rec.Metadata[column] = val
+		}
+	}
+	return rec, nil
+}
+
+type csvReader struct {
+	r       *csv.Reader
+	mapping ColumnMapping
+	header  []string
+	idCol   int
+	vecCol  int
+	line    int
+}
+
+// NewCSVReader reads the header row and maps columns by name. The vector column holds a JSON array
+// ("[0.1, 0.2]") or numbers separated by spaces or semicolons. Metadata cells are typed: numbers become
+// float64, true/false bool, anything else a string; empty cells are left out
+func NewCSVReader(r io.Reader, mapping ColumnMapping) (RecordReader, error) {
+	mapping = mapping.withDefaults()
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("csv header: %w", err)
+	}
+	reader := &csvReader{r: cr, mapping: mapping, header: header, idCol: -1, vecCol: -1}
+	for i, name := range header {
+		switch strings.TrimSpace(name) {
+		case mapping.ID:
+			reader.idCol = i
+		case mapping.Vector:
+			reader.vecCol = i
+		}
+	}
+	if reader.idCol < 0 || reader.vecCol < 0 {
+		return nil, fmt.Errorf("csv header needs the columns %q and %q", mapping.ID, mapping.Vector)
+	}
+	for _, column := range mapping.Metadata {
+		if !slices.Contains(header, column) {
+			return nil, fmt.Errorf("metadata column %q not in csv header", column)
+		}
+	}
+	return reader, nil
+}
+
+func (cr *csvReader) Next() (Record, error) {
+	row, err := cr.r.Read()
+	cr.line++
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return Record{}, &RowError{Line: cr.line, Err: err}
+	}
+	if err != nil {
+		return Record{}, err
+	}
+	rec, rowErr := cr.parse(row)
+	if rowErr != nil {
+		return Record{}, &RowError{Line: cr.line, Raw: csvLine(row), Err: rowErr}
+	}
+	rec.Line, rec.raw = cr.line, csvLine(row)
+	return rec, nil
+}
+
+func csvLine(row []string) string {
+	var raw strings.Builder
+	w := csv.NewWriter(&raw)
+	w.Write(row)
+	w.Flush()
+	return strings.TrimSuffix(raw.String(), "\n")
+}
+
+func (cr *csvReader) parse(row []string) (Record, error) {
+	if len(row) != len(cr.header) {
+		return Record{}, fmt.Errorf("expected %d columns, got %d", len(cr.header), len(row))
+	}
+	rec := Record{ID: strings.TrimSpace(row[cr.idCol])}
+	if rec.ID == "" {
+		return Record{}, errors.New("empty id")
+	}
+	values, err := parseVectorCell(row[cr.vecCol])
+	if err != nil {
+		return Record{}, err
+	}
+	rec.Values = values
+	for i, column := range cr.header {
+		cell := strings.TrimSpace(row[i])
+		if cell == "" || !cr.mapping.isMetadata(column) {
+			continue
+		}
+		if rec.Metadata == nil {
+			rec.Metadata = index.Metadata{}
+		}
+		rec.Metadata[column] = typedCell(cell)
+	}
+	return rec, nil
+}
+
+func parseVectorCell(cell string) ([]float32, error) {
+	cell = strings.TrimSpace(cell)
+	var parts []string
+	if strings.HasPrefix(cell, "[") {
+		if !strings.HasSuffix(cell, "]") {
+			return nil, errors.New("unterminated vector array")
+		}
+		parts = strings.Split(cell[1:len(cell)-1], ",")
+	} else {
+		parts = strings.FieldsFunc(cell, func(r rune) bool { return r == ';' || r == ' ' || r == '\t' })
+	}
+	values := make([]float32, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(part, 32)
+		// out of range values parse to ±Inf, which validation rejects with a better message
+		if err != nil && !errors.Is(err, strconv.ErrRange) {
+			return nil, fmt.Errorf("invalid vector value %q", part)
+		}
+		values = append(values, float32(f))
+	}
+	if len(values) == 0 {
+		return nil, errors.New("empty vector")
+	}
+	return values, nil
+}
+
+func typedCell(cell string) any {
+	if f, err := strconv.ParseFloat(cell, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+		return f
+	}
+	if b, err := strconv.ParseBool(cell); err == nil && (cell == "true" || cell == "false") {
+		return b
+	}
+	return cell
+}
@@ -0,0 +1,90 @@
+package dataio
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// drain reads every row, collecting good records and row errors
+func drain(t *testing.T, r RecordReader) ([]Record, []*RowError) {
+	var recs []Record
+	var bad []*RowError
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			return recs, bad
+		}
+		var rowErr *RowError
+		if errors.As(err, &rowErr) {
+			bad = append(bad, rowErr)
+			continue
+		}
+		if err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+		recs = append(recs, rec)
+	}
+}
+
+// Contract: JSONL rows map id, vector and every other field to metadata; bad rows don't stop the reader.
+func TestJSONLReader(t *testing.T) {
+	input := `{"id": "a", "embedding": [1, 0], "lang": "en", "views": 10}
+
+{"id": 7, "embedding": [0, 1]}
+{"id": "b", "embedding": "oops"}
+not json
+{"embedding": [1, 1]}`
+	recs, bad := drain(t, NewJSONLReader(strings.NewReader(input), ColumnMapping{}))
+	if len(recs) != 2 || len(bad) != 3 {
+		t.Fatalf("expected 2 records and 3 bad rows, got %d and %d", len(recs), len(bad))
+	}
+	if recs[0].ID != "a" || recs[0].Metadata["lang"] != "en" || recs[0].Metadata["views"] != 10.0 {
+		t.Errorf("unexpected first record: %+v", recs[0])
+	}
+	if _, ok := recs[0].Metadata["embedding"]; ok {
+		t.Error("vector column copied into metadata")
+	}
+	if recs[1].ID != "7" || recs[1].Line != 3 || recs[1].Metadata != nil {
+		t.Errorf("unexpected second record: %+v", recs[1])
+	}
+	if bad[0].Line != 4 || bad[1].Raw != "not json" {
+		t.Errorf("unexpected bad rows: %v, %v", bad[0], bad[1])
+	}
+}
+
+// Contract: CSV columns are mapped by header name, metadata cells are typed and limited to the mapping.
+func TestCSVReader(t *testing.T) {
+	input := `doc,vec,lang,score,draft,notes
+d1,"[0.5, 0.5]",en,0.75,true,keep
+d2,1;2;3,de,,false,
+d3,"1 x",fr,1,false,
+d4,1 2`
+	mapping := ColumnMapping{ID: "doc", Vector: "vec", Metadata: []string{"lang", "score", "draft"}}
+	r, err := NewCSVReader(strings.NewReader(input), mapping)
+	if err != nil {
+		t.Fatalf("reader failed: %v", err)
+	}
+	recs, bad := drain(t, r)
+	if len(recs) != 2 || len(bad) != 2 {
+		t.Fatalf("expected 2 records and 2 bad rows, got %d and %d", len(recs), len(bad))
+	}
+	md := recs[0].Metadata
+	if md["lang"] != "en" || md["score"] != 0.75 || md["draft"] != true || md["notes"] != nil {
+		t.Errorf("unexpected metadata: %v", md)
+	}
+	if len(recs[1].Values) != 3 || recs[1].Values[2] != 3 {
+		t.Errorf("expected semicolon separated values, got %v", recs[1].Values)
+	}
+	if _, ok := recs[1].Metadata["score"]; ok {
+		t.Error("empty cell copied into metadata")
+	}
+
+	if _, err := NewCSVReader(strings.NewReader("id,text\n"), ColumnMapping{}); err == nil {
+		t.Error("expected error for a header without the vector column")
+	}
+	if _, err := NewCSVReader(strings.NewReader("id,embedding\n"), ColumnMapping{Metadata: []string{"lang"}}); err == nil {
+		t.Error("expected error for a missing metadata column")
+	}
+}
@@ -0,0 +1,131 @@
+package dataio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// vecsReader reads fvecs, ivecs and bvecs files, every record repeats the dimension
+type vecsReader struct {
+	r      *bufio.Reader
+	format Format
+	dim    int
+	// first record, read to learn the dimension
+	peeked []float32
+	row    int
+}
+
+func newVecsReader(r io.Reader, format Format) (*vecsReader, error) {
+	vr := &vecsReader{r: bufio.NewReader(r), format: format}
+	values, err := vr.read()
+	if err == io.EOF {
+		return nil, errors.New("empty vector file")
+	}
+	if err != nil {
+		return nil, err
+	}
+	vr.dim, vr.peeked = len(values), values
+	return vr, nil
+}
+
+func (vr *vecsReader) Dimension() int {
+	return vr.dim
+}
+
+func (vr *vecsReader) Next() ([]float32, error) {
+	if vr.peeked != nil {
+		values := vr.peeked
+		vr.peeked = nil
+		vr.row++
+		return values, nil
+	}
+	values, err := vr.read()
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != vr.dim {
+		return nil, fmt.Errorf("record %d has dimension %d, expected %d", vr.row, len(values), vr.dim)
+	}
+	vr.row++
+	return values, nil
+}
+
+func (vr *vecsReader) read() ([]float32, error) {
+	var head [4]byte
+	if _, err := io.ReadFull(vr.r, head[:]); err != nil {
+		return nil, err
+	}
+	dim := int(int32(binary.LittleEndian.Uint32(head[:])))
+	if dim <= 0 || dim > 1<<20 {
+		return nil, fmt.Errorf("invalid record dimension %d", dim)
+	}
+	width := 4
+	if vr.format == FormatBvecs {
+		width = 1
+	}
+	buf := make([]byte, dim*width)
+	if _, err := io.ReadFull(vr.r, buf); err != nil {
+		return nil, fmt.Errorf("truncated record: %w", io.ErrUnexpectedEOF)
+	}
+	values := make([]float32, dim)
+	for i := range values {
+		switch vr.format {
+		case FormatFvecs:
+			values[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[i*4:]))
+		case FormatIvecs:
+			values[i] = float32(int32(binary.LittleEndian.Uint32(buf[i*4:])))
+		case FormatBvecs:
+			values[i] = float32(buf[i])
+		}
+	}
+	return values, nil
+}
+
+type vecsWriter struct {
+	w      *bufio.Writer
+	format Format
+	dim    int
+	buf    []byte
+}
+
+func newVecsWriter(w io.Writer, format Format, dim int) (*vecsWriter, error) {
+	if dim <= 0 {
+		return nil, errors.New("invalid dimension")
+	}
+	return &vecsWriter{w: bufio.NewWriter(w), format: format, dim: dim}, nil
+}
+
+// Write stores values as is for fvecs, ivecs and bvecs need integral values in their range
+func (vw *vecsWriter) Write(values []float32) error {
+	if len(values) != vw.dim {
+		return errors.New("dimension mismatch")
+	}
+	buf := binary.LittleEndian.AppendUint32(vw.buf[:0], uint32(vw.dim))
+	for _, val := range values {
+		switch vw.format {
+		case FormatFvecs:
+			buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(val))
+		case FormatIvecs:
+			if val != float32(math.Trunc(float64(val))) || val < math.MinInt32 || val > math.MaxInt32 {
+				return fmt.Errorf("value %v doesn't fit ivecs (int32)", val)
+			}
+			buf = binary.LittleEndian.AppendUint32(buf, uint32(int32(val)))
+		case FormatBvecs:
+			if val != float32(math.Trunc(float64(val))) || val < 0 || val > math.MaxUint8 {
+				return fmt.Errorf("value %v doesn't fit bvecs (uint8)", val)
+			}
+			buf = append(buf, uint8(val))
+		}
+	}
+	vw.buf = buf
+	_, err := vw.w.Write(buf)
+	return err
+}
+
+func (vw *vecsWriter) Close() error {
+	return vw.w.Flush()
+}
@@ -0,0 +1,65 @@
+package dataio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+// Invariant: rows written in each vecs format read back unchanged.
+func TestVecs_RoundTrip(t *testing.T) {
+	rows := [][]float32{{1, 2, 3}, {255, 0, 7}}
+	for _, format := range []Format{FormatFvecs, FormatIvecs, FormatBvecs} {
+		t.Run(format.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			w, _ := NewWriter(&buf, format, 3, 0)
+			for _, row := range rows {
+				if err := w.Write(row); err != nil {
+					t.Fatalf("write failed: %v", err)
+				}
+			}
+			w.Close()
+			r, err := NewReader(&buf, format)
+			if err != nil {
+				t.Fatalf("reader failed: %v", err)
+			}
+			for i, want := range rows {
+				got, err := r.Next()
+				if err != nil || len(got) != 3 || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+					t.Fatalf("row %d: expected %v, got %v (%v)", i, want, got, err)
+				}
+			}
+			if _, err := r.Next(); err != io.EOF {
+				t.Errorf("expected io.EOF, got %v", err)
+			}
+		})
+	}
+}
+
+// Contract: integer formats reject values they can't hold, readers reject mixed dimensions.
+func TestVecs_Errors(t *testing.T) {
+	w, _ := NewWriter(io.Discard, FormatBvecs, 2, 0)
+	if err := w.Write([]float32{256, 0}); err == nil {
+		t.Error("expected error writing 256 to bvecs")
+	}
+	w, _ = NewWriter(io.Discard, FormatIvecs, 2, 0)
+	if err := w.Write([]float32{0.5, 0}); err == nil {
+		t.Error("expected error writing 0.5 to ivecs")
+	}
+
+	var buf bytes.Buffer
+	fw, _ := NewWriter(&buf, FormatFvecs, 2, 0)
+	fw.Write([]float32{1, 2})
+	fw.Close()
+	buf.Write(binary.LittleEndian.AppendUint32(nil, 3))
+	buf.Write(make([]byte, 12))
+	r, _ := NewReader(&buf, FormatFvecs)
+	r.Next()
+	if _, err := r.Next(); err == nil {
+		t.Error("expected error for a record with another dimension")
+	}
+	if _, err := NewReader(bytes.NewReader([]byte{2, 0, 0, 0, 1}), FormatFvecs); err == nil {
+		t.Error("expected error for a truncated record")
+	}
+}
@@ -0,0 +1,274 @@
+package embedder
+
+import (
+	"VectorDatabase/internal/vector"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+)
+
+const defaultCacheSize = 10000
+
+// CacheConfig configures a CachedEmbedder, zero values pick the defaults
+type CacheConfig struct {
+	// Size is the most embeddings kept in memory, least recently used go first, default 10000
+	Size int
+	// Dir keeps every embedding on disk too when set, so they outlive the process. The model name
+	// is part of the key, one directory can serve several models
+	Dir string
+}
+
+// CacheStats counts how Embed calls were served
+type CacheStats struct {
+	// Hits were served from memory
+	Hits uint64
+	// DiskHits were served from Dir, and are in memory now
+	DiskHits uint64
+	// Misses called the embedder, this includes inputs that can't be cached (not text or bytes)
+	Misses uint64
+	// Evictions dropped embeddings from memory to stay within Size
+	Evictions uint64
+	// DiskErrors are failed reads and writes of Dir, they fall back to the embedder and don't fail Embed
+	DiskErrors uint64
+}
+
+// CachedEmbedder remembers the embeddings of an Embedder by model Name and content hash, so embedding
+// content that didn't change is free. Only string and []byte inputs are cached, the same text as string
+// or []byte is the same content
+type CachedEmbedder struct {
+	Embedder
+	cfg CacheConfig
+
+	mu      sync.Mutex
+	lru     *list.List // of *cacheEntry, most recently used first
+	entries map[cacheKey]*list.Element
+
+	hits, diskHits, misses, evictions, diskErrors atomic.Uint64
+}
+
+type cacheKey [sha256.Size]byte
+
+type cacheEntry struct {
+	key cacheKey
+	vec *vector.Vector
+}
+
+// NewCachedEmbedder wraps e, creating cfg.Dir when it doesn't exist
+func NewCachedEmbedder(e Embedder, cfg CacheConfig) (*CachedEmbedder, error) {
+	if e == nil {
+		return nil, errors.New("embedder is required")
+	}
+	if cfg.Size <= 0 {
+		cfg.Size = defaultCacheSize
+	}
+	if cfg.Dir != "" {
+		if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	return &CachedEmbedder{Embedder: e, cfg: cfg, lru: list.New(), entries: make(map[cacheKey]*list.Element)}, nil
+}
+
+// Embed returns the cached embedding of input, embedding and caching it on a miss
+func (c *CachedEmbedder) Embed(ctx context.Context, input any) (*vector.Vector, error) {
+	content, err := textInput(input)
+	if err != nil {
+		c.misses.Add(1)
+		return c.Embedder.Embed(ctx, input)
+	}
+	key := c.key(content)
+	if vec, ok := c.lookup(key); ok {
+		return vec, nil
+	}
+	c.misses.Add(1)
+	vec, err := c.Embedder.Embed(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	c.store(key, vec)
+	return vec, nil
+}
+
+// EmbedBatch serves the inputs it has cached and embeds the rest with one EmbedAll call
+func (c *CachedEmbedder) EmbedBatch(ctx context.Context, inputs []any) ([]*vector.Vector, error) {
+	vecs := make([]*vector.Vector, len(inputs))
+	var missing []int
+	keys := make([]*cacheKey, len(inputs))
+	for i, input := range inputs {
+		if content, err := textInput(input); err == nil {
+			key := c.key(content)
+			keys[i] = &key
+			if vec, ok := c.lookup(key); ok {
+				vecs[i] = vec
+				continue
+			}
+		}
+		missing = append(missing, i)
+	}
+	if len(missing) == 0 {
+		return vecs, nil
+	}
+	c.misses.Add(uint64(len(missing)))
+	batch := make([]any, len(missing))
+	for j, i := range missing {
+		batch[j] = inputs[i]
+	}
+	embedded, err := EmbedAll(ctx, c.Embedder, batch)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range missing {
+		vecs[i] = embedded[j]
+		if keys[i] != nil {
+			c.store(*keys[i], embedded[j])
+		}
+	}
+	return vecs, nil
+}
+
+// Stats returns the counters since the embedder was created
+func (c *CachedEmbedder) Stats() CacheStats {
+	return CacheStats{
+		Hits:       c.hits.Load(),
+		DiskHits:   c.diskHits.Load(),
+		Misses:     c.misses.Load(),
+		Evictions:  c.evictions.Load(),
+		DiskErrors: c.diskErrors.Load(),
+	}
+}
+
+// Len is the number of embeddings in memory
+func (c *CachedEmbedder) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// key hashes the model name and the content, the zero byte keeps "ab"+"c" and "a"+"bc" apart
+func (c *CachedEmbedder) key(content string) cacheKey {
+	h := sha256.New()
+	h.Write([]byte(c.Embedder.Name()))
+	h.Write([]byte{0})
+	h.Write([]byte(content))
+	var key cacheKey
+	h.Sum(key[:0])
+	return key
+}
+
+// lookup finds key in memory, then on disk, and counts the hit
+func (c *CachedEmbedder) lookup(key cacheKey) (*vector.Vector, bool) {
+	if vec, ok := c.get(key); ok {
+		c.hits.Add(1)
+		return vec, true
+	}
+	if vec, ok := c.readDisk(key); ok {
+		c.diskHits.Add(1)
+		c.put(key, vec)
+		return vec, true
+	}
+	return nil, false
+}
+
+func (c *CachedEmbedder) store(key cacheKey, vec *vector.Vector) {
+	c.put(key, vec)
+	c.writeDisk(key, vec)
+}
+
+func (c *CachedEmbedder) get(key cacheKey) (*vector.Vector, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).vec, true
+}
+
+func (c *CachedEmbedder) put(key cacheKey, vec *vector.Vector) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		// another call embedded the same content meanwhile
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, vec: vec})
+	for c.lru.Len() > c.cfg.Size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+		c.evictions.Add(1)
+	}
+}
+
+// diskPath spreads the files over 256 directories by the first key byte
+func (c *CachedEmbedder) diskPath(key cacheKey) string {
+	name := hex.EncodeToString(key[:])
+	return filepath.Join(c.cfg.Dir, name[:2], name)
+}
+
+// readDisk reads an embedding file: the little endian float32 values
+func (c *CachedEmbedder) readDisk(key cacheKey) (*vector.Vector, bool) {
+	if c.cfg.Dir == "" {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.diskPath(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false
+	}
+	if err != nil || len(data) != 4*c.Embedder.Dimension() {
+		c.diskErrors.Add(1)
+		return nil, false
+	}
+	values := make([]float32, len(data)/4)
+	for i := range values {
+		values[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+	}
+	vec, err := vector.NewVector(values, c.Embedder.Dimension())
+	if err != nil {
+		c.diskErrors.Add(1)
+		return nil, false
+	}
+	return vec, true
+}
+
+// writeDisk writes through a temporary file, a crash never leaves a torn embedding behind
+func (c *CachedEmbedder) writeDisk(key cacheKey, vec *vector.Vector) {
+	if c.cfg.Dir == "" {
+		return
+	}
+	values := vec.Values()
+	data := make([]byte, 4*len(values))
+	for i, val := range values {
+		binary.LittleEndian.PutUint32(data[4*i:], math.Float32bits(val))
+	}
+	path := c.diskPath(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		c.diskErrors.Add(1)
+		return
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		c.diskErrors.Add(1)
+		return
+	}
+	_, err = f.Write(data)
+	if err = errors.Join(err, f.Close()); err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		c.diskErrors.Add(1)
+	}
+}
+
+var _ BatchEmbedder = (*CachedEmbedder)(nil)
@@ -0,0 +1,185 @@
+package embedder
+
+import (
+	"VectorDatabase/internal/vector"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// countingEmbedder embeds with a RandomEmbedder and counts the calls
+type countingEmbedder struct {
+	*RandomEmbedder
+	calls atomic.Int64
+}
+
+func (c *countingEmbedder) Embed(ctx context.Context, input any) (*vector.Vector, error) {
+	c.calls.Add(1)
+	return c.RandomEmbedder.Embed(ctx, input)
+}
+
+func newCounting(t *testing.T, dim int) *countingEmbedder {
+	t.Helper()
+	e, err := NewRandomEmbedder(dim, 3)
+	if err != nil {
+		t.Fatalf("NewRandomEmbedder failed: %v", err)
+	}
+	return &countingEmbedder{RandomEmbedder: e}
+}
+
+// Contract: embedding the same content again is served from memory without calling the embedder, with the
+// same vector, whether the text comes as string or []byte; the wrapped embedder's properties show through.
+func TestCachedEmbedder_MemoryHit(t *testing.T) {
+	inner := newCounting(t, 8)
+	c, err := NewCachedEmbedder(inner, CacheConfig{})
+	if err != nil {
+		t.Fatalf("NewCachedEmbedder failed: %v", err)
+	}
+	first := mustEmbed(t, c, "hello")
+	second := mustEmbed(t, c, []byte("hello"))
+	mustEmbed(t, c, "world")
+	if inner.calls.Load() != 2 {
+		t.Errorf("expected 2 embedder calls, got %d", inner.calls.Load())
+	}
+	if !slices.Equal(first.Values(), second.Values()) {
+		t.Error("cached vector differs")
+	}
+	if got := c.Stats(); got != (CacheStats{Hits: 1, Misses: 2}) {
+		t.Errorf("unexpected stats %+v", got)
+	}
+	if c.Name() != inner.Name() || c.Dimension() != 8 {
+		t.Errorf("expected the properties of the wrapped embedder, got %s %d", c.Name(), c.Dimension())
+	}
+}
+
+// Invariant: memory holds at most Size embeddings and evicts the least recently used one.
+func TestCachedEmbedder_LRU(t *testing.T) {
+	inner := newCounting(t, 4)
+	c, _ := NewCachedEmbedder(inner, CacheConfig{Size: 2})
+	mustEmbed(t, c, "a")
+	mustEmbed(t, c, "b")
+	mustEmbed(t, c, "a") // b is the least recently used now
+	mustEmbed(t, c, "c")
+	if c.Len() != 2 || c.Stats().Evictions != 1 {
+		t.Fatalf("expected 2 entries after 1 eviction, got %d, %+v", c.Len(), c.Stats())
+	}
+	before := inner.calls.Load()
+	mustEmbed(t, c, "a")
+	if inner.calls.Load() != before {
+		t.Error("recently used entry was evicted")
+	}
+	mustEmbed(t, c, "b")
+	if inner.calls.Load() != before+1 {
+		t.Error("least recently used entry wasn't evicted")
+	}
+}
+
+// Contract: with Dir set embeddings outlive the cache, a new cache on the same directory serves them from disk,
+// and embedders with another model name don't see them.
+func TestCachedEmbedder_Disk(t *testing.T) {
+	dir := t.TempDir()
+	inner := newCounting(t, 4)
+	c, _ := NewCachedEmbedder(inner, CacheConfig{Dir: dir})
+	want := mustEmbed(t, c, "persisted")
+
+	reopened, _ := NewCachedEmbedder(inner, CacheConfig{Dir: dir})
+	got := mustEmbed(t, reopened, "persisted")
+	mustEmbed(t, reopened, "persisted")
+	if inner.calls.Load() != 1 {
+		t.Errorf("expected 1 embedder call, got %d", inner.calls.Load())
+	}
+	if !slices.EqualFunc(want.Values(), got.Values(), func(a, b float32) bool { return a-b < 1e-6 && b-a < 1e-6 }) {
+		t.Error("vector read from disk differs")
+	}
+	if stats := reopened.Stats(); stats.DiskHits != 1 || stats.Hits != 1 || stats.Misses != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	other := newCounting(t, 6)
+	otherCache, _ := NewCachedEmbedder(other, CacheConfig{Dir: dir})
+	if vec := mustEmbed(t, otherCache, "persisted"); vec.Dimensions() != 6 || other.calls.Load() != 1 {
+		t.Error("embedding of another model was served from disk")
+	}
+}
+
+// Contract: unreadable disk entries and inputs that aren't text fall back to the embedder without failing.
+func TestCachedEmbedder_Fallbacks(t *testing.T) {
+	dir := t.TempDir()
+	inner := newCounting(t, 4)
+	c, _ := NewCachedEmbedder(inner, CacheConfig{Dir: dir})
+	mustEmbed(t, c, "torn")
+	var files []string
+	filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if len(files) != 1 {
+		t.Fatalf("expected 1 cache file, got %v", files)
+	}
+	os.WriteFile(files[0], []byte{1, 2, 3}, 0o644)
+
+	reopened, _ := NewCachedEmbedder(inner, CacheConfig{Dir: dir})
+	mustEmbed(t, reopened, "torn")
+	mustEmbed(t, reopened, 42)
+	mustEmbed(t, reopened, 42)
+	if stats := reopened.Stats(); stats.DiskErrors != 1 || stats.Misses != 3 || inner.calls.Load() != 4 {
+		t.Errorf("unexpected stats %+v with %d calls", stats, inner.calls.Load())
+	}
+	if info, _ := os.Stat(files[0]); info.Size() != 16 {
+		t.Error("torn cache file wasn't rewritten")
+	}
+}
+
+// Invariant: the cache is safe for concurrent use (run with -race).
+func TestCachedEmbedder_Concurrent(t *testing.T) {
+	c, _ := NewCachedEmbedder(newCounting(t, 4), CacheConfig{Size: 8, Dir: t.TempDir()})
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 50 {
+				if _, err := c.Embed(context.Background(), string(rune('a'+(g+i)%16))); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	stats := c.Stats()
+	if stats.Hits+stats.DiskHits+stats.Misses != 400 || c.Len() > 8 {
+		t.Errorf("unexpected stats %+v, %d entries", stats, c.Len())
+	}
+}
+
+// Contract: EmbedBatch serves cached inputs and embeds only the misses, in one batch, keeping input order.
+func TestCachedEmbedder_EmbedBatch(t *testing.T) {
+	random, _ := NewRandomEmbedder(4, 1)
+	inner := &batchCounter{RandomEmbedder: random}
+	c, _ := NewCachedEmbedder(inner, CacheConfig{})
+	mustEmbed(t, c, "b")
+	vecs, err := c.EmbedBatch(context.Background(), []any{"a", "b", 7, "c"})
+	if err != nil {
+		t.Fatalf("EmbedBatch failed: %v", err)
+	}
+	if len(inner.batches) != 1 || inner.batches[0] != 3 {
+		t.Errorf("expected one batch of the 3 misses, got %v", inner.batches)
+	}
+	for i, input := range []any{"a", "b", 7, "c"} {
+		if want := mustEmbed(t, random, input); !slices.Equal(vecs[i].Values(), want.Values()) {
+			t.Errorf("vector %d doesn't belong to %v", i, input)
+		}
+	}
+	if stats := c.Stats(); stats.Hits != 1 || stats.Misses != 4 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if _, err := c.EmbedBatch(context.Background(), []any{"a", "c"}); err != nil || len(inner.batches) != 1 {
+		t.Errorf("expected cached inputs to skip the embedder, batches %v (err %v)", inner.batches, err)
+	}
+}
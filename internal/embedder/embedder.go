@@ -1,35 +1,16 @@
 package embedder
 
 import (
+	"VectorDatabase/internal/types"
 	"VectorDatabase/internal/vector"
 	"context"
+	"fmt"
 )
 
 // ID uniquely identifies a vector produced by an Embedder
 // Ownership : Embedder
 type VectorID string
 
-// modality represents type of raw input data
-type DataType string
-
-const (
-	DataTypeText  DataType = "text"
-	DataTypeImage DataType = "image"
-	// future
-	// DataTypeAudio DataType = "audio"
-	// DataTypeVideo DataType = "video"
-)
-
-// SimilarityMetrics define how vector are compared
-// Note declare early for clarity , the usage will come later
-type SimilarityMetrics string
-
-const (
-	MetricCosine    SimilarityMetrics = "cosine"
-	MetricDot       SimilarityMetrics = "dot"
-	MetricEuclidean SimilarityMetrics = "euclidean"
-)
-
 // Embedder defines the contract  for converting  raw data into vectors
 type Embedder interface {
 	//converts raw data into vectors
@@ -40,11 +21,55 @@ type Embedder interface {
 	Dimension() int
 
 	//DataType returns supported input data type (modality : text, image, audio, video)
-	DataType() DataType
+	DataType() types.DataType
 
 	//Metric returns the similarity metric this metric was trained for..(use same metric for similarity score)
-	Metric() SimilarityMetrics
+	Metric() types.SimilarityMetric
 
 	//Name returns a stable identifeir for thsi Embedder (eg - "openai-text-embedding-3-large")
 	Name() string
 }
+
+// Info describes the model e runs, register it before creating an index for e:
+// types.RegisterModel(embedder.Info(e, "1"))
+func Info(e Embedder, version string) types.ModelInfo {
+	return types.ModelInfo{
+		Name:      types.ModelType(e.Name()),
+		Version:   version,
+		Dimension: e.Dimension(),
+		DataType:  e.DataType(),
+		Metric:    e.Metric(),
+	}
+}
+
+// BatchEmbedder is implemented by embedders that embed many inputs at once cheaper than one by one,
+// like a remote model taking a list of inputs per request
+type BatchEmbedder interface {
+	Embedder
+	// EmbedBatch returns the vectors of inputs in input order
+	EmbedBatch(ctx context.Context, inputs []any) ([]*vector.Vector, error)
+}
+
+// EmbedAll embeds inputs with one EmbedBatch call when e is a BatchEmbedder, with one Embed call
+// per input otherwise
+func EmbedAll(ctx context.Context, e Embedder, inputs []any) ([]*vector.Vector, error) {
+	if be, ok := e.(BatchEmbedder); ok {
+		vecs, err := be.EmbedBatch(ctx, inputs)
+		if err != nil {
+			return nil, err
+		}
+		if len(vecs) != len(inputs) {
+			return nil, fmt.Errorf("embedder %s returned %d vectors for %d inputs", e.Name(), len(vecs), len(inputs))
+		}
+		return vecs, nil
+	}
+	vecs := make([]*vector.Vector, len(inputs))
+	for i, input := range inputs {
+		vec, err := e.Embed(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("input %d: %w", i, err)
+		}
+		vecs[i] = vec
+	}
+	return vecs, nil
+}
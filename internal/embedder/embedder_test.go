@@ -0,0 +1,62 @@
+package embedder
+
+import (
+	"VectorDatabase/internal/types"
+	"VectorDatabase/internal/vector"
+	"context"
+	"testing"
+)
+
+// batchCounter is a BatchEmbedder over a RandomEmbedder recording the batch sizes, short drops the last vector
+type batchCounter struct {
+	*RandomEmbedder
+	batches []int
+	short   bool
+}
+
+func (b *batchCounter) EmbedBatch(ctx context.Context, inputs []any) ([]*vector.Vector, error) {
+	b.batches = append(b.batches, len(inputs))
+	vecs, err := EmbedAll(ctx, b.RandomEmbedder, inputs)
+	if b.short {
+		vecs = vecs[:len(vecs)-1]
+	}
+	return vecs, err
+}
+
+// Contract: EmbedAll uses one EmbedBatch call for a BatchEmbedder, Embed per input otherwise, and returns
+// one vector per input in input order.
+func TestEmbedAll(t *testing.T) {
+	random, _ := NewRandomEmbedder(4, 1)
+	inputs := []any{"a", "b", "c"}
+	b := &batchCounter{RandomEmbedder: random}
+	batched, err := EmbedAll(context.Background(), b, inputs)
+	if err != nil || len(b.batches) != 1 || b.batches[0] != 3 {
+		t.Fatalf("expected one batch of 3, got %v (err %v)", b.batches, err)
+	}
+	single, err := EmbedAll(context.Background(), random, inputs)
+	if err != nil || len(single) != 3 {
+		t.Fatalf("expected 3 vectors, got %d (err %v)", len(single), err)
+	}
+	for i := range inputs {
+		if batched[i].Values()[0] != single[i].Values()[0] {
+			t.Errorf("vector %d out of order", i)
+		}
+	}
+	if _, err := EmbedAll(context.Background(), &batchCounter{RandomEmbedder: random, short: true}, inputs); err == nil {
+		t.Error("expected a short batch result to fail")
+	}
+	hashing, _ := NewHashingEmbedder(4)
+	if _, err := EmbedAll(context.Background(), hashing, []any{"ok", ""}); err == nil {
+		t.Error("expected a failing input to fail")
+	}
+}
+
+// Post-condition: Info describes the embedder the way the model registry expects.
+func TestInfo(t *testing.T) {
+	e, _ := NewHashingEmbedder(16)
+	info := Info(e, "2")
+	want := types.ModelInfo{Name: "hashing-bow-16", Version: "2", Dimension: 16, DataType: types.Text, Metric: types.Cosine}
+	if info != want {
+		t.Errorf("expected %+v, got %+v", want, info)
+	}
+}
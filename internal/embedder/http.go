@@ -0,0 +1,343 @@
+package embedder
+
+import (
+	"VectorDatabase/internal/types"
+	"VectorDatabase/internal/vector"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBatchSize  = 64
+	defaultMaxRetries = 3
+	defaultBackoff    = 500 * time.Millisecond
+	maxBackoff        = 30 * time.Second
+	defaultTimeout    = 30 * time.Second
+)
+
+// HTTPConfig configures an HTTPEmbedder, zero values pick the defaults
+type HTTPConfig struct {
+	// BaseURL is the API root the /embeddings path is appended to: https://api.openai.com/v1,
+	// http://localhost:11434/v1 for Ollama, http://localhost:8080/v1 for text-embeddings-inference
+	BaseURL string
+	// APIKey is sent as a bearer token when set
+	APIKey string
+	// Model is the model name the server knows
+	Model string
+	// Dimension the model produces (required), every response is checked against it
+	Dimension int
+	// SendDimensions asks the server for Dimension values, for models with a configurable output size
+	SendDimensions bool
+	// DataType default types.Text
+	DataType types.DataType
+	// Metric default types.Cosine
+	Metric types.SimilarityMetric
+	// Name is the registered model name of the embedder, default Model
+	Name string
+	// BatchSize is the most inputs sent in one request, default 64
+	BatchSize int
+	// MaxRetries of a request failing with a network error, 429 or 5xx, default 3, negative disables
+	MaxRetries int
+	// Backoff before the first retry, doubled for every further one (with jitter), default 500ms
+	Backoff time.Duration
+	// RequestsPerSecond limits the request rate, zero leaves it unlimited
+	RequestsPerSecond float64
+	// Timeout of one request attempt, default 30s, the context passed in bounds the whole call
+	Timeout time.Duration
+	// Client default http.DefaultClient
+	Client *http.Client
+}
+
+// HTTPEmbedder embeds text through an OpenAI compatible POST /embeddings endpoint
+type HTTPEmbedder struct {
+	cfg     HTTPConfig
+	url     string
+	limiter *rateLimiter
+}
+
+// NewHTTPEmbedder checks cfg and fills in its defaults
+func NewHTTPEmbedder(cfg HTTPConfig) (*HTTPEmbedder, error) {
+	if cfg.BaseURL == "" {
+		return nil, errors.New("base url is required")
+	}
+	if cfg.Model == "" {
+		return nil, errors.New("model is required")
+	}
+	if cfg.Dimension <= 0 {
+		return nil, errors.New("dimension must be a positive integer")
+	}
+	if cfg.DataType == 0 {
+		cfg.DataType = types.Text
+	}
+	if cfg.Metric == 0 {
+		cfg.Metric = types.Cosine
+	}
+	if !cfg.DataType.Valid() || !cfg.Metric.Valid() {
+		return nil, errors.New("invalid data type or metric")
+	}
+	if cfg.Name == "" {
+		cfg.Name = cfg.Model
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = defaultMaxRetries
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = defaultBackoff
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	e := &HTTPEmbedder{cfg: cfg, url: strings.TrimSuffix(cfg.BaseURL, "/") + "/embeddings"}
+	if cfg.RequestsPerSecond > 0 {
+		e.limiter = newRateLimiter(cfg.RequestsPerSecond)
+	}
+	return e, nil
+}
+
+func (e *HTTPEmbedder) Dimension() int                 { return e.cfg.Dimension }
+func (e *HTTPEmbedder) DataType() types.DataType       { return e.cfg.DataType }
+func (e *HTTPEmbedder) Metric() types.SimilarityMetric { return e.cfg.Metric }
+func (e *HTTPEmbedder) Name() string                   { return e.cfg.Name }
+
+// Embed embeds one string (or []byte of text)
+func (e *HTTPEmbedder) Embed(ctx context.Context, input any) (*vector.Vector, error) {
+	vecs, err := e.EmbedBatch(ctx, []any{input})
+	if err != nil {
+		return nil, err
+	}
+	return vecs[0], nil
+}
+
+// EmbedBatch embeds inputs in requests of up to BatchSize inputs, results are in input order
+func (e *HTTPEmbedder) EmbedBatch(ctx context.Context, inputs []any) ([]*vector.Vector, error) {
+	texts := make([]string, len(inputs))
+	for i, input := range inputs {
+		text, err := textInput(input)
+		if err != nil {
+			return nil, fmt.Errorf("input %d: %w", i, err)
+		}
+		texts[i] = text
+	}
+	out := make([]*vector.Vector, 0, len(inputs))
+	for start := 0; start < len(texts); start += e.cfg.BatchSize {
+		batch := texts[start:min(start+e.cfg.BatchSize, len(texts))]
+		vecs, err := e.embedWithRetry(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, vecs...)
+	}
+	return out, nil
+}
+
+func textInput(input any) (string, error) {
+	switch in := input.(type) {
+	case string:
+		return in, nil
+	case []byte:
+		return string(in), nil
+	default:
+		return "", fmt.Errorf("unsupported input type %T, want text", input)
+	}
+}
+
+// APIError is an error response of the embedding endpoint
+type APIError struct {
+	StatusCode int
+	Message    string
+	// retryAfter is the wait the server asked for with a Retry-After header
+	retryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("embedding request failed: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Temporary reports errors worth retrying: rate limiting and server errors
+func (e *APIError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+func (e *HTTPEmbedder) embedWithRetry(ctx context.Context, batch []string) ([]*vector.Vector, error) {
+	backoff := e.cfg.Backoff
+	for attempt := 0; ; attempt++ {
+		if e.limiter != nil {
+			if err := e.limiter.wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+		vecs, err := e.embed(ctx, batch)
+		if err == nil {
+			return vecs, nil
+		}
+		var apiErr *APIError
+		isAPI := errors.As(err, &apiErr)
+		retryable := isAPI && apiErr.Temporary() || !isAPI && isNetworkError(err)
+		if !retryable || attempt >= e.cfg.MaxRetries || ctx.Err() != nil {
+			return nil, err
+		}
+		wait := backoff/2 + rand.N(backoff/2+1)
+		if isAPI && apiErr.retryAfter > 0 {
+			wait = apiErr.retryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+		backoff = min(2*backoff, maxBackoff)
+	}
+}
+
+// networkError marks transport failures (and attempt timeouts), unlike responses the endpoint rejected
+// they are always worth a retry
+type networkError struct{ err error }
+
+func (n networkError) Error() string { return n.err.Error() }
+func (n networkError) Unwrap() error { return n.err }
+
+func isNetworkError(err error) bool {
+	var n networkError
+	return errors.As(err, &n)
+}
+
+type embeddingRequest struct {
+	Model      string   `json:"model"`
+	Input      []string `json:"input"`
+	Dimensions int      `json:"dimensions,omitempty"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// embed sends one request
+func (e *HTTPEmbedder) embed(ctx context.Context, batch []string) ([]*vector.Vector, error) {
+	body := embeddingRequest{Model: e.cfg.Model, Input: batch}
+	if e.cfg.SendDimensions {
+		body.Dimensions = e.cfg.Dimension
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, e.cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.cfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.cfg.APIKey)
+	}
+	resp, err := e.cfg.Client.Do(req)
+	if err != nil {
+		return nil, networkError{err}
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, networkError{err}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp, data)
+	}
+	var parsed embeddingResponse
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("invalid embedding response: %w", err)
+	}
+	if len(parsed.Data) != len(batch) {
+		return nil, fmt.Errorf("embedding response has %d embeddings for %d inputs", len(parsed.Data), len(batch))
+	}
+	vecs := make([]*vector.Vector, len(batch))
+	for _, item := range parsed.Data {
+		if item.Index < 0 || item.Index >= len(batch) || vecs[item.Index] != nil {
+			return nil, fmt.Errorf("embedding response has invalid index %d", item.Index)
+		}
+		vec, err := vector.NewVector(item.Embedding, e.cfg.Dimension)
+		if err != nil {
+			return nil, fmt.Errorf("embedding %d (%d values): %w", item.Index, len(item.Embedding), err)
+		}
+		vecs[item.Index] = vec
+	}
+	return vecs, nil
+}
+
+func newAPIError(resp *http.Response, data []byte) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+	// OpenAI style {"error": {"message": ...}}, TEI style {"error": "..."}
+	var body struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(data, &body) == nil && len(body.Error) > 0 {
+		var detail struct {
+			Message string `json:"message"`
+		}
+		var text string
+		if json.Unmarshal(body.Error, &detail) == nil && detail.Message != "" {
+			apiErr.Message = detail.Message
+		} else if json.Unmarshal(body.Error, &text) == nil && text != "" {
+			apiErr.Message = text
+		}
+	}
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs >= 0 {
+		apiErr.retryAfter = min(time.Duration(secs)*time.Second, maxBackoff)
+	}
+	return apiErr
+}
+
+// rateLimiter spaces requests at least interval apart, callers queue for the next free slot
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(perSecond float64) *rateLimiter {
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next = slot.Add(l.interval)
+	l.mu.Unlock()
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+var _ BatchEmbedder = (*HTTPEmbedder)(nil)
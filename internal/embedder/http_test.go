@@ -0,0 +1,228 @@
+package embedder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeEmbeddings serves /v1/embeddings: the embedding of an input is [len(input), 1, 0] and the data
+// comes back in reverse order, fail (when set) can answer a request instead
+type fakeEmbeddings struct {
+	mu       sync.Mutex
+	requests []embeddingRequest
+	auth     []string
+	fail     func(n int, w http.ResponseWriter) bool
+	delay    time.Duration
+}
+
+func (f *fakeEmbeddings) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/embeddings" || r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	var req embeddingRequest
+	json.NewDecoder(r.Body).Decode(&req)
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	f.auth = append(f.auth, r.Header.Get("Authorization"))
+	n := len(f.requests)
+	f.mu.Unlock()
+	if f.delay > 0 {
+		select {
+		case <-time.After(f.delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if f.fail != nil && f.fail(n, w) {
+		return
+	}
+	type item struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	}
+	var resp struct {
+		Data []item `json:"data"`
+	}
+	for i := len(req.Input) - 1; i >= 0; i-- {
+		resp.Data = append(resp.Data, item{i, []float32{float32(len(req.Input[i])), 1, 0}})
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (f *fakeEmbeddings) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.requests)
+}
+
+func setupHTTPEmbedder(t *testing.T, fake *fakeEmbeddings, cfg HTTPConfig) *HTTPEmbedder {
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	cfg.BaseURL = srv.URL + "/v1/"
+	cfg.Model = "test-embed"
+	cfg.Dimension = 3
+	if cfg.Backoff == 0 {
+		cfg.Backoff = time.Millisecond
+	}
+	e, err := NewHTTPEmbedder(cfg)
+	if err != nil {
+		t.Fatalf("failed to create embedder: %v", err)
+	}
+	return e
+}
+
+// Contract: inputs are sent in batches of BatchSize and the vectors come back in input order.
+func TestHTTPEmbedder_Batching(t *testing.T) {
+	fake := &fakeEmbeddings{}
+	e := setupHTTPEmbedder(t, fake, HTTPConfig{BatchSize: 2, APIKey: "secret", SendDimensions: true})
+	inputs := []any{"a", "bb", []byte("ccc"), "dddd", "eeeee"}
+	vecs, err := e.EmbedBatch(context.Background(), inputs)
+	if err != nil {
+		t.Fatalf("embed failed: %v", err)
+	}
+	if fake.count() != 3 {
+		t.Errorf("expected 3 requests, got %d", fake.count())
+	}
+	for i, vec := range vecs {
+		// normalized [n, 1, 0]: the ratio of the first two values gives n back
+		values := vec.Values()
+		if got := int(values[0]/values[1] + 0.5); got != i+1 {
+			t.Errorf("vector %d belongs to input of length %d", i, got)
+		}
+	}
+	if fake.requests[0].Model != "test-embed" || fake.requests[0].Dimensions != 3 || fake.auth[0] != "Bearer secret" {
+		t.Errorf("unexpected request %+v with auth %q", fake.requests[0], fake.auth[0])
+	}
+	if e.Name() != "test-embed" || e.Dimension() != 3 {
+		t.Errorf("unexpected name %s or dimension %d", e.Name(), e.Dimension())
+	}
+	if _, err := e.Embed(context.Background(), 42); err == nil {
+		t.Error("expected a non text input to fail")
+	}
+}
+
+// Contract: 429 and 5xx responses are retried up to MaxRetries, other errors return the API message at once.
+func TestHTTPEmbedder_Retries(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		failures   int
+		maxRetries int
+		wantErr    string
+		wantCalls  int
+	}{
+		{"server error then success", http.StatusServiceUnavailable, 2, 3, "", 3},
+		{"rate limited then success", http.StatusTooManyRequests, 1, 3, "", 2},
+		{"retries exhausted", http.StatusInternalServerError, 5, 2, "overloaded", 3},
+		{"bad request not retried", http.StatusBadRequest, 5, 3, "input too long", 1},
+		{"retries disabled", http.StatusServiceUnavailable, 1, -1, "overloaded", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeEmbeddings{fail: func(n int, w http.ResponseWriter) bool {
+				if n > tt.failures {
+					return false
+				}
+				w.Header().Set("Retry-After", "0")
+				msg := "overloaded"
+				if tt.status == http.StatusBadRequest {
+					msg = "input too long"
+				}
+				w.WriteHeader(tt.status)
+				json.NewEncoder(w).Encode(map[string]any{"error": map[string]string{"message": msg}})
+				return true
+			}}
+			e := setupHTTPEmbedder(t, fake, HTTPConfig{MaxRetries: tt.maxRetries})
+			_, err := e.Embed(context.Background(), "text")
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			var apiErr *APIError
+			if tt.wantErr != "" && (!errors.As(err, &apiErr) || apiErr.Message != tt.wantErr || apiErr.StatusCode != tt.status) {
+				t.Errorf("expected API error %q, got %v", tt.wantErr, err)
+			}
+			if fake.count() != tt.wantCalls {
+				t.Errorf("expected %d requests, got %d", tt.wantCalls, fake.count())
+			}
+		})
+	}
+}
+
+// Contract: responses of the wrong dimension or size are errors, not vectors.
+func TestHTTPEmbedder_InvalidResponse(t *testing.T) {
+	fake := &fakeEmbeddings{fail: func(n int, w http.ResponseWriter) bool {
+		w.Write([]byte(`{"data":[{"index":0,"embedding":[1,2]}]}`))
+		return true
+	}}
+	e := setupHTTPEmbedder(t, fake, HTTPConfig{})
+	if _, err := e.Embed(context.Background(), "x"); err == nil || !strings.Contains(err.Error(), "2 values") {
+		t.Errorf("expected a dimension error, got %v", err)
+	}
+	if _, err := e.EmbedBatch(context.Background(), []any{"x", "y"}); err == nil || !strings.Contains(err.Error(), "1 embeddings for 2 inputs") {
+		t.Errorf("expected a count error, got %v", err)
+	}
+}
+
+// Post-condition: a slow attempt times out and is retried, a cancelled context stops the call.
+func TestHTTPEmbedder_Timeouts(t *testing.T) {
+	fake := &fakeEmbeddings{delay: 200 * time.Millisecond}
+	e := setupHTTPEmbedder(t, fake, HTTPConfig{Timeout: 20 * time.Millisecond, MaxRetries: 1})
+	start := time.Now()
+	_, err := e.Embed(context.Background(), "x")
+	if !errors.Is(err, context.DeadlineExceeded) || fake.count() != 2 {
+		t.Errorf("expected 2 timed out attempts, got %d requests and %v", fake.count(), err)
+	}
+	if time.Since(start) > 150*time.Millisecond {
+		t.Errorf("attempt timeout not applied, took %v", time.Since(start))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	e = setupHTTPEmbedder(t, &fakeEmbeddings{delay: time.Second}, HTTPConfig{})
+	if _, err := e.Embed(ctx, "x"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the context deadline, got %v", err)
+	}
+}
+
+// Invariant: with RequestsPerSecond set, requests start at least 1/RequestsPerSecond apart.
+func TestHTTPEmbedder_RateLimit(t *testing.T) {
+	fake := &fakeEmbeddings{}
+	e := setupHTTPEmbedder(t, fake, HTTPConfig{BatchSize: 1, RequestsPerSecond: 50})
+	start := time.Now()
+	if _, err := e.EmbedBatch(context.Background(), []any{"a", "b", "c", "d"}); err != nil {
+		t.Fatalf("embed failed: %v", err)
+	}
+	// 4 requests at 20ms intervals: the last one starts 60ms in
+	if elapsed := time.Since(start); elapsed < 55*time.Millisecond {
+		t.Errorf("4 requests at 50/s took only %v", elapsed)
+	}
+}
+
+// Contract: NewHTTPEmbedder requires a base url, model and dimension and defaults to text and cosine.
+func TestNewHTTPEmbedder_Config(t *testing.T) {
+	for _, cfg := range []HTTPConfig{
+		{Model: "m", Dimension: 3},
+		{BaseURL: "http://x", Dimension: 3},
+		{BaseURL: "http://x", Model: "m"},
+		{BaseURL: "http://x", Model: "m", Dimension: 3, Metric: 9},
+	} {
+		if _, err := NewHTTPEmbedder(cfg); err == nil {
+			t.Errorf("expected %+v to be rejected", cfg)
+		}
+	}
+	e, err := NewHTTPEmbedder(HTTPConfig{BaseURL: "http://x", Model: "m", Dimension: 3, Name: "registered"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e.DataType().String() != "text" || e.Metric().String() != "cosine" || e.Name() != "registered" {
+		t.Errorf("unexpected defaults %v %v %s", e.DataType(), e.Metric(), e.Name())
+	}
+}
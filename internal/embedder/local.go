@@ -0,0 +1,202 @@
+package embedder
+
+import (
+	"VectorDatabase/internal/types"
+	"VectorDatabase/internal/vector"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// The embedders below run without a model: same input, same vector, on every machine.
+// They are for tests, demos and offline use, not for semantic quality
+
+// tokenize lowercases text and splits it into runs of letters and digits
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// termCounts counts the tokens of a text input, no tokens is an error
+func termCounts(input any) (map[string]int, error) {
+	text, err := textInput(input)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for _, tok := range tokenize(text) {
+		counts[tok]++
+	}
+	if len(counts) == 0 {
+		return nil, errors.New("input has no words to embed")
+	}
+	return counts, nil
+}
+
+// HashingEmbedder is a bag of words embedder using the hashing trick: every word adds its
+// sublinear term frequency 1+ln(tf) to one of dim buckets, with a sign from the same hash
+type HashingEmbedder struct {
+	dim int
+}
+
+func NewHashingEmbedder(dim int) (*HashingEmbedder, error) {
+	if dim <= 0 {
+		return nil, errors.New("dimension must be a positive integer")
+	}
+	return &HashingEmbedder{dim: dim}, nil
+}
+
+func (e *HashingEmbedder) Embed(ctx context.Context, input any) (*vector.Vector, error) {
+	counts, err := termCounts(input)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]float32, e.dim)
+	for term, tf := range counts {
+		h := hashString(term)
+		weight := float32(1 + math.Log(float64(tf)))
+		if h>>63 == 1 {
+			weight = -weight
+		}
+		values[h%uint64(e.dim)] += weight
+	}
+	return vector.NewVector(values, e.dim)
+}
+
+func (e *HashingEmbedder) Dimension() int                 { return e.dim }
+func (e *HashingEmbedder) DataType() types.DataType       { return types.Text }
+func (e *HashingEmbedder) Metric() types.SimilarityMetric { return types.Cosine }
+func (e *HashingEmbedder) Name() string                   { return fmt.Sprintf("hashing-bow-%d", e.dim) }
+
+// TFIDFEmbedder weighs words by TF-IDF with document frequencies learned from a corpus and
+// projects the sparse weights to dim values: every word has a fixed gaussian direction derived
+// from the seed and the word, the embedding is the weighted sum of those directions
+type TFIDFEmbedder struct {
+	dim  int
+	seed uint64
+	idf  map[string]float64
+	// idf of words the corpus doesn't have, they are as rare as it gets
+	unseenIDF float64
+	name      string
+}
+
+// NewTFIDFEmbedder learns document frequencies from corpus. The name identifies dim, seed and
+// corpus, an embedder fitted on another corpus produces vectors that aren't comparable
+func NewTFIDFEmbedder(dim int, corpus []string, seed uint64) (*TFIDFEmbedder, error) {
+	if dim <= 0 {
+		return nil, errors.New("dimension must be a positive integer")
+	}
+	if len(corpus) == 0 {
+		return nil, errors.New("tf-idf needs a corpus")
+	}
+	df := make(map[string]int)
+	for _, doc := range corpus {
+		seen := make(map[string]bool)
+		for _, tok := range tokenize(doc) {
+			if !seen[tok] {
+				seen[tok] = true
+				df[tok]++
+			}
+		}
+	}
+	n := float64(len(corpus))
+	e := &TFIDFEmbedder{dim: dim, seed: seed, idf: make(map[string]float64, len(df)), unseenIDF: math.Log(1+n) + 1}
+	terms := make([]string, 0, len(df))
+	for term, count := range df {
+		// smoothed idf, never zero so words in every document still count a little
+		e.idf[term] = math.Log((1+n)/(1+float64(count))) + 1
+		terms = append(terms, term)
+	}
+	slices.Sort(terms)
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d/%d/%d", dim, seed, len(corpus))
+	for _, term := range terms {
+		fmt.Fprintf(h, "/%s:%d", term, df[term])
+	}
+	e.name = fmt.Sprintf("tfidf-rp-%d-%08x", dim, uint32(h.Sum64()))
+	return e, nil
+}
+
+func (e *TFIDFEmbedder) Embed(ctx context.Context, input any) (*vector.Vector, error) {
+	counts, err := termCounts(input)
+	if err != nil {
+		return nil, err
+	}
+	terms := make([]string, 0, len(counts))
+	for term := range counts {
+		terms = append(terms, term)
+	}
+	// fixed order so float sums are the same on every run
+	slices.Sort(terms)
+	sum := make([]float64, e.dim)
+	for _, term := range terms {
+		idf, ok := e.idf[term]
+		if !ok {
+			idf = e.unseenIDF
+		}
+		weight := (1 + math.Log(float64(counts[term]))) * idf
+		rng := rand.New(rand.NewPCG(e.seed, hashString(term)))
+		for i := range sum {
+			sum[i] += weight * rng.NormFloat64()
+		}
+	}
+	values := make([]float32, e.dim)
+	for i, val := range sum {
+		values[i] = float32(val)
+	}
+	return vector.NewVector(values, e.dim)
+}
+
+func (e *TFIDFEmbedder) Dimension() int                 { return e.dim }
+func (e *TFIDFEmbedder) DataType() types.DataType       { return types.Text }
+func (e *TFIDFEmbedder) Metric() types.SimilarityMetric { return types.Cosine }
+func (e *TFIDFEmbedder) Name() string                   { return e.name }
+
+// RandomEmbedder maps every distinct input to a gaussian vector seeded by seed and the input,
+// unrelated inputs get unrelated vectors. Text is hashed as is, anything else by its %v form
+type RandomEmbedder struct {
+	dim  int
+	seed uint64
+}
+
+func NewRandomEmbedder(dim int, seed uint64) (*RandomEmbedder, error) {
+	if dim <= 0 {
+		return nil, errors.New("dimension must be a positive integer")
+	}
+	return &RandomEmbedder{dim: dim, seed: seed}, nil
+}
+
+func (e *RandomEmbedder) Embed(ctx context.Context, input any) (*vector.Vector, error) {
+	key, err := textInput(input)
+	if err != nil {
+		key = fmt.Sprintf("%T:%v", input, input)
+	}
+	rng := rand.New(rand.NewPCG(e.seed, hashString(key)))
+	values := make([]float32, e.dim)
+	for i := range values {
+		values[i] = float32(rng.NormFloat64())
+	}
+	return vector.NewVector(values, e.dim)
+}
+
+func (e *RandomEmbedder) Dimension() int                 { return e.dim }
+func (e *RandomEmbedder) DataType() types.DataType       { return types.Text }
+func (e *RandomEmbedder) Metric() types.SimilarityMetric { return types.Cosine }
+func (e *RandomEmbedder) Name() string                   { return fmt.Sprintf("random-%d-seed-%d", e.dim, e.seed) }
+
+var _ Embedder = (*HashingEmbedder)(nil)
+var _ Embedder = (*TFIDFEmbedder)(nil)
+var _ Embedder = (*RandomEmbedder)(nil)
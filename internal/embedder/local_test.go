@@ -0,0 +1,161 @@
+package embedder
+
+import (
+	"VectorDatabase/internal/vector"
+	"context"
+	"slices"
+	"testing"
+)
+
+var localCorpus = []string{
+	"the cat sat on the mat",
+	"a cat chased the mouse",
+	"stock markets fell sharply today",
+	"the central bank raised interest rates",
+	"the dog slept on the porch",
+}
+
+func localEmbedders(t *testing.T) map[string]func() Embedder {
+	t.Helper()
+	return map[string]func() Embedder{
+		"hashing": func() Embedder {
+			e, err := NewHashingEmbedder(64)
+			if err != nil {
+				t.Fatalf("NewHashingEmbedder failed: %v", err)
+			}
+			return e
+		},
+		"tfidf": func() Embedder {
+			e, err := NewTFIDFEmbedder(64, localCorpus, 7)
+			if err != nil {
+				t.Fatalf("NewTFIDFEmbedder failed: %v", err)
+			}
+			return e
+		},
+		"random": func() Embedder {
+			e, err := NewRandomEmbedder(64, 7)
+			if err != nil {
+				t.Fatalf("NewRandomEmbedder failed: %v", err)
+			}
+			return e
+		},
+	}
+}
+
+func mustEmbed(t *testing.T, e Embedder, input any) *vector.Vector {
+	t.Helper()
+	vec, err := e.Embed(context.Background(), input)
+	if err != nil {
+		t.Fatalf("%s: embed %v failed: %v", e.Name(), input, err)
+	}
+	return vec
+}
+
+// Invariant: separately built local embedders with the same parameters give the same name and the same
+// normalized vector of Dimension values for the same input, text as string or []byte.
+func TestLocalEmbedders_Deterministic(t *testing.T) {
+	for name, build := range localEmbedders(t) {
+		t.Run(name, func(t *testing.T) {
+			a, b := build(), build()
+			if a.Name() != b.Name() {
+				t.Errorf("names differ: %s, %s", a.Name(), b.Name())
+			}
+			va := mustEmbed(t, a, "The cat sat on the mat")
+			vb := mustEmbed(t, b, []byte("The cat sat on the mat"))
+			if va.Dimensions() != a.Dimension() {
+				t.Errorf("expected %d values, got %d", a.Dimension(), va.Dimensions())
+			}
+			if !slices.Equal(va.Values(), vb.Values()) {
+				t.Error("same input embedded differently")
+			}
+			if m := vector.Magnitude(va.Values()); m < 0.999 || m > 1.001 {
+				t.Errorf("expected a unit vector, magnitude %f", m)
+			}
+		})
+	}
+}
+
+// Contract: the text embedders put texts sharing words closer than unrelated texts, and ignore case and punctuation.
+func TestLocalEmbedders_Similarity(t *testing.T) {
+	for name, build := range localEmbedders(t) {
+		if name == "random" {
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			e := build()
+			query := mustEmbed(t, e, "the cat sat on the mat")
+			near := mustEmbed(t, e, "a cat sat on a mat")
+			far := mustEmbed(t, e, "interest rates and stock markets")
+			if vector.DotProduct(query.Values(), near.Values()) <= vector.DotProduct(query.Values(), far.Values()) {
+				t.Error("related text isn't closer than unrelated text")
+			}
+			same := mustEmbed(t, e, "THE CAT, sat on: the mat!")
+			if !slices.Equal(query.Values(), same.Values()) {
+				t.Error("case and punctuation changed the embedding")
+			}
+		})
+	}
+}
+
+// Contract: TF-IDF weighs a rare word above a word of every document, so sharing it counts more.
+func TestTFIDFEmbedder_RareWords(t *testing.T) {
+	e, err := NewTFIDFEmbedder(256, localCorpus, 1)
+	if err != nil {
+		t.Fatalf("NewTFIDFEmbedder failed: %v", err)
+	}
+	query := mustEmbed(t, e, "the mouse")
+	sharesRare := mustEmbed(t, e, "mouse bank")
+	sharesCommon := mustEmbed(t, e, "the bank")
+	if vector.DotProduct(query.Values(), sharesRare.Values()) <= vector.DotProduct(query.Values(), sharesCommon.Values()) {
+		t.Error("sharing a rare word should score above sharing a common one")
+	}
+	other, _ := NewTFIDFEmbedder(256, localCorpus[:2], 1)
+	if other.Name() == e.Name() {
+		t.Error("embedders fitted on different corpora share a name")
+	}
+}
+
+// Contract: random embedders with other seeds produce other vectors, distinct inputs get distinct vectors,
+// and non text inputs are embedded by value.
+func TestRandomEmbedder(t *testing.T) {
+	a, _ := NewRandomEmbedder(16, 1)
+	b, _ := NewRandomEmbedder(16, 2)
+	if a.Name() == b.Name() {
+		t.Error("seeds share a name")
+	}
+	if slices.Equal(mustEmbed(t, a, "x").Values(), mustEmbed(t, b, "x").Values()) {
+		t.Error("seeds produced the same vector")
+	}
+	if slices.Equal(mustEmbed(t, a, "x").Values(), mustEmbed(t, a, "y").Values()) {
+		t.Error("inputs produced the same vector")
+	}
+	if !slices.Equal(mustEmbed(t, a, 42).Values(), mustEmbed(t, a, 42).Values()) {
+		t.Error("non text input isn't deterministic")
+	}
+}
+
+// Contract: constructors reject bad parameters, the text embedders reject input without words or of another type.
+func TestLocalEmbedders_Errors(t *testing.T) {
+	if _, err := NewHashingEmbedder(0); err == nil {
+		t.Error("hashing: expected zero dimension to fail")
+	}
+	if _, err := NewTFIDFEmbedder(8, nil, 1); err == nil {
+		t.Error("tfidf: expected an empty corpus to fail")
+	}
+	if _, err := NewRandomEmbedder(-1, 1); err == nil {
+		t.Error("random: expected a negative dimension to fail")
+	}
+	for name, build := range localEmbedders(t) {
+		if name == "random" {
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			e := build()
+			for _, input := range []any{"", " ,.!? ", 42} {
+				if _, err := e.Embed(context.Background(), input); err == nil {
+					t.Errorf("expected %q to fail", input)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package index
+
+import (
+	"VectorDatabase/internal/store"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// CheckIssue is one problem CheckCollection found, Offset is the byte offset in File or -1 when the
+// problem isn't tied to one (a failed file checksum, a count mismatch)
+type CheckIssue struct {
+	File    string
+	Offset  int64
+	Problem string
+}
+
+func (i CheckIssue) String() string {
+	if i.Offset < 0 {
+		return i.File + ": " + i.Problem
+	}
+	return fmt.Sprintf("%s at offset %d: %s", i.File, i.Offset, i.Problem)
+}
+
+// CheckOptions pick the repairs CheckCollection may apply, it only reads without them
+type CheckOptions struct {
+	// TruncateWAL cuts the WAL before its first bad frame: a torn tail left by a crash,
+	// or a corrupt frame (the records after it are lost)
+	TruncateWAL bool
+	// Rebuild rewrites the collection from every vector that is still readable and valid
+	Rebuild bool
+}
+
+// CheckReport is the outcome of CheckCollection
+type CheckReport struct {
+	Segments   int
+	Vectors    int
+	WALRecords int
+	Issues     []CheckIssue
+	// Orphans are files a crashed flush or compaction left behind, the next open removes them
+	Orphans []string
+	// Repairs describes every repair applied, the collection should be checked again after one
+	Repairs []string
+}
+
+// OK reports a collection without issues
+func (r *CheckReport) OK() bool {
+	return len(r.Issues) == 0
+}
+
+func (r *CheckReport) issue(file string, offset int64, format string, args ...any) {
+	r.Issues = append(r.Issues, CheckIssue{File: file, Offset: offset, Problem: fmt.Sprintf(format, args...)})
+}
+
+// checkState is the content of a collection as far as it can be read
+type checkState struct {
+	cfg      IndexConfig
+	manifest store.Manifest
+	// segments that decoded, by file name
+	segments map[string]store.SegmentData
+	wal      []store.WALRecord
+	// where the intact prefix of the WAL ends, -1 when the whole WAL is intact
+	walEnd int64
+}
+
+// CheckCollection verifies the collection in dir without opening it: the MANIFEST and the config
+// it holds, the checksum, header and records of every segment, the WAL frames and that every stored
+// vector is one NewVector accepts at the config dimension. Repairs run only when opts asks for them,
+// they take the collection lock first and fail with store.ErrLocked while another process has it open
+func CheckCollection(dir string, opts CheckOptions) (*CheckReport, error) {
+	dir = filepath.Clean(dir)
+	if err := recoverCollectionRebuild(dir); err != nil {
+		return nil, err
+	}
+	report := &CheckReport{}
+	state, err := checkManifest(dir, report)
+	if err != nil {
+		return report, err
+	}
+	if opts.TruncateWAL || opts.Rebuild {
+		lock, err := store.LockDir(dir)
+		if err != nil {
+			return report, fmt.Errorf("can't repair %s: %w", dir, err)
+		}
+		defer lock.Unlock()
+	}
+	checkSegments(dir, state, report)
+	checkWAL(dir, state, report)
+	report.Vectors = len(liveRecords(state, nil))
+	if report.OK() {
+		return report, nil
+	}
+	switch {
+	case opts.Rebuild:
+		if err := rebuildCollection(dir, state, report); err != nil {
+			return report, fmt.Errorf("rebuild failed: %w", err)
+		}
+	case opts.TruncateWAL && state.walEnd >= 0:
+		if err := os.Truncate(filepath.Join(dir, store.WALFileName), state.walEnd); err != nil {
+			return report, err
+		}
+		report.Repairs = append(report.Repairs, fmt.Sprintf("truncated %s to %d bytes", store.WALFileName, state.walEnd))
+	}
+	return report, nil
+}
+
+// checkManifest fails when there is no readable config, nothing else can be checked then
+func checkManifest(dir string, report *CheckReport) (*checkState, error) {
+	m, err := store.ReadManifest(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no collection in %s", dir)
+	}
+	if err != nil {
+		report.issue(store.ManifestFileName, -1, "%v", err)
+		return nil, err
+	}
+	state := &checkState{manifest: m, segments: make(map[string]store.SegmentData), walEnd: -1}
+	if err := json.Unmarshal(m.Config, &state.cfg); err != nil {
+		report.issue(store.ManifestFileName, -1, "invalid index config: %v", err)
+		return nil, err
+	}
+	var prev uint64
+	for _, info := range m.Segments {
+		if info.File != store.SegmentFileName(info.ID) {
+			report.issue(store.ManifestFileName, -1, "segment %d listed with file %s", info.ID, info.File)
+		}
+		if info.ID <= prev || info.ID >= m.NextSegment {
+			report.issue(store.ManifestFileName, -1, "segment %d out of order (next segment %d)", info.ID, m.NextSegment)
+		}
+		if info.MaxLSN > m.FlushedLSN {
+			report.issue(store.ManifestFileName, -1, "segment %d holds lsn %d past the flushed lsn %d", info.ID, info.MaxLSN, m.FlushedLSN)
+		}
+		prev = info.ID
+	}
+	return state, nil
+}
+
+func checkSegments(dir string, state *checkState, report *CheckReport) {
+	dim := state.cfg.Dimension()
+	live := make(map[string]bool)
+	for _, info := range state.manifest.Segments {
+		live[info.File] = true
+		live[store.SegmentIndexDir(info.ID)] = true
+		data, err := store.ReadSegment(filepath.Join(dir, info.File))
+		if err != nil {
+			report.issue(info.File, -1, "%v", err)
+			continue
+		}
+		report.Segments++
+		state.segments[info.File] = data
+		if data.Dimension != dim {
+			report.issue(info.File, -1, "dimension %d, config dimension is %d", data.Dimension, dim)
+		}
+		if len(data.Entries) != info.Entries || len(data.Tombstones) != info.Tombstones {
+			report.issue(info.File, -1, "%d entries and %d tombstones, manifest lists %d and %d",
+				len(data.Entries), len(data.Tombstones), info.Entries, info.Tombstones)
+		}
+		if data.MinLSN != info.MinLSN || data.MaxLSN != info.MaxLSN {
+			report.issue(info.File, -1, "lsn range %d-%d, manifest lists %d-%d", data.MinLSN, data.MaxLSN, info.MinLSN, info.MaxLSN)
+		}
+		seen := make(map[string]bool, len(data.Entries))
+		for _, rec := range data.Entries {
+			if seen[rec.ID] {
+				report.issue(info.File, -1, "duplicate record %q", rec.ID)
+			}
+			seen[rec.ID] = true
+			if _, _, err := decodeRecord(rec, dim); err != nil {
+				report.issue(info.File, -1, "%v", err)
+			}
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		report.issue(".", -1, "%v", err)
+		return
+	}
+	for _, e := range entries {
+		name := e.Name()
+		segFile := strings.HasSuffix(name, ".vseg") || strings.HasSuffix(name, ".index")
+		if (strings.HasPrefix(name, "seg-") && segFile && !live[name]) || strings.HasSuffix(name, ".tmp") {
+			report.Orphans = append(report.Orphans, name)
+		}
+	}
+}
+
+func checkWAL(dir string, state *checkState, report *CheckReport) {
+	records, end, err := store.ScanWAL(filepath.Join(dir, store.WALFileName))
+	state.wal = records
+	report.WALRecords = len(records)
+	if err != nil {
+		state.walEnd = end
+		problem := err.Error()
+		if errors.Is(err, store.ErrTornWAL) {
+			problem = "incomplete frame, a crash during an append leaves one"
+		} else if errors.Is(err, store.ErrCorrupt) {
+			problem = strings.TrimSuffix(problem, fmt.Sprintf(" at offset %d", end)) + ", the records after it can't be replayed"
+		}
+		if info, err := os.Stat(filepath.Join(dir, store.WALFileName)); err == nil {
+			problem += fmt.Sprintf(" (%d bytes)", info.Size()-end)
+		}
+		report.issue(store.WALFileName, end, "%s", problem)
+	}
+	for _, rec := range records {
+		if rec.Op == store.WALAdd && rec.LSN > state.manifest.FlushedLSN {
+			if _, _, err := decodeRecord(rec.Record, state.cfg.Dimension()); err != nil {
+				report.issue(store.WALFileName, -1, "lsn %d: %v", rec.LSN, err)
+			}
+		}
+	}
+}
+
+// liveRecords replays what was read the way OpenSegmentedIndex would: segments oldest first
+// (tombstones hide older copies, entries win within a segment), then the unflushed WAL records.
+// Records that fail to decode are dropped and passed to skip when it isn't nil
+func liveRecords(state *checkState, skip func(id string, err error)) map[string]store.Record {
+	dim := state.cfg.Dimension()
+	live := make(map[string]store.Record)
+	add := func(rec store.Record) {
+		if _, _, err := decodeRecord(rec, dim); err != nil {
+			if skip != nil {
+				skip(rec.ID, err)
+			}
+			return
+		}
+		live[rec.ID] = rec
+	}
+	for _, info := range state.manifest.Segments {
+		data, ok := state.segments[info.File]
+		if !ok {
+			continue
+		}
+		for _, id := range data.Tombstones {
+			delete(live, id)
+		}
+		for _, rec := range data.Entries {
+			add(rec)
+		}
+	}
+	for _, rec := range state.wal {
+		if rec.LSN <= state.manifest.FlushedLSN {
+			continue
+		}
+		switch rec.Op {
+		case store.WALAdd:
+			add(rec.Record)
+		case store.WALDelete:
+			delete(live, rec.Record.ID)
+		}
+	}
+	return live
+}
+
+// rebuildCollection writes every live, valid vector into a fresh collection next to dir and swaps
+// it in through dir+".old", recoverCollectionRebuild finishes a swap a crash interrupted
+func rebuildCollection(dir string, state *checkState, report *CheckReport) error {
+	dropped := 0
+	live := liveRecords(state, func(string, error) { dropped++ })
+	tmp := dir + ".rebuild"
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	si, err := OpenSegmentedIndex(tmp, state.cfg, SegmentedOptions{})
+	if err != nil {
+		return err
+	}
+	ids := make([]string, 0, len(live))
+	for id := range live {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	for _, id := range ids {
+		vec, md, _ := decodeRecord(live[id], state.cfg.Dimension())
+		if _, err := si.AddWithMetadata(id, vec, md); err != nil {
+			si.Close()
+			return err
+		}
+	}
+	if err := errors.Join(si.Flush(), si.Close()); err != nil {
+		return err
+	}
+	old := dir + ".old"
+	if err := os.Rename(dir, old); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(old); err != nil {
+		return err
+	}
+	report.Repairs = append(report.Repairs, fmt.Sprintf("rebuilt the collection from %d vectors, dropped %d invalid records", len(ids), dropped))
+	return nil
+}
+
+// recoverCollectionRebuild puts the old collection back when a rebuild crashed before its swap
+// completed and clears what a rebuild left behind
+func recoverCollectionRebuild(dir string) error {
+	old := dir + ".old"
+	if _, err := os.Stat(old); err == nil {
+		if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+			if err := os.Rename(old, dir); err != nil {
+				return err
+			}
+		} else if err := os.RemoveAll(old); err != nil {
+			return err
+		}
+	}
+	return os.RemoveAll(dir + ".rebuild")
+}
@@ -0,0 +1,195 @@
+package index
+
+import (
+	"VectorDatabase/internal/store"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Helper to fill a collection with 7 vectors (two sealed segments, one in the WAL only) and close it
+func setupCheckedCollection(t *testing.T) string {
+	dir := filepath.Join(t.TempDir(), "docs")
+	si := setupSegmented(t, dir)
+	for i := 0; i < 7; i++ {
+		si.AddWithMetadata(fmt.Sprintf("vec-%d", i), vec2(1, float32(i)), Metadata{"n": float64(i)})
+	}
+	si.Delete("vec-1")
+	if err := si.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	return dir
+}
+
+func hasIssue(report *CheckReport, file, problem string) bool {
+	for _, issue := range report.Issues {
+		if issue.File == file && strings.Contains(issue.Problem, problem) {
+			return true
+		}
+	}
+	return false
+}
+
+// Post-condition: a cleanly closed collection checks without issues and counts its live vectors.
+func TestCheckCollection_Clean(t *testing.T) {
+	dir := setupCheckedCollection(t)
+	report, err := CheckCollection(dir, CheckOptions{})
+	if err != nil {
+		t.Fatalf("check failed: %v", err)
+	}
+	if !report.OK() {
+		t.Errorf("unexpected issues: %v", report.Issues)
+	}
+	if report.Vectors != 6 || report.Segments != 2 {
+		t.Errorf("expected 6 vectors in 2 segments, got %d in %d", report.Vectors, report.Segments)
+	}
+	if _, err := CheckCollection(t.TempDir(), CheckOptions{}); err == nil {
+		t.Error("expected an error for a directory without a collection")
+	}
+}
+
+// Contract: a torn WAL tail is reported at the offset where it starts and TruncateWAL cuts it off.
+func TestCheckCollection_TornWAL(t *testing.T) {
+	dir := setupCheckedCollection(t)
+	walPath := filepath.Join(dir, store.WALFileName)
+	info, _ := os.Stat(walPath)
+	f, _ := os.OpenFile(walPath, os.O_APPEND|os.O_WRONLY, 0)
+	f.Write([]byte{40, 0, 0, 0, 1, 2})
+	f.Close()
+
+	report, err := CheckCollection(dir, CheckOptions{})
+	if err != nil {
+		t.Fatalf("check failed: %v", err)
+	}
+	if len(report.Issues) != 1 || report.Issues[0].Offset != info.Size() || !hasIssue(report, store.WALFileName, "incomplete frame") {
+		t.Fatalf("expected a torn tail at offset %d, got %v", info.Size(), report.Issues)
+	}
+	if len(report.Repairs) != 0 {
+		t.Errorf("check without options repaired: %v", report.Repairs)
+	}
+	report, err = CheckCollection(dir, CheckOptions{TruncateWAL: true})
+	if err != nil || len(report.Repairs) != 1 {
+		t.Fatalf("expected the wal to be truncated, got %v (err %v)", report.Repairs, err)
+	}
+	if after, _ := os.Stat(walPath); after.Size() != info.Size() {
+		t.Errorf("expected wal size %d after truncation, got %d", info.Size(), after.Size())
+	}
+	if report, _ := CheckCollection(dir, CheckOptions{}); !report.OK() {
+		t.Errorf("issues left after repair: %v", report.Issues)
+	}
+}
+
+// Contract: records NewVector rejects and damaged segments are reported, Rebuild keeps every valid vector.
+func TestCheckCollection_Rebuild(t *testing.T) {
+	dir := setupCheckedCollection(t)
+	m, _ := store.ReadManifest(dir)
+	wal, _, err := store.OpenWAL(filepath.Join(dir, store.WALFileName), m.FlushedLSN, false)
+	if err != nil {
+		t.Fatalf("failed to open wal: %v", err)
+	}
+	wal.Append(store.WALAdd, store.Record{ID: "bad-dim", Values: []float32{1, 2, 3}})
+	wal.Append(store.WALAdd, store.Record{ID: "zero", Values: []float32{0, 0}})
+	wal.Close()
+	// flip a byte in the middle of the oldest segment, vec-0, vec-2 (vec-1 was deleted) are lost
+	segPath := filepath.Join(dir, m.Segments[0].File)
+	data, _ := os.ReadFile(segPath)
+	data[len(data)/2] ^= 0xff
+	os.WriteFile(segPath, data, 0o644)
+
+	report, err := CheckCollection(dir, CheckOptions{})
+	if err != nil {
+		t.Fatalf("check failed: %v", err)
+	}
+	for _, want := range []struct{ file, problem string }{
+		{m.Segments[0].File, "checksum mismatch"},
+		{store.WALFileName, `"bad-dim"`},
+		{store.WALFileName, `"zero"`},
+	} {
+		if !hasIssue(report, want.file, want.problem) {
+			t.Errorf("expected an issue %q in %s, got %v", want.problem, want.file, report.Issues)
+		}
+	}
+	if _, err := OpenSegmentedIndex(dir, mustConfig(t, dir), SegmentedOptions{}); err == nil {
+		t.Fatal("expected the damaged collection to fail to open")
+	}
+
+	report, err = CheckCollection(dir, CheckOptions{Rebuild: true})
+	if err != nil || len(report.Repairs) != 1 {
+		t.Fatalf("expected a rebuild, got %v (err %v)", report.Repairs, err)
+	}
+	if report, _ := CheckCollection(dir, CheckOptions{}); !report.OK() {
+		t.Errorf("issues left after rebuild: %v", report.Issues)
+	}
+	si := setupSegmented(t, dir)
+	defer si.Close()
+	if si.Size() != 4 {
+		t.Errorf("expected 4 vectors after the rebuild, got %d", si.Size())
+	}
+	if md, ok := si.Metadata("vec-6"); !ok || md["n"] != float64(6) {
+		t.Errorf("metadata lost in the rebuild: %v", md)
+	}
+	if _, err := os.Stat(dir + ".old"); !os.IsNotExist(err) {
+		t.Errorf("old collection left behind: %v", err)
+	}
+}
+
+// Invariant: a rebuild interrupted before its swap finished leaves the original collection in place.
+func TestCheckCollection_RecoverRebuild(t *testing.T) {
+	dir := setupCheckedCollection(t)
+	os.Rename(dir, dir+".old")
+	os.MkdirAll(dir+".rebuild", 0o755)
+	report, err := CheckCollection(dir, CheckOptions{})
+	if err != nil || !report.OK() || report.Vectors != 6 {
+		t.Fatalf("expected the collection back with 6 vectors, got %+v (err %v)", report, err)
+	}
+	if _, err := os.Stat(dir + ".rebuild"); !os.IsNotExist(err) {
+		t.Errorf("rebuild directory left behind: %v", err)
+	}
+}
+
+// Contract: segment files and segment index directories the manifest doesn't list and temp files are
+// reported as orphans.
+func TestCheckCollection_Orphans(t *testing.T) {
+	dir := setupCheckedCollection(t)
+	os.WriteFile(filepath.Join(dir, store.SegmentFileName(99)), nil, 0o644)
+	os.MkdirAll(filepath.Join(dir, store.SegmentIndexDir(99)), 0o755)
+	os.WriteFile(filepath.Join(dir, "MANIFEST.tmp"), nil, 0o644)
+	report, err := CheckCollection(dir, CheckOptions{})
+	if err != nil {
+		t.Fatalf("check failed: %v", err)
+	}
+	want := []string{"MANIFEST.tmp", store.SegmentIndexDir(99), store.SegmentFileName(99)}
+	if !slices.Equal(report.Orphans, want) {
+		t.Errorf("expected orphans %v, got %v", want, report.Orphans)
+	}
+}
+
+// Contract: repairs fail with store.ErrLocked while the collection is open, a plain check still runs.
+func TestCheckCollection_Locked(t *testing.T) {
+	dir := setupCheckedCollection(t)
+	si := setupSegmented(t, dir)
+	for _, opts := range []CheckOptions{{TruncateWAL: true}, {Rebuild: true}} {
+		if _, err := CheckCollection(dir, opts); !errors.Is(err, store.ErrLocked) {
+			t.Errorf("expected ErrLocked for %+v, got %v", opts, err)
+		}
+	}
+	if report, err := CheckCollection(dir, CheckOptions{}); err != nil || !report.OK() {
+		t.Errorf("expected a plain check to pass, got %v (err %v)", report, err)
+	}
+	si.Close()
+	if _, err := CheckCollection(dir, CheckOptions{TruncateWAL: true}); err != nil {
+		t.Errorf("expected the repair to run once the collection is closed, got %v", err)
+	}
+}
+
+func mustConfig(t *testing.T, dir string) IndexConfig {
+	cfg, err := LoadSegmentedConfig(dir)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	return cfg
+}
@@ -2,30 +2,48 @@ package index
 
 import (
 	"VectorDatabase/internal/types"
+	"encoding/json"
 	"errors"
+	"fmt"
 )
 
 type IndexConfig struct {
 	indexType types.IndexType
-	modelType types.ModelType
+	// model is kept whole, a stored config loads without the model being registered
+	model     types.ModelInfo
 	dataType  types.DataType
 	metric    types.SimilarityMetric
 	dimension int
 }
 
-// IndexConfig constructor with invariants checks
+// IndexConfig constructor with invariants checks, the model must be registered
 func NewIndexConfig(
 	indexType types.IndexType,
 	modelType types.ModelType,
 	dataType types.DataType,
 	metric types.SimilarityMetric,
 	dimension int,
+) (IndexConfig, error) {
+	model, ok := types.LookupModel(modelType)
+	if !ok {
+		return IndexConfig{}, errors.New("invalid model type")
+	}
+	return newIndexConfig(indexType, model, dataType, metric, dimension)
+}
+
+// newIndexConfig checks the invariants of a config for model without looking it up
+func newIndexConfig(
+	indexType types.IndexType,
+	model types.ModelInfo,
+	dataType types.DataType,
+	metric types.SimilarityMetric,
+	dimension int,
 ) (IndexConfig, error) {
 	if dimension <= 0 {
 		return IndexConfig{}, errors.New("invalid dimension")
 	}
 	switch indexType {
-	case types.LinearIndex, types.HNSWIndex, types.IVFIndex, types.PQIndex:
+	case types.LinearIndex, types.HNSWIndex, types.IVFIndex, types.PQIndex, types.DiskANNIndex:
 		//ok valid input
 	default:
 		return IndexConfig{}, errors.New("invalid index type")
@@ -42,16 +60,15 @@ func NewIndexConfig(
 	default:
 		return IndexConfig{}, errors.New("invalid metric type")
 	}
-	//==================TODO: Add models types============================
-	switch modelType {
-	case types.Testmodel:
-		//ok
-	default:
-		return IndexConfig{}, errors.New("invalid model type")
+	if err := model.Validate(); err != nil {
+		return IndexConfig{}, err
+	}
+	if err := model.Accepts(dataType, metric, dimension); err != nil {
+		return IndexConfig{}, err
 	}
 	return IndexConfig{
 		indexType: indexType,
-		modelType: modelType,
+		model:     model,
 		dataType:  dataType,
 		metric:    metric,
 		dimension: dimension,
@@ -62,17 +79,121 @@ func NewIndexConfig(
 //getters for IndexConfig
 
 func (c IndexConfig) IndexType() types.IndexType     { return c.indexType }
-func (c IndexConfig) ModelType() types.ModelType     { return c.modelType }
+func (c IndexConfig) ModelType() types.ModelType     { return c.model.Name }
+func (c IndexConfig) Model() types.ModelInfo         { return c.model }
 func (c IndexConfig) DataType() types.DataType       { return c.dataType }
 func (c IndexConfig) Metric() types.SimilarityMetric { return c.metric }
 func (c IndexConfig) Dimension() int                 { return c.dimension }
 
+// CheckEmbedding reports why vectors embedded from dataType input for metric, with dimension values,
+// don't belong in an index with this config, nil when they do
+func (c IndexConfig) CheckEmbedding(dataType types.DataType, metric types.SimilarityMetric, dimension int) error {
+	if dataType != c.dataType {
+		return fmt.Errorf("data type %s doesn't match index data type %s", dataType, c.dataType)
+	}
+	if metric != c.metric {
+		return fmt.Errorf("similarity metric %s doesn't match index metric %s", metric, c.metric)
+	}
+	if dimension != c.dimension {
+		return fmt.Errorf("dimension %d doesn't match index dimension %d", dimension, c.dimension)
+	}
+	return nil
+}
+
+// indexConfigJSON is the persisted form of IndexConfig
+type indexConfigJSON struct {
+	IndexType types.IndexType        `json:"index_type"`
+	ModelType types.ModelType        `json:"model_type"`
+	Model     types.ModelInfo        `json:"model"`
+	DataType  types.DataType         `json:"data_type"`
+	Metric    types.SimilarityMetric `json:"metric"`
+	Dimension int                    `json:"dimension"`
+}
+
+func (c IndexConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(indexConfigJSON{
+		IndexType: c.indexType,
+		ModelType: c.model.Name,
+		Model:     c.model,
+		DataType:  c.dataType,
+		Metric:    c.metric,
+		Dimension: c.dimension,
+	})
+}
+
+// UnmarshalJSON checks the same invariants as NewIndexConfig against the stored model, not the
+// registry: a collection stays readable by processes that never registered its model
+func (c *IndexConfig) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		IndexType json.RawMessage  `json:"index_type"`
+		ModelType json.RawMessage  `json:"model_type"`
+		Model     *types.ModelInfo `json:"model"`
+		DataType  json.RawMessage  `json:"data_type"`
+		Metric    json.RawMessage  `json:"metric"`
+		Dimension int              `json:"dimension"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	var stored indexConfigJSON
+	err := errors.Join(
+		decodeEnum(raw.IndexType, &stored.IndexType, types.ParseIndexType),
+		decodeEnum(raw.DataType, &stored.DataType, types.ParseDataType),
+		decodeEnum(raw.Metric, &stored.Metric, types.ParseSimilarityMetric),
+	)
+	if err != nil {
+		return err
+	}
+	// configs stored before the model registry hold a number for the placeholder model of the time
+	if err := json.Unmarshal(raw.ModelType, &stored.ModelType); err != nil {
+		var legacy int
+		if json.Unmarshal(raw.ModelType, &legacy) != nil {
+			return fmt.Errorf("invalid model type %s", raw.ModelType)
+		}
+		stored.ModelType = types.ExternalModel
+	}
+	// configs stored before the model was kept whole only hold its name, its properties are left open
+	model := types.ModelInfo{Name: stored.ModelType}
+	if raw.Model != nil {
+		if raw.Model.Name != stored.ModelType {
+			return fmt.Errorf("model %s doesn't match model type %s", raw.Model.Name, stored.ModelType)
+		}
+		model = *raw.Model
+	}
+	cfg, err := newIndexConfig(stored.IndexType, model, stored.DataType, stored.Metric, raw.Dimension)
+	if err != nil {
+		return err
+	}
+	*c = cfg
+	return nil
+}
+
+// decodeEnum reads an enum stored by name, or by number the way configs were stored
+// before the enums had names, back when they counted from 0
+func decodeEnum[T ~int](raw json.RawMessage, dst *T, parse func(string) (T, error)) error {
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		value, err := parse(name)
+		if err != nil {
+			return err
+		}
+		*dst = value
+		return nil
+	}
+	var legacy int
+	if err := json.Unmarshal(raw, &legacy); err != nil {
+		return fmt.Errorf("invalid enum value %s", raw)
+	}
+	*dst = T(legacy + 1)
+	return nil
+}
+
 // validate config
 func (c IndexConfig) Validate() error {
 	if c.indexType == 0 {
 		return errors.New("index type is required")
 	}
-	if c.modelType == 0 {
+	if c.model.Name == "" {
 		return errors.New("model type is required")
 	}
 	if c.dataType == 0 {
@@ -2,6 +2,8 @@ package index
 
 import (
 	"VectorDatabase/internal/types"
+	"encoding/json"
+	"strings"
 	"testing"
 )
 
@@ -69,6 +71,16 @@ func TestNewIndexConfig_Contracts(t *testing.T) {
 			expectError: true,
 			errorMsg:    "invalid data type",
 		},
+		{
+			name:        "Contract Violation: Missing DataType (zero value)",
+			indexType:   types.LinearIndex,
+			modelType:   types.Testmodel,
+			dataType:    0,
+			metric:      types.Cosine,
+			dimension:   128,
+			expectError: true,
+			errorMsg:    "invalid data type",
+		},
 		{
 			name:        "Contract Violation: Invalid Metric",
 			indexType:   types.LinearIndex,
@@ -82,7 +94,7 @@ func TestNewIndexConfig_Contracts(t *testing.T) {
 		{
 			name:        "Contract Violation: Invalid ModelType",
 			indexType:   types.LinearIndex,
-			modelType:   types.ModelType(-1),
+			modelType:   types.ModelType("no-such-model"),
 			dataType:    types.Text,
 			metric:      types.Cosine,
 			dimension:   128,
@@ -148,3 +160,97 @@ func TestIndexConfig_InvariantsAndGetters(t *testing.T) {
 		t.Errorf("Invariant broken: Expected metric %v, got %v", expectedMetric, cfg.Metric())
 	}
 }
+
+// Contract: configs are stored with enum names and the whole model, configs stored with the old numbers
+// or only a model name still load.
+func TestIndexConfig_JSON(t *testing.T) {
+	cfg, _ := NewIndexConfig(types.DiskANNIndex, types.Testmodel, types.Image, types.Dot, 8)
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"index_type":"diskann"`) || !strings.Contains(string(data), `"metric":"dot"`) {
+		t.Errorf("expected enum names, got %s", data)
+	}
+	var back IndexConfig
+	if err := json.Unmarshal(data, &back); err != nil || back != cfg {
+		t.Errorf("round trip mismatch: %+v (err %v)", back, err)
+	}
+
+	legacy := `{"index_type":0,"model_type":1,"data_type":0,"metric":0,"dimension":2}`
+	if err := json.Unmarshal([]byte(legacy), &back); err != nil {
+		t.Fatalf("legacy config failed to load: %v", err)
+	}
+	if back.IndexType() != types.LinearIndex || back.DataType() != types.Text || back.Metric() != types.Cosine || back.ModelType() != types.ExternalModel {
+		t.Errorf("legacy config decoded as %v %v %v %v", back.IndexType(), back.ModelType(), back.DataType(), back.Metric())
+	}
+	// stored with the model name only, by a version that looked the model up on every load
+	named := `{"index_type":"linear","model_type":"unregistered-model","data_type":"text","metric":"cosine","dimension":2}`
+	if err := json.Unmarshal([]byte(named), &back); err != nil || back.Model() != (types.ModelInfo{Name: "unregistered-model"}) {
+		t.Errorf("config naming an unregistered model decoded as %+v (err %v)", back, err)
+	}
+	mismatch := `{"index_type":"linear","model_type":"a","model":{"name":"b"},"data_type":"text","metric":"cosine","dimension":2}`
+	if err := json.Unmarshal([]byte(mismatch), &back); err == nil {
+		t.Error("expected a model that doesn't match the model type to fail")
+	}
+	wrongDim := `{"index_type":"linear","model_type":"a","model":{"name":"a","dimension":4},"data_type":"text","metric":"cosine","dimension":2}`
+	if err := json.Unmarshal([]byte(wrongDim), &back); err == nil {
+		t.Error("expected a dimension the stored model doesn't produce to fail")
+	}
+	if err := json.Unmarshal([]byte(`{"index_type":"btree","model_type":1,"data_type":"text","metric":"cosine","dimension":2}`), &back); err == nil {
+		t.Error("expected an unknown index type to fail")
+	}
+}
+
+// Contract: CheckEmbedding accepts exactly the data type, metric and dimension of the config.
+func TestIndexConfig_CheckEmbedding(t *testing.T) {
+	cfg, _ := NewIndexConfig(types.LinearIndex, types.Testmodel, types.Text, types.Cosine, 4)
+	tests := []struct {
+		name     string
+		dataType types.DataType
+		metric   types.SimilarityMetric
+		dim      int
+		errorMsg string
+	}{
+		{"match", types.Text, types.Cosine, 4, ""},
+		{"data type", types.Image, types.Cosine, 4, "data type image doesn't match index data type text"},
+		{"metric", types.Text, types.Dot, 4, "similarity metric dot doesn't match index metric cosine"},
+		{"dimension", types.Text, types.Cosine, 3, "dimension 3 doesn't match index dimension 4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := cfg.CheckEmbedding(tt.dataType, tt.metric, tt.dim)
+			if (err == nil) != (tt.errorMsg == "") || (err != nil && err.Error() != tt.errorMsg) {
+				t.Errorf("expected %q, got %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+// Contract: NewIndexConfig only accepts models from the registry, with the properties they were registered with.
+func TestNewIndexConfig_ModelRegistry(t *testing.T) {
+	err := types.RegisterModel(types.ModelInfo{Name: "config-test-model", Version: "1", Dimension: 16, DataType: types.Text, Metric: types.Dot})
+	if err != nil {
+		t.Fatalf("failed to register model: %v", err)
+	}
+	if _, err := NewIndexConfig(types.LinearIndex, "config-test-model", types.Text, types.Dot, 16); err != nil {
+		t.Errorf("registered model rejected: %v", err)
+	}
+	if _, err := NewIndexConfig(types.LinearIndex, "config-test-model", types.Text, types.Cosine, 16); err == nil {
+		t.Error("expected a metric the model wasn't trained for to fail")
+	}
+	if _, err := NewIndexConfig(types.LinearIndex, "config-test-model", types.Text, types.Dot, 32); err == nil {
+		t.Error("expected a dimension the model doesn't produce to fail")
+	}
+}
+
+// Post-condition: Validate accepts every config NewIndexConfig returns and rejects the zero config.
+func TestIndexConfig_Validate(t *testing.T) {
+	cfg, _ := NewIndexConfig(types.LinearIndex, types.Testmodel, types.Text, types.Cosine, 4)
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("valid config rejected: %v", err)
+	}
+	if err := (IndexConfig{}).Validate(); err == nil {
+		t.Error("zero config accepted")
+	}
+}
@@ -0,0 +1,147 @@
+package index
+
+import (
+	"VectorDatabase/internal/store"
+	v "VectorDatabase/internal/vector"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// DiskLinearIndex is a linear index whose vectors live in a memory mapped store.VectorFile instead of
+// the heap, so collections larger than RAM can be searched. A search streams over the file and only
+// keeps the current top-k in memory. It stores vectors only, no metadata
+type DiskLinearIndex struct {
+	// serializes the exists check and the write of Add/Delete, the vector file guards itself
+	mu     sync.Mutex
+	vecs   *store.VectorFile
+	config IndexConfig
+}
+
+// OpenDiskLinearIndex opens (or creates) the vector file name in dir for cfg
+func OpenDiskLinearIndex(dir, name string, cfg IndexConfig) (*DiskLinearIndex, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to initialize disk linear index: %w", err)
+	}
+	vecs, err := store.OpenVectorFile(dir, name, cfg.Dimension())
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize disk linear index: %w", err)
+	}
+	return &DiskLinearIndex{vecs: vecs, config: cfg}, nil
+}
+
+func (di *DiskLinearIndex) Dimension() int {
+	return di.config.Dimension()
+}
+
+// Returns true if vector already exist else error
+func (di *DiskLinearIndex) Add(id string, vec *v.Vector) (bool, error) {
+	if err := store.CheckID(id); err != nil {
+		return false, err
+	}
+	if vec == nil {
+		return false, errors.New("empty vector")
+	}
+	if di.config.Dimension() != vec.Dimensions() {
+		return false, errors.New("dimension mismatch")
+	}
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	if _, ok := di.vecs.Get(id); ok {
+		return true, nil
+	}
+	if err := di.vecs.Append(id, vec.Values()); err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+func (di *DiskLinearIndex) Delete(id string) error {
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	if _, ok := di.vecs.Get(id); !ok {
+		return errors.New("vector doesn't exist in index")
+	}
+	return di.vecs.Delete(id)
+}
+
+func (di *DiskLinearIndex) Get(id string) (*v.Vector, bool) {
+	values, ok := di.vecs.Get(id)
+	if !ok {
+		return nil, false
+	}
+	vec, err := v.NewVector(values, di.config.Dimension())
+	if err != nil {
+		return nil, false
+	}
+	return vec, true
+}
+
+func (di *DiskLinearIndex) Search(query *v.Vector, k int) ([]SearchResult, error) {
+	result, _, err := di.SearchWithOptions(query, k, SearchOptions{})
+	return result, err
+}
+
+// SearchWithOptions scans the mapped file once, filters see nil metadata
+func (di *DiskLinearIndex) SearchWithOptions(query *v.Vector, k int, opts SearchOptions) ([]SearchResult, *SearchStats, error) {
+	if di.vecs.Len() == 0 {
+		return nil, opts.newStats(), nil
+	}
+	if query == nil {
+		return nil, nil, errors.New("empty query input")
+	}
+	if di.config.Dimension() != query.Dimensions() {
+		return nil, nil, errors.New("index and query dimension mismatched")
+	}
+	if k <= 0 {
+		return nil, nil, errors.New("invalid input for number of results")
+	}
+	stats := opts.newStats()
+	phase := stats.startPhase("scan")
+	best := newTopK(k)
+	queryVals := query.Values()
+	di.vecs.Range(func(id string, values []float32) bool {
+		stats.visit()
+		if opts.Filter != nil {
+			passed := opts.Filter(id, nil)
+			stats.filtered(passed)
+			if !passed {
+				return true
+			}
+		}
+		// stored vectors were normalized at construction, cosine is the dot product
+		best.offer(SearchResult{vecId: id, score: v.DotProduct(queryVals, values)})
+		stats.distance()
+		return true
+	})
+	phase.end()
+	return best.sorted(), stats, nil
+}
+
+// Range visits every stored vector, building a Vector per entry
+func (di *DiskLinearIndex) Range(fn func(id string, vec *v.Vector) bool) {
+	di.vecs.Range(func(id string, values []float32) bool {
+		vec, err := v.NewVector(values, di.config.Dimension())
+		if err != nil {
+			return true
+		}
+		return fn(id, vec)
+	})
+}
+
+func (di *DiskLinearIndex) Size() int {
+	return di.vecs.Len()
+}
+
+// Sync flushes the vector file to stable storage
+func (di *DiskLinearIndex) Sync() error {
+	return di.vecs.Sync()
+}
+
+func (di *DiskLinearIndex) Close() error {
+	return di.vecs.Close()
+}
+
+var _ VectorIndex = (*DiskLinearIndex)(nil)
+var _ OptionSearcher = (*DiskLinearIndex)(nil)
+var _ RangeIndex = (*DiskLinearIndex)(nil)
@@ -0,0 +1,69 @@
+package index
+
+import (
+	"VectorDatabase/internal/types"
+	"fmt"
+	"testing"
+)
+
+func setupDiskLinear(t *testing.T, dir string) *DiskLinearIndex {
+	cfg, _ := NewIndexConfig(types.LinearIndex, types.Testmodel, types.Text, types.Cosine, 2)
+	di, err := OpenDiskLinearIndex(dir, "vectors", cfg)
+	if err != nil {
+		t.Fatalf("failed to open disk linear index: %v", err)
+	}
+	return di
+}
+
+// Invariant: searching the mapped file matches the in-memory linear index.
+func TestDiskLinearIndex_MatchesLinear(t *testing.T) {
+	di := setupDiskLinear(t, t.TempDir())
+	defer di.Close()
+	li := setupIndex(t, 2)
+	for i := 0; i < 50; i++ {
+		id, vec := fmt.Sprintf("vec-%d", i), vec2(float32(50-i), float32(i))
+		di.Add(id, vec)
+		li.Add(id, vec)
+	}
+	di.Delete("vec-0")
+	li.Delete("vec-0")
+
+	query := vec2(1, 0.3)
+	want, _ := li.Search(query, 7)
+	got, err := di.Search(query, 7)
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d results, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i].ID() != want[i].ID() {
+			t.Errorf("position %d: expected %s, got %s", i, want[i].ID(), got[i].ID())
+		}
+	}
+	if exists, _ := di.Add("vec-1", vec2(1, 1)); !exists {
+		t.Error("expected exists=true for duplicate id")
+	}
+	if err := di.Delete("vec-0"); err == nil {
+		t.Error("expected error deleting a missing vector")
+	}
+}
+
+// Post-condition: the index reopens from disk with the same content.
+func TestDiskLinearIndex_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	di := setupDiskLinear(t, dir)
+	di.Add("vec-1", vec2(3, 4))
+	di.Close()
+
+	di = setupDiskLinear(t, dir)
+	defer di.Close()
+	vec, ok := di.Get("vec-1")
+	if !ok || di.Size() != 1 {
+		t.Fatal("vector lost after reopen")
+	}
+	if vals := vec.Values(); vals[0] != 0.6 || vals[1] != 0.8 {
+		t.Errorf("expected normalized values [0.6 0.8], got %v", vals)
+	}
+}
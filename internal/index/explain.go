@@ -0,0 +1,163 @@
+package index
+
+import (
+	v "VectorDatabase/internal/vector"
+	"errors"
+	"time"
+)
+
+// Filter restricts a search to the vectors it accepts, md is nil for vectors without metadata
+// and must not be modified
+type Filter func(id string, md Metadata) bool
+
+// SearchOptions tune a single search
+type SearchOptions struct {
+	Filter Filter
+	// Explain collects SearchStats for the query
+	Explain bool
+}
+
+// OptionSearcher is implemented by indexes that support filtered and explained searches,
+// stats are nil unless opts.Explain is set
+type OptionSearcher interface {
+	SearchWithOptions(query *v.Vector, k int, opts SearchOptions) ([]SearchResult, *SearchStats, error)
+}
+
+// PhaseTiming is the wall time spent in one phase of a search
+type PhaseTiming struct {
+	Name     string
+	Duration time.Duration
+}
+
+// ScoreExplanation compares the score the index returned with the exact similarity
+type ScoreExplanation struct {
+	ID          string
+	Approximate float64
+	Exact       float64
+}
+
+// SearchStats profiles one query, counters an index type doesn't have stay zero
+// (a linear scan visits nodes but probes no lists)
+type SearchStats struct {
+	DistanceComputations int
+	NodesVisited         int
+	ListsProbed          int
+	FilterEvaluated      int
+	FilterPassed         int
+	Phases               []PhaseTiming
+	Scores               []ScoreExplanation
+}
+
+// FilterSelectivity is the fraction of evaluated vectors accepted by the filter, 1 without a filter
+func (s *SearchStats) FilterSelectivity() float64 {
+	if s.FilterEvaluated == 0 {
+		return 1
+	}
+	return float64(s.FilterPassed) / float64(s.FilterEvaluated)
+}
+
+// SearchExplain runs a search with opts and, when opts.Explain is set, adds the exact score of every
+// returned result next to the score the index produced.
+// Indexes without OptionSearcher support are searched plainly and can't take a filter
+func SearchExplain(idx VectorIndex, query *v.Vector, k int, opts SearchOptions) ([]SearchResult, *SearchStats, error) {
+	if idx == nil {
+		return nil, nil, errors.New("nil index")
+	}
+	var (
+		result []SearchResult
+		stats  *SearchStats
+		err    error
+	)
+	if searcher, ok := idx.(OptionSearcher); ok {
+		result, stats, err = searcher.SearchWithOptions(query, k, opts)
+	} else {
+		if opts.Filter != nil {
+			return nil, nil, errors.New("index doesn't support filtered search")
+		}
+		stats = opts.newStats()
+		phase := stats.startPhase("search")
+		result, err = idx.Search(query, k)
+		phase.end()
+	}
+	if err != nil || stats == nil {
+		return result, stats, err
+	}
+	phase := stats.startPhase("exact rescoring")
+	stats.Scores = make([]ScoreExplanation, 0, len(result))
+	for _, r := range result {
+		vec, ok := idx.Get(r.vecId)
+		if !ok {
+			continue
+		}
+		exact, err := query.Similarity(vec)
+		if err != nil {
+			return nil, nil, err
+		}
+		stats.Scores = append(stats.Scores, ScoreExplanation{ID: r.vecId, Approximate: r.score, Exact: exact})
+	}
+	phase.end()
+	return result, stats, nil
+}
+
+// the helpers below are no-ops on nil stats so index code can record unconditionally
+
+func (opts SearchOptions) newStats() *SearchStats {
+	if !opts.Explain {
+		return nil
+	}
+	return &SearchStats{}
+}
+
+func (s *SearchStats) visit() {
+	if s != nil {
+		s.NodesVisited++
+	}
+}
+
+func (s *SearchStats) distance() {
+	if s != nil {
+		s.DistanceComputations++
+	}
+}
+
+func (s *SearchStats) filtered(passed bool) {
+	if s == nil {
+		return
+	}
+	s.FilterEvaluated++
+	if passed {
+		s.FilterPassed++
+	}
+}
+
+// merge adds the work recorded by a sub search (e.g. one segment) to s
+func (s *SearchStats) merge(other *SearchStats) {
+	if s == nil || other == nil {
+		return
+	}
+	s.DistanceComputations += other.DistanceComputations
+	s.NodesVisited += other.NodesVisited
+	s.ListsProbed += other.ListsProbed
+	s.FilterEvaluated += other.FilterEvaluated
+	s.FilterPassed += other.FilterPassed
+	s.Phases = append(s.Phases, other.Phases...)
+}
+
+type phaseTimer struct {
+	stats *SearchStats
+	name  string
+	start time.Time
+}
+
+func (s *SearchStats) startPhase(name string) phaseTimer {
+	if s == nil {
+		return phaseTimer{}
+	}
+	return phaseTimer{stats: s, name: name, start: time.Now()}
+}
+
+func (p phaseTimer) end() {
+	if p.stats != nil {
+		p.stats.Phases = append(p.stats.Phases, PhaseTiming{Name: p.name, Duration: time.Since(p.start)})
+	}
+}
@@ -0,0 +1,80 @@
+package index
+
+import (
+	v "VectorDatabase/internal/vector"
+	"fmt"
+	"testing"
+)
+
+// Post-condition: explain reports scan counters, filter selectivity, phases and exact scores.
+func TestSearchExplain_LinearIndex(t *testing.T) {
+	idx := setupIndex(t, 2)
+	for i := 0; i < 10; i++ {
+		vec, _ := v.NewVector([]float32{1, float32(i)}, 2)
+		idx.AddWithMetadata(fmt.Sprintf("vec-%d", i), vec, Metadata{"even": i%2 == 0})
+	}
+	query, _ := v.NewVector([]float32{1, 0}, 2)
+	even := func(_ string, md Metadata) bool { return md["even"] == true }
+
+	res, stats, err := SearchExplain(idx, query, 3, SearchOptions{Filter: even, Explain: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res) != 3 || res[0].ID() != "vec-0" || res[1].ID() != "vec-2" {
+		t.Fatalf("filter not applied: %+v", res)
+	}
+	if stats.NodesVisited != 10 || stats.FilterEvaluated != 10 || stats.FilterPassed != 5 {
+		t.Errorf("unexpected counters: %+v", stats)
+	}
+	if stats.DistanceComputations != 5 {
+		t.Errorf("expected 5 distance computations, got %d", stats.DistanceComputations)
+	}
+	if stats.FilterSelectivity() != 0.5 {
+		t.Errorf("expected selectivity 0.5, got %f", stats.FilterSelectivity())
+	}
+	if len(stats.Phases) != 3 {
+		t.Errorf("expected scan, sort and exact rescoring phases, got %+v", stats.Phases)
+	}
+	if len(stats.Scores) != 3 {
+		t.Fatalf("expected 3 score explanations, got %d", len(stats.Scores))
+	}
+	for _, s := range stats.Scores {
+		// linear search is exact
+		if s.Approximate != s.Exact {
+			t.Errorf("%s: approximate %f != exact %f", s.ID, s.Approximate, s.Exact)
+		}
+	}
+}
+
+// Contract: stats are only collected when asked for.
+func TestSearchExplain_Disabled(t *testing.T) {
+	idx := setupIndex(t, 2)
+	vec, _ := v.NewVector([]float32{1, 0}, 2)
+	idx.Add("vec-1", vec)
+	res, stats, err := SearchExplain(idx, vec, 1, SearchOptions{})
+	if err != nil || len(res) != 1 {
+		t.Fatalf("unexpected result %+v, err %v", res, err)
+	}
+	if stats != nil {
+		t.Errorf("expected nil stats without explain, got %+v", stats)
+	}
+}
+
+// Contract: plain indexes can be explained but not filtered.
+func TestSearchExplain_PlainIndex(t *testing.T) {
+	idx := setupIndex(t, 2)
+	vec, _ := v.NewVector([]float32{1, 0}, 2)
+	idx.Add("vec-1", vec)
+	approx := stubIndex{idx, []SearchResult{{vecId: "vec-1", score: 0.5}}}
+
+	_, stats, err := SearchExplain(approx, vec, 1, SearchOptions{Explain: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stats.Scores) != 1 || stats.Scores[0].Approximate != 0.5 || stats.Scores[0].Exact < 0.99 {
+		t.Errorf("unexpected score explanation %+v", stats.Scores)
+	}
+	if _, _, err := SearchExplain(approx, vec, 1, SearchOptions{Filter: func(string, Metadata) bool { return true }}); err == nil {
+		t.Error("expected error filtering a plain index")
+	}
+}
@@ -2,25 +2,66 @@ package index
 
 import (
 	"VectorDatabase/internal/types"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
 )
 
 type IndexFactory interface {
 	CreateIndex(cfg IndexConfig) (VectorIndex, error)
 }
 
-// empty struct to implement IndexFactory and bind Registery struct and interface
+// DirIndexFactory is an IndexFactory that can put a disk resident index in a given directory,
+// SegmentedIndex uses it to give every sealed segment a directory of its own
+type DirIndexFactory interface {
+	IndexFactory
+	CreateIndexIn(dir string, cfg IndexConfig) (VectorIndex, error)
+}
+
+// struct to implement IndexFactory and bind Registery struct and interface,
+// DataDir is where disk resident indexes (DiskANN) put their files
 type DefaultIndexFactory struct {
+	DataDir string
 }
 
+// CreateIndex builds an index for cfg, a DiskANN index lives in a directory of DataDir named after cfg,
+// so the same config reopens it
 func (d *DefaultIndexFactory) CreateIndex(cfg IndexConfig) (VectorIndex, error) {
+	if cfg.IndexType() != types.DiskANNIndex {
+		return d.CreateIndexIn("", cfg)
+	}
+	if d.DataDir == "" {
+		return nil, errors.New("diskann index needs a data directory")
+	}
+	raw, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(raw)
+	return d.CreateIndexIn(filepath.Join(d.DataDir, "diskann-"+hex.EncodeToString(sum[:8])), cfg)
+}
+
+// CreateIndexIn builds an index for cfg, a disk resident one keeps its files in dir and reopens what it
+// finds there. In-memory indexes ignore dir
+func (d *DefaultIndexFactory) CreateIndexIn(dir string, cfg IndexConfig) (VectorIndex, error) {
 	switch cfg.IndexType() {
 	case types.LinearIndex:
 		return NewLinearIndex(cfg)
+	case types.DiskANNIndex:
+		if dir == "" {
+			return nil, errors.New("diskann index needs a data directory")
+		}
+		return OpenVamanaIndex(dir, cfg, VamanaOptions{})
 	// case IndexHNSW :
 	// 	return NewHNSWIndex()
 	// case IndexIVF :
 	// 	return NewIVFIndex()
 	default:
-		panic("unsupported index type")
+		return nil, fmt.Errorf("unsupported index type %s", cfg.IndexType())
 	}
 }
+
+var _ DirIndexFactory = (*DefaultIndexFactory)(nil)
@@ -0,0 +1,57 @@
+package index
+
+import (
+	"VectorDatabase/internal/types"
+	"io"
+	"testing"
+)
+
+// Contract: every valid config gets an index or an error, never a panic; a DiskANN index is created in
+// a directory named after its config, so the same config reopens it.
+func TestDefaultIndexFactory(t *testing.T) {
+	tests := []struct {
+		name    string
+		factory *DefaultIndexFactory
+		typ     types.IndexType
+		wantErr bool
+	}{
+		{"linear", &DefaultIndexFactory{}, types.LinearIndex, false},
+		{"diskann", &DefaultIndexFactory{DataDir: t.TempDir()}, types.DiskANNIndex, false},
+		{"diskann without data dir", &DefaultIndexFactory{}, types.DiskANNIndex, true},
+		{"hnsw", &DefaultIndexFactory{}, types.HNSWIndex, true},
+		{"ivf", &DefaultIndexFactory{}, types.IVFIndex, true},
+		{"pq", &DefaultIndexFactory{}, types.PQIndex, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := NewIndexConfig(tt.typ, types.Testmodel, types.Text, types.Cosine, 2)
+			if err != nil {
+				t.Fatalf("NewIndexConfig failed: %v", err)
+			}
+			idx, err := tt.factory.CreateIndex(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if c, ok := idx.(io.Closer); ok {
+				c.Close()
+			}
+		})
+	}
+
+	factory := &DefaultIndexFactory{DataDir: t.TempDir()}
+	cfg, _ := NewIndexConfig(types.DiskANNIndex, types.Testmodel, types.Text, types.Cosine, 2)
+	idx, err := factory.CreateIndex(cfg)
+	if err != nil {
+		t.Fatalf("CreateIndex failed: %v", err)
+	}
+	idx.Add("a", vec2(1, 0))
+	idx.(io.Closer).Close()
+	idx, err = factory.CreateIndex(cfg)
+	if err != nil {
+		t.Fatalf("CreateIndex failed: %v", err)
+	}
+	defer idx.(io.Closer).Close()
+	if _, ok := idx.Get("a"); !ok {
+		t.Error("expected the same config to reopen the index")
+	}
+}
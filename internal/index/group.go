@@ -0,0 +1,85 @@
+package index
+
+import (
+	v "VectorDatabase/internal/vector"
+	"errors"
+	"fmt"
+)
+
+// GroupRequest asks for the best Groups values of a metadata field, each with up to GroupSize hits,
+// so many chunks of one document can't take every slot of a plain top-k search
+type GroupRequest struct {
+	Field     string
+	Groups    int
+	GroupSize int
+}
+
+// Group holds the hits sharing one value of the grouping field, ordered like Search results
+type Group struct {
+	Key  string
+	Hits []SearchResult
+}
+
+// GroupSearch returns groups ordered by their best hit, vectors without the field are skipped.
+// The index is searched with a growing k until every selected group is full or results run out
+func GroupSearch(idx MetadataIndex, query *v.Vector, req GroupRequest) ([]Group, error) {
+	if idx == nil {
+		return nil, errors.New("nil index")
+	}
+	if req.Field == "" {
+		return nil, errors.New("group field required")
+	}
+	if req.Groups <= 0 || req.GroupSize <= 0 {
+		return nil, errors.New("invalid group limits")
+	}
+	k := req.Groups * req.GroupSize
+	for {
+		found, err := idx.Search(query, k)
+		if err != nil {
+			return nil, err
+		}
+		groups, full := groupResults(idx, found, req)
+		if full || len(found) < k {
+			return groups, nil
+		}
+		k *= 2
+	}
+}
+
+// groups results in order, full reports that all req.Groups groups reached req.GroupSize hits
+func groupResults(md MetadataSource, results []SearchResult, req GroupRequest) ([]Group, bool) {
+	groups := make([]Group, 0, req.Groups)
+	position := make(map[string]int, req.Groups)
+	filled := 0
+	for _, r := range results {
+		meta, ok := md.Metadata(r.vecId)
+		if !ok {
+			continue
+		}
+		val, ok := meta[req.Field]
+		if !ok || val == nil {
+			continue
+		}
+		key := fmt.Sprint(val)
+		i, ok := position[key]
+		if !ok {
+			if len(groups) == req.Groups {
+				continue
+			}
+			i = len(groups)
+			position[key] = i
+			groups = append(groups, Group{Key: key})
+		}
+		if len(groups[i].Hits) == req.GroupSize {
+			continue
+		}
+		groups[i].Hits = append(groups[i].Hits, r)
+		if len(groups[i].Hits) == req.GroupSize {
+			filled++
+			if filled == req.Groups {
+				return groups, true
+			}
+		}
+	}
+	return groups, false
+}
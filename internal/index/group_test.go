@@ -0,0 +1,76 @@
+package index
+
+import (
+	v "VectorDatabase/internal/vector"
+	"fmt"
+	"testing"
+)
+
+// Post-condition: one long document can't take every slot, groups are ordered by their best hit.
+func TestGroupSearch_LimitsPerGroup(t *testing.T) {
+	idx := setupIndex(t, 2)
+	// doc-a has many chunks all close to the query, doc-b and doc-c sit further away
+	for i := 0; i < 8; i++ {
+		vec, _ := v.NewVector([]float32{10, float32(i) * 0.1}, 2)
+		idx.AddWithMetadata(fmt.Sprintf("a-%d", i), vec, Metadata{"document_id": "doc-a"})
+	}
+	b, _ := v.NewVector([]float32{1, 1}, 2)
+	idx.AddWithMetadata("b-0", b, Metadata{"document_id": "doc-b"})
+	c, _ := v.NewVector([]float32{0, 1}, 2)
+	idx.AddWithMetadata("c-0", c, Metadata{"document_id": "doc-c"})
+	untagged, _ := v.NewVector([]float32{1, 0}, 2)
+	idx.Add("untagged", untagged)
+
+	query, _ := v.NewVector([]float32{1, 0}, 2)
+	groups, err := GroupSearch(idx, query, GroupRequest{Field: "document_id", Groups: 2, GroupSize: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %d", len(groups))
+	}
+	if groups[0].Key != "doc-a" || len(groups[0].Hits) != 3 {
+		t.Errorf("expected doc-a with 3 hits first, got %s with %d", groups[0].Key, len(groups[0].Hits))
+	}
+	if groups[1].Key != "doc-b" || len(groups[1].Hits) != 1 {
+		t.Errorf("expected doc-b with 1 hit second, got %s with %d", groups[1].Key, len(groups[1].Hits))
+	}
+	if groups[0].Hits[0].ID() != "a-0" {
+		t.Errorf("expected best chunk a-0 first, got %s", groups[0].Hits[0].ID())
+	}
+}
+
+// Invariant: metadata returned by the index is a copy and is removed with the vector.
+func TestLinearIndex_Metadata(t *testing.T) {
+	idx := setupIndex(t, 2)
+	vec, _ := v.NewVector([]float32{1, 0}, 2)
+	idx.AddWithMetadata("vec-1", vec, Metadata{"document_id": "doc-a"})
+
+	md, ok := idx.Metadata("vec-1")
+	if !ok || md["document_id"] != "doc-a" {
+		t.Fatalf("metadata not stored, got %v", md)
+	}
+	md["document_id"] = "mutated"
+	if again, _ := idx.Metadata("vec-1"); again["document_id"] != "doc-a" {
+		t.Error("caller mutation leaked into the index")
+	}
+	idx.Delete("vec-1")
+	if _, ok := idx.Metadata("vec-1"); ok {
+		t.Error("metadata still exists after deletion")
+	}
+}
+
+// Contract: GroupSearch must reject requests without a field or limits.
+func TestGroupSearch_Contracts(t *testing.T) {
+	idx := setupIndex(t, 2)
+	query, _ := v.NewVector([]float32{1, 0}, 2)
+	for _, req := range []GroupRequest{
+		{Field: "", Groups: 1, GroupSize: 1},
+		{Field: "document_id", Groups: 0, GroupSize: 1},
+		{Field: "document_id", Groups: 1, GroupSize: 0},
+	} {
+		if _, err := GroupSearch(idx, query, req); err == nil {
+			t.Errorf("expected error for %+v", req)
+		}
+	}
+}
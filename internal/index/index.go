@@ -11,3 +11,9 @@ type VectorIndex interface {
 	Search(query *v.Vector, k int) ([]SearchResult, error)
 	Size() int
 }
+
+// RangeIndex is implemented by indexes that can enumerate their vectors,
+// fn returns false to stop early and must not modify the index
+type RangeIndex interface {
+	Range(fn func(id string, vec *v.Vector) bool)
+}
@@ -2,7 +2,6 @@ package index
 
 import (
 	v "VectorDatabase/internal/vector"
-	"cmp"
 	"errors"
 	"fmt"
 	"slices"
@@ -13,9 +12,10 @@ import (
 // after first add index state each index gets its own fixed dimension,
 // IndexConfig is now Imutable and index is schema driven not data driven i.e first IndexConfig structure is defined
 type LinearIndex struct {
-	mu      sync.RWMutex
-	vectors map[string]*v.Vector
-	config  IndexConfig
+	mu       sync.RWMutex
+	vectors  map[string]*v.Vector
+	metadata map[string]Metadata
+	config   IndexConfig
 }
 
 // Index must know its invariants at birth, IndexConfig enforces invariants
@@ -24,9 +24,10 @@ func NewLinearIndex(cfg IndexConfig) (*LinearIndex, error) {
 		return nil, fmt.Errorf("failed to initialize linear index: %w", err)
 	}
 	return &LinearIndex{
-		mu:      sync.RWMutex{},
-		vectors: make(map[string]*v.Vector),
-		config:  cfg,
+		mu:       sync.RWMutex{},
+		vectors:  make(map[string]*v.Vector),
+		metadata: make(map[string]Metadata),
+		config:   cfg,
 	}, nil
 }
 func (li *LinearIndex) Dimension() int {
@@ -37,6 +38,11 @@ func (li *LinearIndex) Dimension() int {
 
 // Returns true if vector already exist else error
 func (li *LinearIndex) Add(id string, vec *v.Vector) (bool, error) {
+	return li.AddWithMetadata(id, vec, nil)
+}
+
+// AddWithMetadata stores vec with an optional metadata payload, an existing id keeps its old payload
+func (li *LinearIndex) AddWithMetadata(id string, vec *v.Vector, md Metadata) (bool, error) {
 	li.mu.Lock()
 	defer li.mu.Unlock()
 	if id == "" {
@@ -53,6 +59,9 @@ func (li *LinearIndex) Add(id string, vec *v.Vector) (bool, error) {
 		return true, nil
 	}
 	li.vectors[id] = vec
+	if len(md) > 0 {
+		li.metadata[id] = md.clone()
+	}
 	return false, nil
 }
 func (li *LinearIndex) Delete(id string) error {
@@ -63,6 +72,7 @@ func (li *LinearIndex) Delete(id string) error {
 		return errors.New("vector doesn't exist in index")
 	}
 	delete(li.vectors, id)
+	delete(li.metadata, id)
 	return nil
 
 }
@@ -72,17 +82,32 @@ func (li *LinearIndex) Get(id string) (*v.Vector, bool) {
 	vec, ok := li.vectors[id]
 	return vec, ok
 }
+func (li *LinearIndex) Metadata(id string) (Metadata, bool) {
+	li.mu.RLock()
+	defer li.mu.RUnlock()
+	if _, ok := li.vectors[id]; !ok {
+		return nil, false
+	}
+	return li.metadata[id].clone(), true
+}
 func (li *LinearIndex) Search(query *v.Vector, k int) ([]SearchResult, error) {
+	result, _, err := li.SearchWithOptions(query, k, SearchOptions{})
+	return result, err
+}
+
+// SearchWithOptions is Search with an optional filter, stats are only collected with opts.Explain
+func (li *LinearIndex) SearchWithOptions(query *v.Vector, k int, opts SearchOptions) ([]SearchResult, *SearchStats, error) {
 	li.mu.RLock()
 	defer li.mu.RUnlock()
-	if li.Size() == 0 {
-		return nil, nil
+	// size and dimension read directly, the read lock is already held
+	if len(li.vectors) == 0 {
+		return nil, opts.newStats(), nil
 	}
 	if query == nil {
-		return nil, errors.New("empty query input")
+		return nil, nil, errors.New("empty query input")
 	}
-	if li.Dimension() != query.Dimensions() {
-		return nil, errors.New("index and query dimension mismatched")
+	if li.config.Dimension() != query.Dimensions() {
+		return nil, nil, errors.New("index and query dimension mismatched")
 	}
 	// if li.config.DataType != query.DataType() {
 	// 	return nil, errors.New("index and vector data type mismatch")
@@ -91,28 +116,49 @@ func (li *LinearIndex) Search(query *v.Vector, k int) ([]SearchResult, error) {
 	// 	return nil, errors.New("index and query similarity metric mismatch")
 	// }
 	if k <= 0 {
-		return nil, errors.New("invalid input for number of results")
+		return nil, nil, errors.New("invalid input for number of results")
 	}
+	stats := opts.newStats()
+	phase := stats.startPhase("scan")
 	// for k >= index size might need li.Size() memory capacity
-	result := make([]SearchResult, 0, li.Size())
+	result := make([]SearchResult, 0, len(li.vectors))
 	for key, val := range li.vectors {
+		stats.visit()
+		if opts.Filter != nil {
+			passed := opts.Filter(key, li.metadata[key])
+			stats.filtered(passed)
+			if !passed {
+				continue
+			}
+		}
 		simScore, err := query.Similarity(val)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
+		stats.distance()
 		result = append(result, SearchResult{
 			vecId: key,
 			score: simScore,
 		})
 	}
-	//sort descending similarity score
-	slices.SortFunc(result, func(a, b SearchResult) int {
-		return cmp.Compare(b.score, a.score)
-	})
-	if k > li.Size() {
-		return result, nil
+	phase.end()
+	phase = stats.startPhase("sort")
+	//sort descending similarity score, ties broken by id
+	slices.SortFunc(result, compareResults)
+	phase.end()
+	if k < len(result) {
+		result = result[:k]
+	}
+	return result, stats, nil
+}
+func (li *LinearIndex) Range(fn func(id string, vec *v.Vector) bool) {
+	li.mu.RLock()
+	defer li.mu.RUnlock()
+	for id, vec := range li.vectors {
+		if !fn(id, vec) {
+			return
+		}
 	}
-	return result[:k], nil
 }
 func (li *LinearIndex) Size() int {
 	li.mu.RLock()
@@ -121,3 +167,6 @@ func (li *LinearIndex) Size() int {
 }
 
 var _ VectorIndex = (*LinearIndex)(nil)
+var _ MetadataIndex = (*LinearIndex)(nil)
+var _ OptionSearcher = (*LinearIndex)(nil)
+var _ RangeIndex = (*LinearIndex)(nil)
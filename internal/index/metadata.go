@@ -0,0 +1,62 @@
+package index
+
+import (
+	v "VectorDatabase/internal/vector"
+	"errors"
+	"fmt"
+	"maps"
+)
+
+// Metadata is the optional payload stored next to a vector (field name -> value),
+// values are plain JSON-like data: string, float64, bool, nil
+type Metadata map[string]any
+
+// MetadataSource is implemented by indexes that keep metadata for their vectors
+type MetadataSource interface {
+	Metadata(id string) (Metadata, bool)
+}
+
+// MetadataIndex is a VectorIndex that can store and return metadata per vector
+type MetadataIndex interface {
+	VectorIndex
+	MetadataSource
+	AddWithMetadata(id string, vec *v.Vector, md Metadata) (bool, error)
+}
+
+// copy so callers can't mutate what the index holds
+func (md Metadata) clone() Metadata {
+	if md == nil {
+		return nil
+	}
+	return maps.Clone(md)
+}
+
+// DeleteWhere deletes every vector of idx filter accepts and returns how many it deleted,
+// idx must be a RangeIndex and a MetadataSource. It scans the whole index
+func DeleteWhere(idx VectorIndex, filter Filter) (int, error) {
+	ranger, ok := idx.(RangeIndex)
+	source, hasMetadata := idx.(MetadataSource)
+	if !ok || !hasMetadata {
+		return 0, errors.New("index can't enumerate vectors with their metadata")
+	}
+	// metadata is read outside Range, fn can't call back into the index holding its lock
+	var ids []string
+	ranger.Range(func(id string, _ *v.Vector) bool {
+		ids = append(ids, id)
+		return true
+	})
+	deleted := 0
+	var errs []error
+	for _, id := range ids {
+		md, ok := source.Metadata(id)
+		if !ok || !filter(id, md) {
+			continue
+		}
+		if err := idx.Delete(id); err != nil {
+			errs = append(errs, fmt.Errorf("delete %s: %w", id, err))
+			continue
+		}
+		deleted++
+	}
+	return deleted, errors.Join(errs...)
+}
@@ -0,0 +1,33 @@
+package index
+
+import (
+	"VectorDatabase/internal/types"
+	v "VectorDatabase/internal/vector"
+	"testing"
+)
+
+// Contract: DeleteWhere deletes exactly the vectors the filter accepts and reports how many, and fails on
+// an index that can't enumerate its vectors with metadata.
+func TestDeleteWhere(t *testing.T) {
+	cfg, _ := NewIndexConfig(types.LinearIndex, types.Testmodel, types.Text, types.Cosine, 2)
+	idx, _ := NewLinearIndex(cfg)
+	vec, _ := v.NewVector([]float32{1, 1}, 2)
+	idx.AddWithMetadata("a1", vec, Metadata{"parent": "a"})
+	idx.AddWithMetadata("a2", vec, Metadata{"parent": "a"})
+	idx.AddWithMetadata("b1", vec, Metadata{"parent": "b"})
+	idx.Add("plain", vec)
+
+	deleted, err := DeleteWhere(idx, func(id string, md Metadata) bool { return md["parent"] == "a" })
+	if err != nil || deleted != 2 {
+		t.Fatalf("expected 2 deleted, got %d (err %v)", deleted, err)
+	}
+	for id, want := range map[string]bool{"a1": false, "a2": false, "b1": true, "plain": true} {
+		if _, ok := idx.Get(id); ok != want {
+			t.Errorf("%s: expected present %v", id, want)
+		}
+	}
+
+	if _, err := DeleteWhere(struct{ VectorIndex }{idx}, func(string, Metadata) bool { return true }); err == nil {
+		t.Error("expected an index without Range and Metadata to fail")
+	}
+}
@@ -0,0 +1,108 @@
+package index
+
+import (
+	v "VectorDatabase/internal/vector"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// PageRequest selects one page of search results.
+// Cursor (from a previous Page) takes precedence over Offset, offset pages shift when vectors
+// are inserted above them while cursor pages stay stable and never overlap
+type PageRequest struct {
+	PageSize int
+	Offset   int
+	Cursor   string
+}
+
+// Page is one slice of the full result ordering, NextCursor is empty on the last page
+type Page struct {
+	Results    []SearchResult
+	NextCursor string
+}
+
+// cursor marks the last result handed out, the next page starts strictly after it
+type cursor struct {
+	score float64
+	id    string
+}
+
+func encodeCursor(r SearchResult) string {
+	buf := make([]byte, 8, 8+len(r.vecId))
+	binary.BigEndian.PutUint64(buf, math.Float64bits(r.score))
+	buf = append(buf, r.vecId...)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+func decodeCursor(token string) (cursor, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(buf) <= 8 {
+		return cursor{}, errors.New("invalid page cursor")
+	}
+	score := math.Float64frombits(binary.BigEndian.Uint64(buf[:8]))
+	if math.IsNaN(score) || math.IsInf(score, 0) {
+		return cursor{}, errors.New("invalid page cursor")
+	}
+	return cursor{score: score, id: string(buf[8:])}, nil
+}
+
+// results ordered after the cursor in compareResults order
+func (c cursor) before(r SearchResult) bool {
+	return compareResults(SearchResult{vecId: c.id, score: c.score}, r) < 0
+}
+
+// SearchPage returns the requested page for query, the index is searched with a growing k until
+// the page (plus one look ahead result to know if another page exists) is filled or results run out
+func SearchPage(idx VectorIndex, query *v.Vector, req PageRequest) (Page, error) {
+	if idx == nil {
+		return Page{}, errors.New("nil index")
+	}
+	if req.PageSize <= 0 {
+		return Page{}, errors.New("invalid page size")
+	}
+	if req.Offset < 0 {
+		return Page{}, errors.New("invalid page offset")
+	}
+	var after *cursor
+	if req.Cursor != "" {
+		c, err := decodeCursor(req.Cursor)
+		if err != nil {
+			return Page{}, err
+		}
+		after = &c
+	}
+	k := req.PageSize + 1
+	if after == nil {
+		k += req.Offset
+	}
+	for {
+		found, err := idx.Search(query, k)
+		if err != nil {
+			return Page{}, err
+		}
+		exhausted := len(found) < k
+		var rest []SearchResult
+		if after == nil {
+			rest = found[min(req.Offset, len(found)):]
+		} else {
+			start := len(found)
+			for i, r := range found {
+				if after.before(r) {
+					start = i
+					break
+				}
+			}
+			rest = found[start:]
+		}
+		if len(rest) > req.PageSize {
+			results := rest[:req.PageSize]
+			return Page{Results: results, NextCursor: encodeCursor(results[len(results)-1])}, nil
+		}
+		if exhausted {
+			return Page{Results: rest}, nil
+		}
+		k *= 2
+	}
+}
@@ -0,0 +1,122 @@
+package index
+
+import (
+	v "VectorDatabase/internal/vector"
+	"fmt"
+	"testing"
+)
+
+// Helper to add n 2D vectors spread over a quarter circle, vec-0 is closest to the x axis
+func fillPageIndex(t *testing.T, idx *LinearIndex, prefix string, n int) {
+	for i := 0; i < n; i++ {
+		vec, err := v.NewVector([]float32{float32(n - i), float32(i)}, 2)
+		if err != nil {
+			t.Fatalf("failed to create vector: %v", err)
+		}
+		idx.Add(fmt.Sprintf("%s-%d", prefix, i), vec)
+	}
+}
+
+// Post-condition: walking cursors visits every result exactly once, in search order.
+func TestSearchPage_CursorWalk(t *testing.T) {
+	idx := setupIndex(t, 2)
+	fillPageIndex(t, idx, "vec", 10)
+	query, _ := v.NewVector([]float32{1, 0}, 2)
+	all, _ := idx.Search(query, 10)
+
+	var walked []SearchResult
+	req := PageRequest{PageSize: 3}
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatal("pagination did not terminate")
+		}
+		page, err := SearchPage(idx, query, req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		walked = append(walked, page.Results...)
+		if page.NextCursor == "" {
+			break
+		}
+		req.Cursor = page.NextCursor
+	}
+	if len(walked) != len(all) {
+		t.Fatalf("expected %d results, got %d", len(all), len(walked))
+	}
+	for i := range all {
+		if walked[i].ID() != all[i].ID() {
+			t.Errorf("position %d: expected %s, got %s", i, all[i].ID(), walked[i].ID())
+		}
+	}
+}
+
+// Invariant: inserts ranked above the cursor don't shift or repeat the following pages.
+func TestSearchPage_StableUnderInserts(t *testing.T) {
+	idx := setupIndex(t, 2)
+	fillPageIndex(t, idx, "vec", 6)
+	query, _ := v.NewVector([]float32{1, 0}, 2)
+
+	first, err := SearchPage(idx, query, PageRequest{PageSize: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// closer to the query than anything on the first page
+	top, _ := v.NewVector([]float32{1, 0}, 2)
+	idx.Add("inserted", top)
+
+	second, err := SearchPage(idx, query, PageRequest{PageSize: 3, Cursor: first.NextCursor})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	seen := make(map[string]bool)
+	for _, r := range first.Results {
+		seen[r.ID()] = true
+	}
+	for _, r := range second.Results {
+		if seen[r.ID()] || r.ID() == "inserted" {
+			t.Errorf("result %s repeated across pages", r.ID())
+		}
+	}
+	if len(second.Results) != 3 || second.NextCursor != "" {
+		t.Errorf("expected a final page of 3, got %d results, cursor %q", len(second.Results), second.NextCursor)
+	}
+}
+
+// Contract: Offset skips results from the top when no cursor is given.
+func TestSearchPage_Offset(t *testing.T) {
+	idx := setupIndex(t, 2)
+	fillPageIndex(t, idx, "vec", 5)
+	query, _ := v.NewVector([]float32{1, 0}, 2)
+	all, _ := idx.Search(query, 5)
+
+	page, err := SearchPage(idx, query, PageRequest{PageSize: 2, Offset: 4})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(page.Results) != 1 || page.Results[0].ID() != all[4].ID() || page.NextCursor != "" {
+		t.Errorf("unexpected last page: %+v", page)
+	}
+}
+
+// Contract: SearchPage must reject invalid page requests and cursors.
+func TestSearchPage_Contracts(t *testing.T) {
+	idx := setupIndex(t, 2)
+	fillPageIndex(t, idx, "vec", 3)
+	query, _ := v.NewVector([]float32{1, 0}, 2)
+	tests := []struct {
+		name string
+		req  PageRequest
+	}{
+		{"Zero Page Size", PageRequest{PageSize: 0}},
+		{"Negative Offset", PageRequest{PageSize: 1, Offset: -1}},
+		{"Garbage Cursor", PageRequest{PageSize: 1, Cursor: "not a cursor!"}},
+		{"Truncated Cursor", PageRequest{PageSize: 1, Cursor: "AAAA"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := SearchPage(idx, query, tt.req); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
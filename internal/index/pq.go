@@ -0,0 +1,168 @@
+package index
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/rand/v2"
+)
+
+// productQuantizer compresses vectors into m one byte codes, one per subspace of dim/m values.
+// Each subspace has its own codebook of up to 256 centroids learned with k-means
+type productQuantizer struct {
+	dim       int
+	m         int
+	subDim    int
+	k         int
+	centroids []float32 // m * k * subDim
+}
+
+const pqIterations = 12
+
+// pqSubspaces picks the largest divisor of dim not above want
+func pqSubspaces(dim, want int) int {
+	for m := min(want, dim); m > 1; m-- {
+		if dim%m == 0 {
+			return m
+		}
+	}
+	return 1
+}
+
+// trainPQ learns codebooks from samples, the seed keeps training deterministic
+func trainPQ(samples [][]float32, dim, m int, seed uint64) (*productQuantizer, error) {
+	if len(samples) == 0 {
+		return nil, errors.New("no samples to train product quantizer")
+	}
+	if m <= 0 || dim%m != 0 {
+		return nil, errors.New("dimension must be divisible by the number of subspaces")
+	}
+	pq := &productQuantizer{dim: dim, m: m, subDim: dim / m, k: min(256, len(samples))}
+	pq.centroids = make([]float32, m*pq.k*pq.subDim)
+	rng := rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+	assign := make([]int, len(samples))
+	for sub := 0; sub < m; sub++ {
+		// start from k distinct random samples
+		for c, s := range rng.Perm(len(samples))[:pq.k] {
+			copy(pq.centroid(sub, c), samples[s][sub*pq.subDim:])
+		}
+		for iter := 0; iter < pqIterations; iter++ {
+			changed := false
+			for i, s := range samples {
+				best := pq.nearest(sub, s[sub*pq.subDim:(sub+1)*pq.subDim])
+				if iter == 0 || best != assign[i] {
+					changed = true
+				}
+				assign[i] = best
+			}
+			if !changed {
+				break
+			}
+			pq.updateCentroids(sub, samples, assign)
+		}
+	}
+	return pq, nil
+}
+
+func (pq *productQuantizer) centroid(sub, c int) []float32 {
+	start := (sub*pq.k + c) * pq.subDim
+	return pq.centroids[start : start+pq.subDim]
+}
+
+func (pq *productQuantizer) nearest(sub int, part []float32) int {
+	best, bestDist := 0, math.Inf(1)
+	for c := 0; c < pq.k; c++ {
+		var dist float64
+		for i, val := range pq.centroid(sub, c) {
+			diff := float64(part[i] - val)
+			dist += diff * diff
+		}
+		if dist < bestDist {
+			best, bestDist = c, dist
+		}
+	}
+	return best
+}
+
+// moves every centroid of sub to the mean of its samples, empty clusters keep their centroid
+func (pq *productQuantizer) updateCentroids(sub int, samples [][]float32, assign []int) {
+	sums := make([]float64, pq.k*pq.subDim)
+	counts := make([]int, pq.k)
+	for i, s := range samples {
+		c := assign[i]
+		counts[c]++
+		for j, val := range s[sub*pq.subDim : (sub+1)*pq.subDim] {
+			sums[c*pq.subDim+j] += float64(val)
+		}
+	}
+	for c := 0; c < pq.k; c++ {
+		if counts[c] == 0 {
+			continue
+		}
+		cent := pq.centroid(sub, c)
+		for j := range cent {
+			cent[j] = float32(sums[c*pq.subDim+j] / float64(counts[c]))
+		}
+	}
+}
+
+func (pq *productQuantizer) encode(values []float32) []byte {
+	code := make([]byte, pq.m)
+	for sub := range code {
+		code[sub] = byte(pq.nearest(sub, values[sub*pq.subDim:(sub+1)*pq.subDim]))
+	}
+	return code
+}
+
+// dotTable precomputes the dot product of each query subvector with every centroid,
+// the approximate dot product of a code is then m table lookups
+func (pq *productQuantizer) dotTable(query []float32) []float64 {
+	table := make([]float64, pq.m*pq.k)
+	for sub := 0; sub < pq.m; sub++ {
+		part := query[sub*pq.subDim : (sub+1)*pq.subDim]
+		for c := 0; c < pq.k; c++ {
+			var dot float64
+			for i, val := range pq.centroid(sub, c) {
+				dot += float64(part[i]) * float64(val)
+			}
+			table[sub*pq.k+c] = dot
+		}
+	}
+	return table
+}
+
+func (pq *productQuantizer) approxDot(table []float64, code []byte) float64 {
+	var dot float64
+	for sub, c := range code {
+		dot += table[sub*pq.k+int(c)]
+	}
+	return dot
+}
+
+// binary form: dim u32 | m u32 | k u32 | centroids float32, little endian
+func (pq *productQuantizer) MarshalBinary() ([]byte, error) {
+	buf := binary.LittleEndian.AppendUint32(nil, uint32(pq.dim))
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(pq.m))
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(pq.k))
+	for _, val := range pq.centroids {
+		buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(val))
+	}
+	return buf, nil
+}
+
+func (pq *productQuantizer) UnmarshalBinary(data []byte) error {
+	if len(data) < 12 {
+		return errors.New("truncated product quantizer")
+	}
+	dim := int(binary.LittleEndian.Uint32(data))
+	m := int(binary.LittleEndian.Uint32(data[4:]))
+	k := int(binary.LittleEndian.Uint32(data[8:]))
+	if dim <= 0 || m <= 0 || dim%m != 0 || k <= 0 || k > 256 || len(data) != 12+dim*k*4 {
+		return errors.New("invalid product quantizer")
+	}
+	*pq = productQuantizer{dim: dim, m: m, subDim: dim / m, k: k, centroids: make([]float32, dim*k)}
+	for i := range pq.centroids {
+		pq.centroids[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[12+i*4:]))
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package index
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func randomUnitVectors(n, dim int, seed uint64) [][]float32 {
+	rng := rand.New(rand.NewPCG(seed, seed))
+	out := make([][]float32, n)
+	for i := range out {
+		vals := make([]float32, dim)
+		var norm float64
+		for j := range vals {
+			vals[j] = float32(rng.NormFloat64())
+			norm += float64(vals[j] * vals[j])
+		}
+		for j := range vals {
+			vals[j] /= float32(math.Sqrt(norm))
+		}
+		out[i] = vals
+	}
+	return out
+}
+
+// Invariant: the approximate dot product stays close to the exact one and the codebook round trips.
+func TestProductQuantizer(t *testing.T) {
+	samples := randomUnitVectors(300, 16, 1)
+	pq, err := trainPQ(samples, 16, pqSubspaces(16, 8), 7)
+	if err != nil {
+		t.Fatalf("training failed: %v", err)
+	}
+	if pq.m != 8 || pq.k != 256 {
+		t.Fatalf("expected 8 subspaces of 256 centroids, got %d of %d", pq.m, pq.k)
+	}
+	query := samples[0]
+	table := pq.dotTable(query)
+	var errSum float64
+	for _, s := range samples {
+		var exact float64
+		for i := range s {
+			exact += float64(s[i] * query[i])
+		}
+		diff := pq.approxDot(table, pq.encode(s)) - exact
+		errSum += diff * diff
+	}
+	if mse := errSum / float64(len(samples)); mse > 0.01 {
+		t.Errorf("approximation error too large: mse %f", mse)
+	}
+
+	data, _ := pq.MarshalBinary()
+	var loaded productQuantizer
+	if err := loaded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if string(loaded.encode(query)) != string(pq.encode(query)) {
+		t.Error("reloaded quantizer encodes differently")
+	}
+	if err := loaded.UnmarshalBinary(data[:20]); err == nil {
+		t.Error("expected error for a truncated codebook")
+	}
+	if pqSubspaces(12, 8) != 6 || pqSubspaces(7, 8) != 7 || pqSubspaces(13, 8) != 1 {
+		t.Error("unexpected subspace count")
+	}
+}
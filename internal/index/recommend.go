@@ -0,0 +1,193 @@
+package index
+
+import (
+	v "VectorDatabase/internal/vector"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// ErrExamplesCancelOut is returned by RecommendAverageVector when the examples average to a zero query
+var ErrExamplesCancelOut = errors.New("positive and negative examples cancel out")
+
+// cancelledNorm is the query norm below which the average strategy has nothing left to search for
+const cancelledNorm = 1e-6
+
+// RecommendStrategy decides how positive and negative examples are turned into a search
+type RecommendStrategy int
+
+const (
+	// RecommendAverageVector searches with a single query built from the example centroids
+	RecommendAverageVector RecommendStrategy = iota
+	// RecommendBestScore scores every candidate against each example and keeps the best match
+	RecommendBestScore
+)
+
+// RecommendRequest is a "more like these, less like those" query over ids already stored in the index
+type RecommendRequest struct {
+	Positive []string
+	Negative []string
+	Strategy RecommendStrategy
+	K        int
+}
+
+// Recommend fetches the example vectors from the index itself (clients only send ids)
+// and returns the k best matches, never including the examples themselves
+func Recommend(idx VectorIndex, req RecommendRequest) ([]SearchResult, error) {
+	if idx == nil {
+		return nil, errors.New("nil index")
+	}
+	if len(req.Positive) == 0 {
+		return nil, errors.New("at least one positive example required")
+	}
+	if req.K <= 0 {
+		return nil, errors.New("invalid input for number of results")
+	}
+	positives, err := lookupExamples(idx, req.Positive)
+	if err != nil {
+		return nil, err
+	}
+	negatives, err := lookupExamples(idx, req.Negative)
+	if err != nil {
+		return nil, err
+	}
+	excluded := make(map[string]struct{}, len(req.Positive)+len(req.Negative))
+	for _, id := range req.Positive {
+		excluded[id] = struct{}{}
+	}
+	for _, id := range req.Negative {
+		excluded[id] = struct{}{}
+	}
+	switch req.Strategy {
+	case RecommendAverageVector:
+		return recommendAverage(idx, positives, negatives, excluded, req.K)
+	case RecommendBestScore:
+		return recommendBestScore(idx, positives, negatives, excluded, req.K)
+	default:
+		return nil, errors.New("invalid recommend strategy")
+	}
+}
+
+func lookupExamples(idx VectorIndex, ids []string) ([]*v.Vector, error) {
+	vecs := make([]*v.Vector, 0, len(ids))
+	for _, id := range ids {
+		vec, ok := idx.Get(id)
+		if !ok {
+			return nil, fmt.Errorf("example vector %q doesn't exist in index", id)
+		}
+		vecs = append(vecs, vec)
+	}
+	return vecs, nil
+}
+
+// query = avg(positive) + (avg(positive) - avg(negative)), pushes the query away from the negatives
+func recommendAverage(idx VectorIndex, positives, negatives []*v.Vector, excluded map[string]struct{}, k int) ([]SearchResult, error) {
+	dim := positives[0].Dimensions()
+	query := centroid(positives, dim)
+	if len(negatives) > 0 {
+		negCentroid := centroid(negatives, dim)
+		for i := range query {
+			query[i] += query[i] - negCentroid[i]
+		}
+	}
+	var norm float64
+	for _, val := range query {
+		norm += float64(val) * float64(val)
+	}
+	if math.Sqrt(norm) < cancelledNorm {
+		return nil, ErrExamplesCancelOut
+	}
+	qVec, err := v.NewVector(query, dim)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build recommend query: %w", err)
+	}
+	// over fetch so that dropping the examples still leaves k results
+	found, err := idx.Search(qVec, k+len(excluded))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]SearchResult, 0, k)
+	for _, r := range found {
+		if _, ok := excluded[r.vecId]; ok {
+			continue
+		}
+		result = append(result, r)
+		if len(result) == k {
+			break
+		}
+	}
+	return result, nil
+}
+
+func centroid(vecs []*v.Vector, dim int) []float32 {
+	sum := make([]float32, dim)
+	for _, vec := range vecs {
+		for i, val := range vec.Values() {
+			sum[i] += val
+		}
+	}
+	for i := range sum {
+		sum[i] /= float32(len(vecs))
+	}
+	return sum
+}
+
+// candidates come from a search per positive example, each candidate is then scored by its best
+// positive similarity, or by the negated best negative similarity when a negative example is closer
+func recommendBestScore(idx VectorIndex, positives, negatives []*v.Vector, excluded map[string]struct{}, k int) ([]SearchResult, error) {
+	candidates := make(map[string]*v.Vector)
+	for _, pos := range positives {
+		found, err := idx.Search(pos, k+len(excluded))
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range found {
+			if _, ok := excluded[r.vecId]; ok {
+				continue
+			}
+			if _, ok := candidates[r.vecId]; ok {
+				continue
+			}
+			// candidate may have been deleted between Search and Get
+			if vec, ok := idx.Get(r.vecId); ok {
+				candidates[r.vecId] = vec
+			}
+		}
+	}
+	result := make([]SearchResult, 0, len(candidates))
+	for id, vec := range candidates {
+		bestPos, err := bestSimilarity(vec, positives)
+		if err != nil {
+			return nil, err
+		}
+		score := bestPos
+		if len(negatives) > 0 {
+			bestNeg, err := bestSimilarity(vec, negatives)
+			if err != nil {
+				return nil, err
+			}
+			if bestNeg > bestPos {
+				score = -bestNeg
+			}
+		}
+		result = append(result, SearchResult{vecId: id, score: score})
+	}
+	slices.SortFunc(result, compareResults)
+	if k < len(result) {
+		result = result[:k]
+	}
+	return result, nil
+}
+
+func bestSimilarity(vec *v.Vector, examples []*v.Vector) (float64, error) {
+	best := -2.0 // below the minimum cosine similarity
+	for _, ex := range examples {
+		sim, err := vec.Similarity(ex)
+		if err != nil {
+			return 0, err
+		}
+		best = max(best, sim)
+	}
+	return best, nil
+}
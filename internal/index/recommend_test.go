@@ -0,0 +1,118 @@
+package index
+
+import (
+	v "VectorDatabase/internal/vector"
+	"errors"
+	"testing"
+)
+
+// Helper to fill an index with 2D vectors keyed by id
+func setupRecommendIndex(t *testing.T) *LinearIndex {
+	idx := setupIndex(t, 2)
+	points := map[string][]float32{
+		"east":       {1, 0},
+		"east-north": {0.9, 0.1},
+		"north-east": {0.6, 0.4},
+		"north":      {0, 1},
+		"west":       {-1, 0},
+		"west-north": {-0.9, 0.2},
+	}
+	for id, vals := range points {
+		vec, err := v.NewVector(vals, 2)
+		if err != nil {
+			t.Fatalf("failed to create vector %s: %v", id, err)
+		}
+		if _, err := idx.Add(id, vec); err != nil {
+			t.Fatalf("failed to add vector %s: %v", id, err)
+		}
+	}
+	return idx
+}
+
+// Contract: examples are never part of the recommendation.
+// Post-condition: results are ordered by descending score and capped at k.
+func TestRecommend_Strategies(t *testing.T) {
+	idx := setupRecommendIndex(t)
+
+	for _, strategy := range []RecommendStrategy{RecommendAverageVector, RecommendBestScore} {
+		res, err := Recommend(idx, RecommendRequest{
+			Positive: []string{"east"},
+			Negative: []string{"west"},
+			Strategy: strategy,
+			K:        2,
+		})
+		if err != nil {
+			t.Fatalf("strategy %d: unexpected error: %v", strategy, err)
+		}
+		if len(res) != 2 {
+			t.Fatalf("strategy %d: expected 2 results, got %d", strategy, len(res))
+		}
+		if res[0].ID() != "east-north" || res[1].ID() != "north-east" {
+			t.Errorf("strategy %d: unexpected order %s, %s", strategy, res[0].ID(), res[1].ID())
+		}
+		for _, r := range res {
+			if r.ID() == "east" || r.ID() == "west" {
+				t.Errorf("strategy %d: example %s returned in results", strategy, r.ID())
+			}
+		}
+	}
+}
+
+// Post-condition: best score strategy ranks candidates closer to a negative below all others.
+func TestRecommend_BestScoreNegatives(t *testing.T) {
+	idx := setupRecommendIndex(t)
+	res, err := Recommend(idx, RecommendRequest{
+		Positive: []string{"north"},
+		Negative: []string{"west"},
+		Strategy: RecommendBestScore,
+		K:        10,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	last := res[len(res)-1]
+	if last.ID() != "west-north" || last.Score() >= 0 {
+		t.Errorf("expected west-north with negative score last, got %s (%f)", last.ID(), last.Score())
+	}
+}
+
+// Contract: Recommend must reject requests it can't serve.
+func TestRecommend_Contracts(t *testing.T) {
+	idx := setupRecommendIndex(t)
+	tests := []struct {
+		name string
+		req  RecommendRequest
+	}{
+		{"No Positives", RecommendRequest{Negative: []string{"west"}, K: 1}},
+		{"Invalid K", RecommendRequest{Positive: []string{"east"}, K: 0}},
+		{"Unknown Example", RecommendRequest{Positive: []string{"ghost"}, K: 1}},
+		{"Unknown Strategy", RecommendRequest{Positive: []string{"east"}, Strategy: RecommendStrategy(99), K: 1}},
+		{"Examples Cancel Out", RecommendRequest{Positive: []string{"east", "west"}, K: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Recommend(idx, tt.req); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+// Contract: examples that average to a zero query fail with ErrExamplesCancelOut.
+func TestRecommend_ExamplesCancelOut(t *testing.T) {
+	idx := setupRecommendIndex(t)
+	tests := []struct {
+		name string
+		req  RecommendRequest
+	}{
+		{"Positives", RecommendRequest{Positive: []string{"east", "west"}, K: 1}},
+		{"Positives And Negatives", RecommendRequest{Positive: []string{"east", "west"}, Negative: []string{"east", "west"}, K: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Recommend(idx, tt.req); !errors.Is(err, ErrExamplesCancelOut) {
+				t.Errorf("expected ErrExamplesCancelOut, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package index
+
+import (
+	v "VectorDatabase/internal/vector"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+)
+
+// Reranker rescores candidates after vector retrieval, the caller re-sorts the returned slice
+type Reranker interface {
+	Rerank(ctx context.Context, query *v.Vector, candidates []SearchResult) ([]SearchResult, error)
+}
+
+// RerankRequest configures the rerank stages of a single query
+type RerankRequest struct {
+	K int
+	// Candidates is how many results retrieval hands to the rerankers, defaults to K
+	Candidates int
+	// Rerankers run in order, each one sees the previous stage's scores
+	Rerankers []Reranker
+}
+
+// SearchRerank retrieves req.Candidates results from idx, passes them through every reranker
+// and returns the best req.K by final score
+func SearchRerank(ctx context.Context, idx VectorIndex, query *v.Vector, req RerankRequest) ([]SearchResult, error) {
+	if idx == nil {
+		return nil, errors.New("nil index")
+	}
+	if req.K <= 0 {
+		return nil, errors.New("invalid input for number of results")
+	}
+	candidates := max(req.Candidates, req.K)
+	result, err := idx.Search(query, candidates)
+	if err != nil {
+		return nil, err
+	}
+	for i, rr := range req.Rerankers {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		result, err = rr.Rerank(ctx, query, result)
+		if err != nil {
+			return nil, fmt.Errorf("rerank stage %d failed: %w", i, err)
+		}
+		slices.SortFunc(result, compareResults)
+	}
+	if req.K < len(result) {
+		result = result[:req.K]
+	}
+	return result, nil
+}
+
+// VectorSource gives full precision vectors by id, every VectorIndex is one
+type VectorSource interface {
+	Get(id string) (*v.Vector, bool)
+}
+
+// ExactRescorer replaces approximate scores with the exact similarity against full precision vectors,
+// candidates missing from the source (deleted meanwhile) are dropped
+type ExactRescorer struct {
+	Vectors VectorSource
+}
+
+func (e ExactRescorer) Rerank(_ context.Context, query *v.Vector, candidates []SearchResult) ([]SearchResult, error) {
+	if e.Vectors == nil {
+		return nil, errors.New("exact rescorer has no vector source")
+	}
+	result := make([]SearchResult, 0, len(candidates))
+	for _, c := range candidates {
+		vec, ok := e.Vectors.Get(c.vecId)
+		if !ok {
+			continue
+		}
+		score, err := query.Similarity(vec)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, SearchResult{vecId: c.vecId, score: score})
+	}
+	return result, nil
+}
+
+// MetadataBooster adds metadata driven boosts to the similarity score:
+//
+//	score + RecencyWeight * 0.5^(age/HalfLife) + PopularityWeight * ln(1+popularity)
+//
+// The recency field holds unix seconds or an RFC 3339 string, the popularity field a non negative number.
+// Candidates without a field get no boost for it
+type MetadataBooster struct {
+	Metadata         MetadataSource
+	RecencyField     string
+	HalfLife         time.Duration
+	RecencyWeight    float64
+	PopularityField  string
+	PopularityWeight float64
+	// Now defaults to time.Now, settable for deterministic tests
+	Now func() time.Time
+}
+
+func (b MetadataBooster) Rerank(_ context.Context, _ *v.Vector, candidates []SearchResult) ([]SearchResult, error) {
+	if b.Metadata == nil {
+		return nil, errors.New("metadata booster has no metadata source")
+	}
+	if b.RecencyField != "" && b.HalfLife <= 0 {
+		return nil, errors.New("recency boost requires a positive half life")
+	}
+	now := time.Now
+	if b.Now != nil {
+		now = b.Now
+	}
+	ts := now()
+	result := make([]SearchResult, len(candidates))
+	for i, c := range candidates {
+		result[i] = c
+		md, ok := b.Metadata.Metadata(c.vecId)
+		if !ok {
+			continue
+		}
+		if b.RecencyField != "" {
+			if at, ok := metadataTime(md[b.RecencyField]); ok {
+				age := max(ts.Sub(at), 0)
+				result[i].score += b.RecencyWeight * math.Pow(0.5, float64(age)/float64(b.HalfLife))
+			}
+		}
+		if b.PopularityField != "" {
+			if pop, ok := md[b.PopularityField].(float64); ok && pop >= 0 {
+				result[i].score += b.PopularityWeight * math.Log1p(pop)
+			}
+		}
+	}
+	return result, nil
+}
+
+func metadataTime(val any) (time.Time, bool) {
+	switch t := val.(type) {
+	case float64:
+		return time.Unix(0, int64(t*float64(time.Second))), true
+	case string:
+		parsed, err := time.Parse(time.RFC3339, t)
+		return parsed, err == nil
+	case time.Time:
+		return t, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// CrossEncoder hooks an external scorer (e.g. a cross-encoder model service) into reranking.
+// It receives candidate ids in order and returns one score per id, the query text is expected
+// to be captured by the closure since the index only knows the query vector
+type CrossEncoder func(ctx context.Context, ids []string) ([]float64, error)
+
+func (ce CrossEncoder) Rerank(ctx context.Context, _ *v.Vector, candidates []SearchResult) ([]SearchResult, error) {
+	ids := make([]string, len(candidates))
+	for i, c := range candidates {
+		ids[i] = c.vecId
+	}
+	scores, err := ce(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(scores) != len(candidates) {
+		return nil, fmt.Errorf("cross encoder returned %d scores for %d candidates", len(scores), len(candidates))
+	}
+	result := make([]SearchResult, len(candidates))
+	for i, c := range candidates {
+		result[i] = SearchResult{vecId: c.vecId, score: scores[i]}
+	}
+	return result, nil
+}
+
+var _ Reranker = ExactRescorer{}
+var _ Reranker = MetadataBooster{}
+var _ Reranker = CrossEncoder(nil)
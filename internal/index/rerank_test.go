@@ -0,0 +1,117 @@
+package index
+
+import (
+	v "VectorDatabase/internal/vector"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Helper: three 2D vectors at decreasing similarity to the x axis
+func setupRerankIndex(t *testing.T) (*LinearIndex, *v.Vector) {
+	idx := setupIndex(t, 2)
+	near, _ := v.NewVector([]float32{1, 0.1}, 2)
+	mid, _ := v.NewVector([]float32{1, 1}, 2)
+	far, _ := v.NewVector([]float32{0.1, 1}, 2)
+	idx.AddWithMetadata("near", near, Metadata{"published": "2026-01-01T00:00:00Z", "views": 0.0})
+	idx.AddWithMetadata("mid", mid, Metadata{"published": "2026-01-09T00:00:00Z", "views": 10.0})
+	idx.AddWithMetadata("far", far, nil)
+	query, _ := v.NewVector([]float32{1, 0}, 2)
+	return idx, query
+}
+
+// Post-condition: exact rescoring restores true similarity and drops vanished candidates.
+func TestExactRescorer(t *testing.T) {
+	idx, query := setupRerankIndex(t)
+	// approximate scores in the wrong order, plus a candidate the source doesn't have
+	candidates := []SearchResult{{vecId: "far", score: 0.9}, {vecId: "near", score: 0.1}, {vecId: "ghost", score: 0.5}}
+	res, err := SearchRerank(context.Background(), stubIndex{idx, candidates}, query, RerankRequest{
+		K:         3,
+		Rerankers: []Reranker{ExactRescorer{Vectors: idx}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res) != 2 || res[0].ID() != "near" || res[1].ID() != "far" {
+		t.Fatalf("unexpected rescored results: %+v", res)
+	}
+	want, _ := query.Similarity(mustGet(t, idx, "near"))
+	if res[0].Score() != want {
+		t.Errorf("expected exact score %f, got %f", want, res[0].Score())
+	}
+}
+
+// Post-condition: recency and popularity boosts can lift a less similar vector to the top.
+func TestMetadataBooster(t *testing.T) {
+	idx, query := setupRerankIndex(t)
+	booster := MetadataBooster{
+		Metadata:         idx,
+		RecencyField:     "published",
+		HalfLife:         24 * time.Hour,
+		RecencyWeight:    0.5,
+		PopularityField:  "views",
+		PopularityWeight: 0.1,
+		Now:              func() time.Time { return time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC) },
+	}
+	res, err := SearchRerank(context.Background(), idx, query, RerankRequest{K: 3, Rerankers: []Reranker{booster}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res[0].ID() != "mid" {
+		t.Errorf("expected boosted mid first, got %s", res[0].ID())
+	}
+
+	booster.HalfLife = 0
+	if _, err := booster.Rerank(context.Background(), query, res); err == nil {
+		t.Error("expected error for recency boost without half life")
+	}
+}
+
+// Contract: the cross encoder decides the final order and must score every candidate.
+func TestCrossEncoder(t *testing.T) {
+	idx, query := setupRerankIndex(t)
+	reverse := CrossEncoder(func(_ context.Context, ids []string) ([]float64, error) {
+		scores := make([]float64, len(ids))
+		for i := range ids {
+			scores[i] = float64(i)
+		}
+		return scores, nil
+	})
+	res, err := SearchRerank(context.Background(), idx, query, RerankRequest{K: 1, Candidates: 3, Rerankers: []Reranker{reverse}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res) != 1 || res[0].ID() != "far" {
+		t.Errorf("expected far after reverse rerank, got %+v", res)
+	}
+
+	short := CrossEncoder(func(context.Context, []string) ([]float64, error) { return []float64{1}, nil })
+	if _, err := SearchRerank(context.Background(), idx, query, RerankRequest{K: 3, Rerankers: []Reranker{short}}); err == nil {
+		t.Error("expected error for missing cross encoder scores")
+	}
+	failing := CrossEncoder(func(context.Context, []string) ([]float64, error) { return nil, errors.New("model down") })
+	if _, err := SearchRerank(context.Background(), idx, query, RerankRequest{K: 3, Rerankers: []Reranker{failing}}); err == nil {
+		t.Error("expected cross encoder error to propagate")
+	}
+}
+
+// stubIndex returns fixed search results, standing in for an approximate index
+type stubIndex struct {
+	idx     *LinearIndex
+	results []SearchResult
+}
+
+func (s stubIndex) Add(id string, vec *v.Vector) (bool, error)    { return s.idx.Add(id, vec) }
+func (s stubIndex) Delete(id string) error                        { return s.idx.Delete(id) }
+func (s stubIndex) Get(id string) (*v.Vector, bool)               { return s.idx.Get(id) }
+func (s stubIndex) Search(*v.Vector, int) ([]SearchResult, error) { return s.results, nil }
+func (s stubIndex) Size() int                                     { return s.idx.Size() }
+
+func mustGet(t *testing.T, idx VectorIndex, id string) *v.Vector {
+	vec, ok := idx.Get(id)
+	if !ok {
+		t.Fatalf("vector %s missing", id)
+	}
+	return vec
+}
@@ -1,5 +1,7 @@
 package index
 
+import "cmp"
+
 // SearchResult represent onematch result after vector serach, immutable, ordered by desceding similarity score
 type SearchResult struct {
 	vecId string
@@ -12,3 +14,12 @@ func (r SearchResult) ID() string {
 func (r SearchResult) Score() float64 {
 	return r.score
 }
+
+// compareResults orders by descending score, equal scores fall back to ascending id
+// so result order is deterministic across calls
+func compareResults(a, b SearchResult) int {
+	if c := cmp.Compare(b.score, a.score); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.vecId, b.vecId)
+}
@@ -0,0 +1,858 @@
+package index
+
+import (
+	"VectorDatabase/internal/store"
+	v "VectorDatabase/internal/vector"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// SegmentedOptions tune a SegmentedIndex, zero values pick the defaults
+type SegmentedOptions struct {
+	// FlushThreshold is how many vectors and tombstones the mutable segment holds before it is sealed, default 4096
+	FlushThreshold int
+	// CompactionThreshold is the sealed segment count that wakes the background compactor, default 4
+	CompactionThreshold int
+	// SyncWrites fsyncs the WAL on every mutation instead of only on flush and Close
+	SyncWrites bool
+	// Factory builds the sub-index of each sealed segment, default DefaultIndexFactory. A DirIndexFactory
+	// gets a directory per segment inside the collection directory
+	Factory IndexFactory
+}
+
+// errIndexClosed is returned by every mutation after Close
+var errIndexClosed = errors.New("index closed")
+
+const (
+	defaultFlushThreshold      = 4096
+	defaultCompactionThreshold = 4
+)
+
+// segment is one layer of a SegmentedIndex: the mutable memtable or a sealed immutable segment.
+// Within a segment an entry wins over a tombstone for the same id (deleted, then re-added)
+type segment struct {
+	info       store.SegmentInfo
+	index      VectorIndex
+	metadata   map[string]Metadata
+	tombstones map[string]struct{}
+	// ids of a sealed segment, its sub-index may not be able to enumerate itself
+	ids []string
+	// dir holds the files of a disk resident sub-index, "" for in-memory ones
+	dir string
+}
+
+// close releases the sub-index, its files stay for the next open
+func (s *segment) close() error {
+	if c, ok := s.index.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// drop closes the sub-index of a segment that is gone and removes its files
+func (s *segment) drop() error {
+	err := s.close()
+	if s.dir != "" {
+		err = errors.Join(err, os.RemoveAll(s.dir))
+	}
+	return err
+}
+
+func closeSegments(segs []*segment) error {
+	var errs []error
+	for _, seg := range segs {
+		errs = append(errs, seg.close())
+	}
+	return errors.Join(errs...)
+}
+
+func (s *segment) has(id string) bool {
+	_, ok := s.index.Get(id)
+	return ok
+}
+
+// contains reports whether this segment decides the fate of id for every older segment
+func (s *segment) contains(id string) bool {
+	if _, ok := s.tombstones[id]; ok {
+		return true
+	}
+	return s.has(id)
+}
+
+func (s *segment) ops() int {
+	return s.index.Size() + len(s.tombstones)
+}
+
+func (s *segment) forEach(fn func(id string, vec *v.Vector) bool) {
+	if r, ok := s.index.(RangeIndex); ok {
+		r.Range(fn)
+		return
+	}
+	for _, id := range s.ids {
+		if vec, ok := s.index.Get(id); ok && !fn(id, vec) {
+			return
+		}
+	}
+}
+
+// search returns the k best vectors of this segment that pass visible and the query filter
+func (s *segment) search(query *v.Vector, k int, opts SearchOptions, visible func(id string) bool) ([]SearchResult, *SearchStats, error) {
+	filter := func(id string, _ Metadata) bool {
+		if !visible(id) {
+			return false
+		}
+		return opts.Filter == nil || opts.Filter(id, s.metadata[id])
+	}
+	if searcher, ok := s.index.(OptionSearcher); ok {
+		return searcher.SearchWithOptions(query, k, SearchOptions{Filter: filter, Explain: opts.Explain})
+	}
+	// plain sub-index, over fetch until k results survive the filter
+	for n := k; ; n *= 2 {
+		found, err := s.index.Search(query, n)
+		if err != nil {
+			return nil, nil, err
+		}
+		kept := make([]SearchResult, 0, k)
+		for _, r := range found {
+			if filter(r.vecId, nil) {
+				kept = append(kept, r)
+			}
+		}
+		if len(kept) >= k || len(found) < n {
+			return kept[:min(k, len(kept))], nil, nil
+		}
+	}
+}
+
+// SegmentedIndex is an LSM-like storage engine: a mutable in-memory segment absorbs Adds (logged to a WAL),
+// is sealed into an immutable on-disk segment with its own sub-index once full, and a background
+// compactor merges sealed segments and purges deleted vectors. Deletes of sealed vectors are tombstones.
+// Searches fan out over every segment and merge the per-segment top-k
+type SegmentedIndex struct {
+	mu       sync.RWMutex
+	dir      string
+	lock     *store.DirLock
+	config   IndexConfig
+	opts     SegmentedOptions
+	wal      *store.WAL
+	manifest store.Manifest
+	memtable *segment
+	// oldest first
+	sealed []*segment
+	size   int
+	closed bool
+	bgErr  error
+
+	// one compaction at a time, held without mu while segments are merged
+	compactMu sync.Mutex
+	compactCh chan struct{}
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// OpenSegmentedIndex opens the collection stored in dir, creating it when dir holds none.
+// An existing collection must have been created with the same cfg. The collection stays locked until
+// Close, opening it again meanwhile (from any process) fails with store.ErrLocked
+func OpenSegmentedIndex(dir string, cfg IndexConfig, opts SegmentedOptions) (_ *SegmentedIndex, err error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to initialize segmented index: %w", err)
+	}
+	if opts.FlushThreshold <= 0 {
+		opts.FlushThreshold = defaultFlushThreshold
+	}
+	if opts.CompactionThreshold <= 1 {
+		opts.CompactionThreshold = defaultCompactionThreshold
+	}
+	if opts.Factory == nil {
+		opts.Factory = &DefaultIndexFactory{}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	lock, err := store.LockDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", dir, err)
+	}
+	defer func() {
+		if err != nil {
+			lock.Unlock()
+		}
+	}()
+	m, err := store.ReadManifest(dir)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		raw, err := json.Marshal(cfg)
+		if err != nil {
+			return nil, err
+		}
+		m = store.Manifest{Config: raw, NextSegment: 1}
+		if err := store.WriteManifest(dir, m); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	default:
+		stored, err := LoadSegmentedConfig(dir)
+		if err != nil {
+			return nil, err
+		}
+		if stored != cfg {
+			return nil, errors.New("index config doesn't match stored collection config")
+		}
+	}
+	if err := store.RemoveOrphanSegments(dir, m); err != nil {
+		return nil, err
+	}
+	si := &SegmentedIndex{
+		dir:       dir,
+		lock:      lock,
+		config:    cfg,
+		opts:      opts,
+		manifest:  m,
+		compactCh: make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+	si.memtable, err = si.newMemtable()
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range m.Segments {
+		data, err := store.ReadSegment(filepath.Join(dir, info.File))
+		if err != nil {
+			closeSegments(si.sealed)
+			return nil, err
+		}
+		seg, err := si.buildSegment(info, data)
+		if err != nil {
+			closeSegments(si.sealed)
+			return nil, err
+		}
+		si.sealed = append(si.sealed, seg)
+	}
+	wal, records, err := store.OpenWAL(filepath.Join(dir, store.WALFileName), m.FlushedLSN, opts.SyncWrites)
+	if err != nil {
+		closeSegments(si.sealed)
+		return nil, err
+	}
+	si.wal = wal
+	for _, rec := range records {
+		if rec.LSN <= m.FlushedLSN {
+			continue
+		}
+		if err := si.replay(rec); err != nil {
+			wal.Close()
+			closeSegments(si.sealed)
+			return nil, fmt.Errorf("failed to replay wal record %d: %w", rec.LSN, err)
+		}
+	}
+	si.size = si.countVisible()
+	si.wg.Add(1)
+	go si.compactor()
+	return si, nil
+}
+
+// LoadSegmentedConfig reads the IndexConfig a collection directory was created with
+func LoadSegmentedConfig(dir string) (IndexConfig, error) {
+	m, err := store.ReadManifest(dir)
+	if err != nil {
+		return IndexConfig{}, err
+	}
+	var cfg IndexConfig
+	if err := json.Unmarshal(m.Config, &cfg); err != nil {
+		return IndexConfig{}, fmt.Errorf("invalid stored index config: %w", err)
+	}
+	return cfg, nil
+}
+
+func (si *SegmentedIndex) newMemtable() (*segment, error) {
+	li, err := NewLinearIndex(si.config)
+	if err != nil {
+		return nil, err
+	}
+	return &segment{index: li, metadata: make(map[string]Metadata), tombstones: make(map[string]struct{})}, nil
+}
+
+// buildSegment loads sealed segment data into a sub-index, a disk resident one reopens its directory
+// and only adds what it's missing
+func (si *SegmentedIndex) buildSegment(info store.SegmentInfo, data store.SegmentData) (*segment, error) {
+	if data.Dimension != si.config.Dimension() {
+		return nil, fmt.Errorf("segment %s: dimension mismatch", info.File)
+	}
+	var (
+		sub VectorIndex
+		dir string
+		err error
+	)
+	if f, ok := si.opts.Factory.(DirIndexFactory); ok {
+		dir = filepath.Join(si.dir, store.SegmentIndexDir(info.ID))
+		sub, err = f.CreateIndexIn(dir, si.config)
+	} else {
+		sub, err = si.opts.Factory.CreateIndex(si.config)
+	}
+	if err != nil {
+		return nil, err
+	}
+	seg := &segment{
+		info:       info,
+		index:      sub,
+		dir:        dir,
+		metadata:   make(map[string]Metadata),
+		tombstones: make(map[string]struct{}, len(data.Tombstones)),
+		ids:        make([]string, 0, len(data.Entries)),
+	}
+	for _, rec := range data.Entries {
+		vec, md, err := decodeRecord(rec, si.config.Dimension())
+		if err != nil {
+			seg.close()
+			return nil, fmt.Errorf("segment %s: %w", info.File, err)
+		}
+		if _, err := sub.Add(rec.ID, vec); err != nil {
+			seg.close()
+			return nil, fmt.Errorf("segment %s: %w", info.File, err)
+		}
+		if md != nil {
+			seg.metadata[rec.ID] = md
+		}
+		seg.ids = append(seg.ids, rec.ID)
+	}
+	for _, id := range data.Tombstones {
+		seg.tombstones[id] = struct{}{}
+	}
+	return seg, nil
+}
+
+func decodeRecord(rec store.Record, dim int) (*v.Vector, Metadata, error) {
+	vec, err := v.NewVector(rec.Values, dim)
+	if err != nil {
+		return nil, nil, fmt.Errorf("record %q: %w", rec.ID, err)
+	}
+	var md Metadata
+	if len(rec.Metadata) > 0 {
+		if err := json.Unmarshal(rec.Metadata, &md); err != nil {
+			return nil, nil, fmt.Errorf("record %q metadata: %w", rec.ID, err)
+		}
+	}
+	return vec, md, nil
+}
+
+func encodeMetadata(md Metadata) ([]byte, error) {
+	if len(md) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(md)
+}
+
+// layers returns every segment newest first, the memtable leads
+func (si *SegmentedIndex) layers() []*segment {
+	layers := make([]*segment, 0, len(si.sealed)+1)
+	layers = append(layers, si.memtable)
+	for i := len(si.sealed) - 1; i >= 0; i-- {
+		layers = append(layers, si.sealed[i])
+	}
+	return layers
+}
+
+// lookup finds the segment holding the live version of id
+func (si *SegmentedIndex) lookup(id string) (*segment, bool) {
+	for _, layer := range si.layers() {
+		if layer.has(id) {
+			return layer, true
+		}
+		if _, ok := layer.tombstones[id]; ok {
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+func (si *SegmentedIndex) countVisible() int {
+	layers := si.layers()
+	count := 0
+	for i, layer := range layers {
+		layer.forEach(func(id string, _ *v.Vector) bool {
+			if !shadowed(layers[:i], id) {
+				count++
+			}
+			return true
+		})
+	}
+	return count
+}
+
+func shadowed(newer []*segment, id string) bool {
+	for _, layer := range newer {
+		if layer.contains(id) {
+			return true
+		}
+	}
+	return false
+}
+
+func (si *SegmentedIndex) trackLSN(lsn uint64) {
+	if si.memtable.info.MinLSN == 0 {
+		si.memtable.info.MinLSN = lsn
+	}
+	si.memtable.info.MaxLSN = lsn
+}
+
+func (si *SegmentedIndex) applyAdd(id string, vec *v.Vector, md Metadata, lsn uint64) error {
+	if _, err := si.memtable.index.Add(id, vec); err != nil {
+		return err
+	}
+	if md != nil {
+		si.memtable.metadata[id] = md
+	}
+	si.trackLSN(lsn)
+	return nil
+}
+
+func (si *SegmentedIndex) applyDelete(id string, lsn uint64) {
+	if si.memtable.has(id) {
+		si.memtable.index.Delete(id)
+		delete(si.memtable.metadata, id)
+	}
+	// sealed segments can't change, hide their copy behind a tombstone
+	for _, seg := range si.sealed {
+		if seg.has(id) {
+			si.memtable.tombstones[id] = struct{}{}
+			break
+		}
+	}
+	si.trackLSN(lsn)
+}
+
+func (si *SegmentedIndex) replay(rec store.WALRecord) error {
+	switch rec.Op {
+	case store.WALAdd:
+		vec, md, err := decodeRecord(rec.Record, si.config.Dimension())
+		if err != nil {
+			return err
+		}
+		return si.applyAdd(rec.Record.ID, vec, md, rec.LSN)
+	case store.WALDelete:
+		si.applyDelete(rec.Record.ID, rec.LSN)
+		return nil
+	default:
+		return fmt.Errorf("unknown wal op %d", rec.Op)
+	}
+}
+
+func (si *SegmentedIndex) Dimension() int {
+	return si.config.Dimension()
+}
+
+func (si *SegmentedIndex) Config() IndexConfig {
+	return si.config
+}
+
+// Returns true if vector already exist else error
+func (si *SegmentedIndex) Add(id string, vec *v.Vector) (bool, error) {
+	return si.AddWithMetadata(id, vec, nil)
+}
+
+// AddWithMetadata logs the vector to the WAL and adds it to the mutable segment,
+// sealing the segment once it reaches the flush threshold
+func (si *SegmentedIndex) AddWithMetadata(id string, vec *v.Vector, md Metadata) (bool, error) {
+	if err := store.CheckID(id); err != nil {
+		return false, err
+	}
+	if vec == nil {
+		return false, errors.New("empty vector")
+	}
+	if si.config.Dimension() != vec.Dimensions() {
+		return false, errors.New("dimension mismatch")
+	}
+	raw, err := encodeMetadata(md)
+	if err != nil {
+		return false, fmt.Errorf("invalid metadata: %w", err)
+	}
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	if si.closed {
+		return false, errIndexClosed
+	}
+	if _, ok := si.lookup(id); ok {
+		return true, nil
+	}
+	lsn, err := si.wal.Append(store.WALAdd, store.Record{ID: id, Values: vec.Values(), Metadata: raw})
+	if err != nil {
+		return false, fmt.Errorf("failed to log add: %w", err)
+	}
+	if err := si.applyAdd(id, vec, md.clone(), lsn); err != nil {
+		return false, err
+	}
+	si.size++
+	return false, si.maybeFlushLocked()
+}
+
+func (si *SegmentedIndex) Delete(id string) error {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	if si.closed {
+		return errIndexClosed
+	}
+	if _, ok := si.lookup(id); !ok {
+		return errors.New("vector doesn't exist in index")
+	}
+	lsn, err := si.wal.Append(store.WALDelete, store.Record{ID: id})
+	if err != nil {
+		return fmt.Errorf("failed to log delete: %w", err)
+	}
+	si.applyDelete(id, lsn)
+	si.size--
+	return si.maybeFlushLocked()
+}
+
+func (si *SegmentedIndex) Get(id string) (*v.Vector, bool) {
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+	seg, ok := si.lookup(id)
+	if !ok {
+		return nil, false
+	}
+	return seg.index.Get(id)
+}
+
+func (si *SegmentedIndex) Metadata(id string) (Metadata, bool) {
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+	seg, ok := si.lookup(id)
+	if !ok {
+		return nil, false
+	}
+	return seg.metadata[id].clone(), true
+}
+
+func (si *SegmentedIndex) Search(query *v.Vector, k int) ([]SearchResult, error) {
+	result, _, err := si.SearchWithOptions(query, k, SearchOptions{})
+	return result, err
+}
+
+// SearchWithOptions searches every segment for its k best visible vectors and merges them,
+// stats add up the work of all segments
+func (si *SegmentedIndex) SearchWithOptions(query *v.Vector, k int, opts SearchOptions) ([]SearchResult, *SearchStats, error) {
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+	if si.size == 0 {
+		return nil, opts.newStats(), nil
+	}
+	if query == nil {
+		return nil, nil, errors.New("empty query input")
+	}
+	if si.config.Dimension() != query.Dimensions() {
+		return nil, nil, errors.New("index and query dimension mismatched")
+	}
+	if k <= 0 {
+		return nil, nil, errors.New("invalid input for number of results")
+	}
+	stats := opts.newStats()
+	layers := si.layers()
+	var merged []SearchResult
+	for i, layer := range layers {
+		if layer.index.Size() == 0 {
+			continue
+		}
+		newer := layers[:i]
+		found, layerStats, err := layer.search(query, k, opts, func(id string) bool { return !shadowed(newer, id) })
+		if err != nil {
+			return nil, nil, err
+		}
+		stats.merge(layerStats)
+		merged = append(merged, found...)
+	}
+	phase := stats.startPhase("merge")
+	slices.SortFunc(merged, compareResults)
+	if k < len(merged) {
+		merged = merged[:k]
+	}
+	phase.end()
+	return merged, stats, nil
+}
+
+// Range visits every live vector once, in no particular order
+func (si *SegmentedIndex) Range(fn func(id string, vec *v.Vector) bool) {
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+	layers := si.layers()
+	stop := false
+	for i, layer := range layers {
+		layer.forEach(func(id string, vec *v.Vector) bool {
+			if shadowed(layers[:i], id) {
+				return true
+			}
+			stop = !fn(id, vec)
+			return !stop
+		})
+		if stop {
+			return
+		}
+	}
+}
+
+func (si *SegmentedIndex) Size() int {
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+	return si.size
+}
+
+// SegmentCount is the number of sealed segments
+func (si *SegmentedIndex) SegmentCount() int {
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+	return len(si.sealed)
+}
+
+// Flush seals the mutable segment to disk now instead of waiting for the flush threshold
+func (si *SegmentedIndex) Flush() error {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	if si.closed {
+		return errIndexClosed
+	}
+	return si.flushLocked()
+}
+
+// Checkpoint flushes the mutable segment and hands fn the directory and manifest of that on-disk state.
+// Compactions wait until fn returns, so the segment files the manifest lists stay in place while fn
+// copies them; adds and searches keep running. Later flushes rewrite the MANIFEST file, copy m instead
+func (si *SegmentedIndex) Checkpoint(fn func(dir string, m store.Manifest) error) error {
+	si.compactMu.Lock()
+	defer si.compactMu.Unlock()
+	si.mu.Lock()
+	if si.closed {
+		si.mu.Unlock()
+		return errIndexClosed
+	}
+	if err := si.flushLocked(); err != nil {
+		si.mu.Unlock()
+		return err
+	}
+	m := si.manifest
+	m.Segments = slices.Clone(m.Segments)
+	si.mu.Unlock()
+	return fn(si.dir, m)
+}
+
+func (si *SegmentedIndex) maybeFlushLocked() error {
+	if si.memtable.ops() < si.opts.FlushThreshold {
+		return nil
+	}
+	if err := si.flushLocked(); err != nil {
+		return fmt.Errorf("failed to flush segment: %w", err)
+	}
+	return nil
+}
+
+// flushLocked writes the memtable as a new segment, records it in the manifest and empties the WAL
+func (si *SegmentedIndex) flushLocked() error {
+	mem := si.memtable
+	if mem.ops() == 0 {
+		return nil
+	}
+	data := store.SegmentData{Dimension: si.config.Dimension(), MinLSN: mem.info.MinLSN, MaxLSN: mem.info.MaxLSN}
+	var err error
+	mem.forEach(func(id string, vec *v.Vector) bool {
+		var raw []byte
+		raw, err = encodeMetadata(mem.metadata[id])
+		if err != nil {
+			return false
+		}
+		data.Entries = append(data.Entries, store.Record{ID: id, Values: vec.Values(), Metadata: raw})
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	for id := range mem.tombstones {
+		// the entry already hides older copies
+		if !mem.has(id) {
+			data.Tombstones = append(data.Tombstones, id)
+		}
+	}
+	sortSegmentData(&data)
+	info, seg, err := si.writeSegment(si.manifest.NextSegment, data)
+	if err != nil {
+		return err
+	}
+	m := si.manifest
+	m.Segments = append(slices.Clone(m.Segments), info)
+	m.NextSegment++
+	m.FlushedLSN = si.wal.LastLSN()
+	if err := store.WriteManifest(si.dir, m); err != nil {
+		seg.drop()
+		os.Remove(filepath.Join(si.dir, info.File))
+		return err
+	}
+	si.manifest = m
+	si.sealed = append(si.sealed, seg)
+	if si.memtable, err = si.newMemtable(); err != nil {
+		return err
+	}
+	// records up to FlushedLSN are skipped on replay, a failed reset only costs replay time
+	if err := si.wal.Reset(); err != nil {
+		return err
+	}
+	if len(si.sealed) >= si.opts.CompactionThreshold {
+		select {
+		case si.compactCh <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+func sortSegmentData(data *store.SegmentData) {
+	slices.SortFunc(data.Entries, func(a, b store.Record) int { return cmp.Compare(a.ID, b.ID) })
+	slices.Sort(data.Tombstones)
+}
+
+func (si *SegmentedIndex) writeSegment(id uint64, data store.SegmentData) (store.SegmentInfo, *segment, error) {
+	info := store.SegmentInfo{
+		ID:         id,
+		File:       store.SegmentFileName(id),
+		MinLSN:     data.MinLSN,
+		MaxLSN:     data.MaxLSN,
+		Entries:    len(data.Entries),
+		Tombstones: len(data.Tombstones),
+	}
+	path := filepath.Join(si.dir, info.File)
+	if err := store.WriteSegment(path, data); err != nil {
+		return store.SegmentInfo{}, nil, err
+	}
+	// the id is new, whatever sits in its index directory is left over from a crash
+	if err := os.RemoveAll(filepath.Join(si.dir, store.SegmentIndexDir(id))); err != nil {
+		os.Remove(path)
+		return store.SegmentInfo{}, nil, err
+	}
+	seg, err := si.buildSegment(info, data)
+	if err != nil {
+		os.RemoveAll(filepath.Join(si.dir, store.SegmentIndexDir(id)))
+		os.Remove(path)
+		return store.SegmentInfo{}, nil, err
+	}
+	return info, seg, nil
+}
+
+// Compact merges every sealed segment into one, keeping only the newest version of each id
+// and dropping tombstones along with the vectors they hide.
+// Adds and searches keep running while the merged segment is written
+func (si *SegmentedIndex) Compact() error {
+	si.compactMu.Lock()
+	defer si.compactMu.Unlock()
+
+	si.mu.Lock()
+	if si.closed {
+		si.mu.Unlock()
+		return errIndexClosed
+	}
+	inputs := slices.Clone(si.sealed)
+	if len(inputs) == 0 || (len(inputs) == 1 && inputs[0].info.Tombstones == 0) {
+		si.mu.Unlock()
+		return nil
+	}
+	id := si.manifest.NextSegment
+	si.manifest.NextSegment++
+	si.mu.Unlock()
+
+	// sealed segments are immutable, merge straight from their files, newest first
+	merged := store.SegmentData{Dimension: si.config.Dimension()}
+	seen := make(map[string]struct{})
+	for i := len(inputs) - 1; i >= 0; i-- {
+		data, err := store.ReadSegment(filepath.Join(si.dir, inputs[i].info.File))
+		if err != nil {
+			return err
+		}
+		if merged.MinLSN == 0 || (data.MinLSN != 0 && data.MinLSN < merged.MinLSN) {
+			merged.MinLSN = data.MinLSN
+		}
+		merged.MaxLSN = max(merged.MaxLSN, data.MaxLSN)
+		for _, rec := range data.Entries {
+			if _, ok := seen[rec.ID]; !ok {
+				seen[rec.ID] = struct{}{}
+				merged.Entries = append(merged.Entries, rec)
+			}
+		}
+		// nothing is older than the oldest input, so tombstones only need to hide entries seen later
+		for _, tomb := range data.Tombstones {
+			seen[tomb] = struct{}{}
+		}
+	}
+	sortSegmentData(&merged)
+	info, seg, err := si.writeSegment(id, merged)
+	if err != nil {
+		return err
+	}
+
+	si.mu.Lock()
+	// flushes only append, so the inputs are still the oldest segments
+	m := si.manifest
+	m.Segments = append([]store.SegmentInfo{info}, m.Segments[len(inputs):]...)
+	if err := store.WriteManifest(si.dir, m); err != nil {
+		si.mu.Unlock()
+		seg.drop()
+		os.Remove(filepath.Join(si.dir, info.File))
+		return err
+	}
+	si.manifest = m
+	si.sealed = append([]*segment{seg}, si.sealed[len(inputs):]...)
+	si.mu.Unlock()
+
+	// searches hold mu, none of them can still be reading the inputs
+	var errs []error
+	for _, in := range inputs {
+		errs = append(errs, in.drop())
+		if err := os.Remove(filepath.Join(si.dir, in.info.File)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (si *SegmentedIndex) compactor() {
+	defer si.wg.Done()
+	for {
+		// a signal still queued at Close must not start a compaction
+		select {
+		case <-si.done:
+			return
+		default:
+		}
+		select {
+		case <-si.done:
+			return
+		case <-si.compactCh:
+			if err := si.Compact(); err != nil && !errors.Is(err, errIndexClosed) {
+				si.mu.Lock()
+				si.bgErr = err
+				si.mu.Unlock()
+			}
+		}
+	}
+}
+
+// Close stops the compactor, closes the segment sub-indexes and syncs the WAL, unflushed vectors are
+// replayed on the next open. It reports the last background compaction error, if any
+func (si *SegmentedIndex) Close() error {
+	si.mu.Lock()
+	if si.closed {
+		si.mu.Unlock()
+		return nil
+	}
+	si.closed = true
+	si.mu.Unlock()
+	close(si.done)
+	si.wg.Wait()
+	return errors.Join(si.bgErr, closeSegments(si.sealed), si.wal.Close(), si.lock.Unlock())
+}
+
+var _ VectorIndex = (*SegmentedIndex)(nil)
+var _ MetadataIndex = (*SegmentedIndex)(nil)
+var _ OptionSearcher = (*SegmentedIndex)(nil)
+var _ RangeIndex = (*SegmentedIndex)(nil)
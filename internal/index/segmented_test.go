@@ -0,0 +1,339 @@
+package index
+
+import (
+	"VectorDatabase/internal/store"
+	"VectorDatabase/internal/types"
+	v "VectorDatabase/internal/vector"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Helper to open a segmented index with a tiny flush threshold so tests cross segment boundaries
+func setupSegmented(t *testing.T, dir string) *SegmentedIndex {
+	cfg, _ := NewIndexConfig(types.LinearIndex, types.Testmodel, types.Text, types.Cosine, 2)
+	si, err := OpenSegmentedIndex(dir, cfg, SegmentedOptions{FlushThreshold: 3, CompactionThreshold: 100})
+	if err != nil {
+		t.Fatalf("failed to open segmented index: %v", err)
+	}
+	return si
+}
+
+func vec2(x, y float32) *v.Vector {
+	vec, _ := v.NewVector([]float32{x, y}, 2)
+	return vec
+}
+
+// Invariant: the segmented index answers exactly like a single linear index with the same history.
+func TestSegmentedIndex_MatchesLinear(t *testing.T) {
+	si := setupSegmented(t, t.TempDir())
+	defer si.Close()
+	li := setupIndex(t, 2)
+	for i := 0; i < 10; i++ {
+		id, vec := fmt.Sprintf("vec-%d", i), vec2(float32(10-i), float32(i))
+		si.Add(id, vec)
+		li.Add(id, vec)
+	}
+	for _, id := range []string{"vec-0", "vec-4", "vec-9"} {
+		if err := si.Delete(id); err != nil {
+			t.Fatalf("delete %s failed: %v", id, err)
+		}
+		li.Delete(id)
+	}
+	// re-add a vector deleted from a sealed segment
+	si.Add("vec-0", vec2(10, 0))
+	li.Add("vec-0", vec2(10, 0))
+
+	if si.SegmentCount() < 2 {
+		t.Fatalf("expected several sealed segments, got %d", si.SegmentCount())
+	}
+	if si.Size() != li.Size() {
+		t.Errorf("size mismatch: segmented %d, linear %d", si.Size(), li.Size())
+	}
+	query := vec2(1, 0)
+	want, _ := li.Search(query, 5)
+	got, err := si.Search(query, 5)
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	for i := range want {
+		if got[i].ID() != want[i].ID() {
+			t.Errorf("position %d: expected %s, got %s", i, want[i].ID(), got[i].ID())
+		}
+	}
+	if _, ok := si.Get("vec-4"); ok {
+		t.Error("deleted vector still visible")
+	}
+	if exists, _ := si.Add("vec-1", vec2(1, 1)); !exists {
+		t.Error("expected exists=true for id stored in a sealed segment")
+	}
+}
+
+// Post-condition: flushed segments and unflushed WAL records both survive a reopen.
+func TestSegmentedIndex_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	si := setupSegmented(t, dir)
+	for i := 0; i < 5; i++ {
+		si.AddWithMetadata(fmt.Sprintf("vec-%d", i), vec2(1, float32(i)), Metadata{"n": float64(i)})
+	}
+	si.Delete("vec-1") // tombstone for a sealed vector, still in the WAL
+	if err := si.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	si = setupSegmented(t, dir)
+	defer si.Close()
+	if si.Size() != 4 {
+		t.Errorf("expected 4 vectors after reopen, got %d", si.Size())
+	}
+	if _, ok := si.Get("vec-1"); ok {
+		t.Error("deleted vector came back after reopen")
+	}
+	md, ok := si.Metadata("vec-4")
+	if !ok || md["n"] != 4.0 {
+		t.Errorf("metadata lost after reopen: %v", md)
+	}
+}
+
+// Post-condition: compaction leaves one segment without deleted vectors and the same visible data.
+func TestSegmentedIndex_Compact(t *testing.T) {
+	dir := t.TempDir()
+	si := setupSegmented(t, dir)
+	defer si.Close()
+	for i := 0; i < 9; i++ {
+		si.Add(fmt.Sprintf("vec-%d", i), vec2(1, float32(i)))
+	}
+	si.Delete("vec-2")
+	si.Delete("vec-7")
+	si.Flush()
+
+	if err := si.Compact(); err != nil {
+		t.Fatalf("compact failed: %v", err)
+	}
+	if si.SegmentCount() != 1 {
+		t.Fatalf("expected 1 segment after compaction, got %d", si.SegmentCount())
+	}
+	if si.Size() != 7 {
+		t.Errorf("expected 7 vectors, got %d", si.Size())
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "seg-*.vseg"))
+	if len(files) != 1 {
+		t.Errorf("expected old segment files removed, found %v", files)
+	}
+	if si.sealed[0].info.Entries != 7 || si.sealed[0].info.Tombstones != 0 {
+		t.Errorf("deleted vectors not purged: %+v", si.sealed[0].info)
+	}
+}
+
+// Post-condition: Checkpoint hands out a flushed state whose segment files stay in place while it runs.
+func TestSegmentedIndex_Checkpoint(t *testing.T) {
+	dir := t.TempDir()
+	si := setupSegmented(t, dir)
+	defer si.Close()
+	for i := 0; i < 7; i++ {
+		si.Add(fmt.Sprintf("vec-%d", i), vec2(1, float32(i)))
+	}
+	err := si.Checkpoint(func(got string, m store.Manifest) error {
+		if got != dir {
+			t.Errorf("expected dir %s, got %s", dir, got)
+		}
+		entries := 0
+		for _, seg := range m.Segments {
+			if _, err := os.Stat(filepath.Join(dir, seg.File)); err != nil {
+				t.Errorf("segment %s missing: %v", seg.File, err)
+			}
+			entries += seg.Entries
+		}
+		if entries != 7 || m.FlushedLSN != 7 {
+			t.Errorf("expected 7 flushed entries up to lsn 7, got %d up to %d", entries, m.FlushedLSN)
+		}
+		// writes go on during the checkpoint
+		if _, err := si.Add("vec-7", vec2(1, 7)); err != nil {
+			t.Errorf("add during checkpoint failed: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("checkpoint failed: %v", err)
+	}
+	if si.Size() != 8 {
+		t.Errorf("expected 8 vectors, got %d", si.Size())
+	}
+}
+
+// Post-condition: crossing the compaction threshold merges segments in the background.
+func TestSegmentedIndex_BackgroundCompaction(t *testing.T) {
+	cfg, _ := NewIndexConfig(types.LinearIndex, types.Testmodel, types.Text, types.Cosine, 2)
+	si, err := OpenSegmentedIndex(t.TempDir(), cfg, SegmentedOptions{FlushThreshold: 1, CompactionThreshold: 2})
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	for i := 0; i < 6; i++ {
+		si.Add(fmt.Sprintf("vec-%d", i), vec2(1, float32(i)))
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for si.SegmentCount() >= 6 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := si.Close(); err != nil {
+		t.Fatalf("background compaction failed: %v", err)
+	}
+	if si.SegmentCount() >= 6 {
+		t.Errorf("expected compaction to merge segments, still %d", si.SegmentCount())
+	}
+	if si.Size() != 6 {
+		t.Errorf("expected 6 vectors, got %d", si.Size())
+	}
+}
+
+// Contract: a collection can only be reopened with the config it was created with.
+func TestSegmentedIndex_ConfigMismatch(t *testing.T) {
+	dir := t.TempDir()
+	si := setupSegmented(t, dir)
+	si.Close()
+
+	other, _ := NewIndexConfig(types.LinearIndex, types.Testmodel, types.Text, types.Cosine, 3)
+	if _, err := OpenSegmentedIndex(dir, other, SegmentedOptions{}); err == nil {
+		t.Error("expected config mismatch error")
+	}
+	stored, err := LoadSegmentedConfig(dir)
+	if err != nil || stored.Dimension() != 2 {
+		t.Errorf("expected stored dimension 2, got %v, err %v", stored.Dimension(), err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "MANIFEST")); err != nil {
+		t.Errorf("manifest missing: %v", err)
+	}
+}
+
+// Contract: ids the on-disk formats can't hold are rejected before anything is written, the collection
+// still reopens.
+func TestSegmentedIndex_LongID(t *testing.T) {
+	dir := t.TempDir()
+	si := setupSegmented(t, dir)
+	long := strings.Repeat("x", store.MaxIDLen+1)
+	if _, err := si.Add(long, vec2(1, 0)); !errors.Is(err, store.ErrIDTooLong) {
+		t.Errorf("expected ErrIDTooLong, got %v", err)
+	}
+	if _, err := si.Add(long[:store.MaxIDLen], vec2(1, 0)); err != nil {
+		t.Errorf("expected the longest id to fit, got %v", err)
+	}
+	si.Close()
+	si = setupSegmented(t, dir)
+	defer si.Close()
+	if si.Size() != 1 {
+		t.Errorf("expected 1 vector after reopen, got %d", si.Size())
+	}
+}
+
+// Post-condition: DiskANN segments work with the default factory, each keeps one directory that
+// compaction removes with its segment, and the collection reopens.
+func TestSegmentedIndex_DiskANNSegments(t *testing.T) {
+	dir := t.TempDir()
+	cfg, _ := NewIndexConfig(types.DiskANNIndex, types.Testmodel, types.Text, types.Cosine, 2)
+	open := func() *SegmentedIndex {
+		si, err := OpenSegmentedIndex(dir, cfg, SegmentedOptions{FlushThreshold: 3, CompactionThreshold: 100})
+		if err != nil {
+			t.Fatalf("open failed: %v", err)
+		}
+		return si
+	}
+	indexDirs := func() int {
+		matches, _ := filepath.Glob(filepath.Join(dir, "seg-*.index"))
+		return len(matches)
+	}
+	si := open()
+	for i := 0; i < 9; i++ {
+		if _, err := si.Add(fmt.Sprintf("vec-%d", i), vec2(1, float32(i))); err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+	}
+	if si.SegmentCount() != 3 || indexDirs() != 3 {
+		t.Fatalf("expected 3 segments with a directory each, got %d and %d", si.SegmentCount(), indexDirs())
+	}
+	si.Delete("vec-0")
+	si.Flush()
+	if err := si.Compact(); err != nil {
+		t.Fatalf("compact failed: %v", err)
+	}
+	if indexDirs() != 1 {
+		t.Errorf("expected the compacted segments' directories removed, %d left", indexDirs())
+	}
+	if err := si.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	for range 2 {
+		si = open()
+		if si.Size() != 8 || indexDirs() != 1 {
+			t.Errorf("expected 8 vectors in 1 directory after reopen, got %d in %d", si.Size(), indexDirs())
+		}
+		if res, err := si.Search(vec2(1, 8), 1); err != nil || len(res) != 1 || res[0].ID() != "vec-8" {
+			t.Errorf("unexpected search result %v (err %v)", res, err)
+		}
+		si.Close()
+	}
+}
+
+// Contract: a collection is opened by one owner at a time, a second open fails fast with store.ErrLocked
+// Contract: a collection of a model registered at runtime reopens in a process whose registry never
+// saw the model, with the model as it was registered.
+func TestSegmentedIndex_RuntimeModel(t *testing.T) {
+	model := types.ModelInfo{Name: "runtime-bow-2", Version: "1", Dimension: 2, DataType: types.Text, Metric: types.Cosine}
+	saved := types.DefaultModels
+	t.Cleanup(func() { types.DefaultModels = saved })
+	types.DefaultModels = types.NewModelRegistry()
+	if err := types.RegisterModel(model); err != nil {
+		t.Fatalf("failed to register model: %v", err)
+	}
+	cfg, err := NewIndexConfig(types.LinearIndex, model.Name, types.Text, types.Cosine, 2)
+	if err != nil {
+		t.Fatalf("NewIndexConfig failed: %v", err)
+	}
+	dir := t.TempDir()
+	si, err := OpenSegmentedIndex(dir, cfg, SegmentedOptions{})
+	if err != nil {
+		t.Fatalf("failed to open segmented index: %v", err)
+	}
+	si.Add("a", vec2(1, 0))
+	if err := si.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	types.DefaultModels = types.NewModelRegistry()
+	loaded, err := LoadSegmentedConfig(dir)
+	if err != nil {
+		t.Fatalf("config failed to load without the model registered: %v", err)
+	}
+	if loaded != cfg || loaded.Model() != model {
+		t.Errorf("expected %+v, got %+v", cfg, loaded)
+	}
+	si, err = OpenSegmentedIndex(dir, loaded, SegmentedOptions{})
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer si.Close()
+	if _, ok := si.Get("a"); !ok {
+		t.Error("vector lost across the reopen")
+	}
+}
+
+// until the first one is closed.
+func TestSegmentedIndex_Lock(t *testing.T) {
+	dir := t.TempDir()
+	si := setupSegmented(t, dir)
+	cfg, _ := NewIndexConfig(types.LinearIndex, types.Testmodel, types.Text, types.Cosine, 2)
+	if _, err := OpenSegmentedIndex(dir, cfg, SegmentedOptions{}); !errors.Is(err, store.ErrLocked) {
+		t.Fatalf("expected store.ErrLocked, got %v", err)
+	}
+	si.Close()
+	other, _ := NewIndexConfig(types.LinearIndex, types.Testmodel, types.Text, types.Cosine, 3)
+	if _, err := OpenSegmentedIndex(dir, other, SegmentedOptions{}); err == nil || errors.Is(err, store.ErrLocked) {
+		t.Fatalf("expected a config mismatch, got %v", err)
+	}
+	// the failed open released the lock
+	si = setupSegmented(t, dir)
+	si.Close()
+}
@@ -0,0 +1,100 @@
+package index
+
+import (
+	"math/bits"
+	"sync"
+)
+
+// RepairableIndex is an approximate index whose deletes are soft: a deleted vector is hidden from
+// results right away but stays in the index structure (a graph node keeps routing searches) until
+// Repair reconnects the structure around it or rebuilds it
+type RepairableIndex interface {
+	VectorIndex
+	// TombstoneRatio is the share of internal ids that are deleted but not repaired yet
+	TombstoneRatio() float64
+	Repair() error
+}
+
+// tombstones is a bitset of deleted internal ids
+type tombstones struct {
+	words []uint64
+	count int
+}
+
+func tombstonesFrom(words []uint64) tombstones {
+	t := tombstones{words: words}
+	for _, w := range words {
+		t.count += bits.OnesCount64(w)
+	}
+	return t
+}
+
+// set marks id deleted, false if it already was
+func (t *tombstones) set(id int) bool {
+	word, bit := id/64, uint64(1)<<(id%64)
+	for len(t.words) <= word {
+		t.words = append(t.words, 0)
+	}
+	if t.words[word]&bit != 0 {
+		return false
+	}
+	t.words[word] |= bit
+	t.count++
+	return true
+}
+
+func (t *tombstones) has(id int) bool {
+	word := id / 64
+	return word < len(t.words) && t.words[word]&(uint64(1)<<(id%64)) != 0
+}
+
+func (t *tombstones) len() int {
+	return t.count
+}
+
+// repairWorker runs the repair of an index in the background each time it is triggered,
+// triggers that arrive while a repair runs collapse into one more run
+type repairWorker struct {
+	ch   chan struct{}
+	done chan struct{}
+	wg   sync.WaitGroup
+	mu   sync.Mutex
+	err  error
+}
+
+func startRepairWorker(repair func() error) *repairWorker {
+	w := &repairWorker{ch: make(chan struct{}, 1), done: make(chan struct{})}
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		for {
+			select {
+			case <-w.done:
+				return
+			case <-w.ch:
+				if err := repair(); err != nil {
+					w.mu.Lock()
+					w.err = err
+					w.mu.Unlock()
+				}
+			}
+		}
+	}()
+	return w
+}
+
+func (w *repairWorker) trigger() {
+	select {
+	case w.ch <- struct{}{}:
+	default:
+	}
+}
+
+// stop waits for a running repair and returns the last background error
+func (w *repairWorker) stop() error {
+	close(w.done)
+	w.wg.Wait()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
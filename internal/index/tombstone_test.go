@@ -0,0 +1,45 @@
+package index
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// Invariant: the bitset counts each deleted id once and round trips through its words.
+func TestTombstones(t *testing.T) {
+	var ts tombstones
+	for _, id := range []int{0, 63, 64, 200} {
+		if !ts.set(id) {
+			t.Errorf("expected %d to be newly set", id)
+		}
+	}
+	if ts.set(64) {
+		t.Error("expected setting 64 twice to report false")
+	}
+	if ts.len() != 4 || !ts.has(200) || ts.has(1) || ts.has(10_000) {
+		t.Errorf("unexpected bitset state: %v (count %d)", ts.words, ts.len())
+	}
+	loaded := tombstonesFrom(ts.words)
+	if loaded.len() != 4 || !loaded.has(63) {
+		t.Error("bitset changed after reload")
+	}
+}
+
+// Contract: triggers run the repair in the background and stop reports its last error.
+func TestRepairWorker(t *testing.T) {
+	runs := make(chan struct{}, 10)
+	w := startRepairWorker(func() error {
+		runs <- struct{}{}
+		return errors.New("repair failed")
+	})
+	w.trigger()
+	select {
+	case <-runs:
+	case <-time.After(2 * time.Second):
+		t.Fatal("repair never ran")
+	}
+	if err := w.stop(); err == nil {
+		t.Error("expected the background error from stop")
+	}
+}
@@ -0,0 +1,46 @@
+package index
+
+import (
+	"container/heap"
+	"slices"
+)
+
+// topK keeps the k best results seen so far in a min heap (worst kept result on top),
+// so a scan needs O(k) memory instead of one result per stored vector
+type topK struct {
+	k       int
+	results []SearchResult
+}
+
+func newTopK(k int) *topK {
+	return &topK{k: k, results: make([]SearchResult, 0, k)}
+}
+
+func (t *topK) Len() int           { return len(t.results) }
+func (t *topK) Less(i, j int) bool { return compareResults(t.results[i], t.results[j]) > 0 }
+func (t *topK) Swap(i, j int)      { t.results[i], t.results[j] = t.results[j], t.results[i] }
+func (t *topK) Push(x any)         { t.results = append(t.results, x.(SearchResult)) }
+func (t *topK) Pop() any {
+	last := t.results[len(t.results)-1]
+	t.results = t.results[:len(t.results)-1]
+	return last
+}
+
+// offer keeps r if it ranks among the best k
+func (t *topK) offer(r SearchResult) {
+	if len(t.results) < t.k {
+		heap.Push(t, r)
+		return
+	}
+	if compareResults(r, t.results[0]) < 0 {
+		t.results[0] = r
+		heap.Fix(t, 0)
+	}
+}
+
+// sorted returns the kept results in search order
+func (t *topK) sorted() []SearchResult {
+	result := slices.Clone(t.results)
+	slices.SortFunc(result, compareResults)
+	return result
+}
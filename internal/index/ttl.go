@@ -0,0 +1,362 @@
+package index
+
+import (
+	v "VectorDatabase/internal/vector"
+	"container/heap"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ExpiresAtField is the metadata field a TTLIndex stores expiry times in (RFC 3339 string),
+// so they survive restarts of a persistent inner index
+const ExpiresAtField = "_expires_at"
+
+const defaultSweepInterval = time.Minute
+
+// TTLOptions configure a TTLIndex, zero values pick the defaults
+type TTLOptions struct {
+	// DefaultTTL applies to every Add without its own TTL, zero keeps vectors forever
+	DefaultTTL time.Duration
+	// SweepInterval is how often expired vectors are deleted from the inner index, default 1m
+	SweepInterval time.Duration
+	// Now is the clock, default time.Now
+	Now func() time.Time
+}
+
+// TTLIndex makes vectors of an inner index expire: an expired vector is hidden from Get, Search and
+// Metadata at once and deleted from the inner index (so from its WAL / files too) by a background sweeper
+type TTLIndex struct {
+	mu    sync.RWMutex
+	inner VectorIndex
+	opts  TTLOptions
+	// every vector that expires is in expires and queue, soonest expiry on top of queue
+	expires map[string]*expiry
+	queue   expiryQueue
+
+	done chan struct{}
+	wg   sync.WaitGroup
+	// last background sweep error
+	bgErr error
+}
+
+// NewTTLIndex wraps inner, expiry times already stored in its metadata are picked up again
+func NewTTLIndex(inner VectorIndex, opts TTLOptions) (*TTLIndex, error) {
+	if inner == nil {
+		return nil, errors.New("inner index is nil")
+	}
+	if opts.DefaultTTL < 0 {
+		return nil, errors.New("negative ttl")
+	}
+	if opts.SweepInterval <= 0 {
+		opts.SweepInterval = defaultSweepInterval
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	t := &TTLIndex{inner: inner, opts: opts, expires: make(map[string]*expiry), done: make(chan struct{})}
+	ranger, okRange := inner.(RangeIndex)
+	source, okMeta := inner.(MetadataSource)
+	if okRange && okMeta {
+		// metadata is read outside Range, fn can't call back into the index holding its lock
+		var ids []string
+		ranger.Range(func(id string, _ *v.Vector) bool {
+			ids = append(ids, id)
+			return true
+		})
+		for _, id := range ids {
+			md, _ := source.Metadata(id)
+			if at, ok := parseExpiry(md); ok {
+				t.setExpiry(id, at)
+			}
+		}
+	}
+	t.wg.Add(1)
+	go t.sweeper()
+	return t, nil
+}
+
+func parseExpiry(md Metadata) (time.Time, bool) {
+	s, ok := md[ExpiresAtField].(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	at, err := time.Parse(time.RFC3339Nano, s)
+	return at, err == nil
+}
+
+// expiry is the entry of a vector in the expiry queue
+type expiry struct {
+	id    string
+	at    time.Time
+	index int
+}
+
+// expiryQueue is a min heap of expiry times, so the expired vectors are found without a scan
+type expiryQueue []*expiry
+
+func (q expiryQueue) Len() int           { return len(q) }
+func (q expiryQueue) Less(i, j int) bool { return q[i].at.Before(q[j].at) }
+func (q expiryQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+func (q *expiryQueue) Push(x any) {
+	e := x.(*expiry)
+	e.index = len(*q)
+	*q = append(*q, e)
+}
+func (q *expiryQueue) Pop() any {
+	old := *q
+	last := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return last
+}
+
+func (t *TTLIndex) setExpiry(id string, at time.Time) {
+	if e, ok := t.expires[id]; ok {
+		e.at = at
+		heap.Fix(&t.queue, e.index)
+		return
+	}
+	e := &expiry{id: id, at: at}
+	heap.Push(&t.queue, e)
+	t.expires[id] = e
+}
+
+func (t *TTLIndex) clearExpiry(id string) {
+	if e, ok := t.expires[id]; ok {
+		heap.Remove(&t.queue, e.index)
+		delete(t.expires, id)
+	}
+}
+
+func (t *TTLIndex) expired(id string, now time.Time) bool {
+	e, ok := t.expires[id]
+	return ok && !now.Before(e.at)
+}
+
+func (t *TTLIndex) Add(id string, vec *v.Vector) (bool, error) {
+	return t.AddWithTTL(id, vec, nil, t.opts.DefaultTTL)
+}
+
+func (t *TTLIndex) AddWithMetadata(id string, vec *v.Vector, md Metadata) (bool, error) {
+	return t.AddWithTTL(id, vec, md, t.opts.DefaultTTL)
+}
+
+// AddWithTTL adds a vector that expires ttl from now, zero ttl keeps it forever.
+// Like Add it returns true and changes nothing when id already exists; an expired id that
+// wasn't swept yet is deleted first so it can be added again
+func (t *TTLIndex) AddWithTTL(id string, vec *v.Vector, md Metadata, ttl time.Duration) (bool, error) {
+	if ttl < 0 {
+		return false, errors.New("negative ttl")
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.opts.Now()
+	if t.expired(id, now) {
+		if err := t.inner.Delete(id); err != nil {
+			return false, err
+		}
+		t.clearExpiry(id)
+	}
+	mi, stores := t.inner.(MetadataIndex)
+	if !stores && len(md) > 0 {
+		return false, errors.New("inner index doesn't store metadata")
+	}
+	var at time.Time
+	if ttl > 0 {
+		at = now.Add(ttl)
+		md = md.clone()
+		if md == nil {
+			md = Metadata{}
+		}
+		md[ExpiresAtField] = at.UTC().Format(time.RFC3339Nano)
+	}
+	var exists bool
+	var err error
+	if stores {
+		exists, err = mi.AddWithMetadata(id, vec, md)
+	} else {
+		// expiry stays in memory only
+		exists, err = t.inner.Add(id, vec)
+	}
+	if err != nil || exists {
+		return exists, err
+	}
+	if ttl > 0 {
+		t.setExpiry(id, at)
+	}
+	return false, nil
+}
+
+func (t *TTLIndex) Delete(id string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.inner.Delete(id); err != nil {
+		return err
+	}
+	t.clearExpiry(id)
+	return nil
+}
+
+func (t *TTLIndex) Get(id string) (*v.Vector, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.expired(id, t.opts.Now()) {
+		return nil, false
+	}
+	return t.inner.Get(id)
+}
+
+func (t *TTLIndex) Metadata(id string) (Metadata, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	source, ok := t.inner.(MetadataSource)
+	if !ok || t.expired(id, t.opts.Now()) {
+		return nil, false
+	}
+	return source.Metadata(id)
+}
+
+// TTL returns how long id has left, false when it doesn't expire (or doesn't exist)
+func (t *TTLIndex) TTL(id string) (time.Duration, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	e, ok := t.expires[id]
+	if !ok {
+		return 0, false
+	}
+	return max(0, e.at.Sub(t.opts.Now())), true
+}
+
+// expiredCount is the number of expired vectors the sweeper hasn't deleted yet. It only visits the
+// expired entries of the queue (and their direct children), not every vector that expires
+func (t *TTLIndex) expiredCount(now time.Time) int {
+	n := 0
+	stack := []int{0}
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if i >= len(t.queue) || now.Before(t.queue[i].at) {
+			continue
+		}
+		n++
+		stack = append(stack, 2*i+1, 2*i+2)
+	}
+	return n
+}
+
+func (t *TTLIndex) Search(query *v.Vector, k int) ([]SearchResult, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	now := t.opts.Now()
+	hidden := t.expiredCount(now)
+	if hidden == 0 {
+		return t.inner.Search(query, k)
+	}
+	// over-fetch by the number of expired vectors still in the inner index
+	results, err := t.inner.Search(query, k+hidden)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]SearchResult, 0, k)
+	for _, r := range results {
+		if len(out) == k {
+			break
+		}
+		if !t.expired(r.ID(), now) {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+// SearchWithOptions needs an inner OptionSearcher, expired vectors are filtered out before the user filter
+func (t *TTLIndex) SearchWithOptions(query *v.Vector, k int, opts SearchOptions) ([]SearchResult, *SearchStats, error) {
+	searcher, ok := t.inner.(OptionSearcher)
+	if !ok {
+		return nil, nil, errors.New("inner index doesn't support search options")
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	now := t.opts.Now()
+	if t.expiredCount(now) > 0 {
+		filter := opts.Filter
+		opts.Filter = func(id string, md Metadata) bool {
+			return !t.expired(id, now) && (filter == nil || filter(id, md))
+		}
+	}
+	return searcher.SearchWithOptions(query, k, opts)
+}
+
+func (t *TTLIndex) Size() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.inner.Size() - t.expiredCount(t.opts.Now())
+}
+
+// Sweep deletes every expired vector from the inner index and returns how many it removed
+func (t *TTLIndex) Sweep() (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.opts.Now()
+	removed := 0
+	var errs []error
+	var failed []*expiry
+	for len(t.queue) > 0 && !now.Before(t.queue[0].at) {
+		e := heap.Pop(&t.queue).(*expiry)
+		if err := t.inner.Delete(e.id); err != nil {
+			if _, ok := t.inner.Get(e.id); ok {
+				errs = append(errs, err)
+				failed = append(failed, e)
+				continue
+			}
+		}
+		delete(t.expires, e.id)
+		removed++
+	}
+	// retried on the next sweep
+	for _, e := range failed {
+		heap.Push(&t.queue, e)
+	}
+	return removed, errors.Join(errs...)
+}
+
+func (t *TTLIndex) sweeper() {
+	defer t.wg.Done()
+	ticker := time.NewTicker(t.opts.SweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-ticker.C:
+			if _, err := t.Sweep(); err != nil {
+				t.mu.Lock()
+				t.bgErr = err
+				t.mu.Unlock()
+			}
+		}
+	}
+}
+
+// Close stops the sweeper and reports its last error, the inner index stays open
+func (t *TTLIndex) Close() error {
+	t.mu.Lock()
+	select {
+	case <-t.done:
+		t.mu.Unlock()
+		return nil
+	default:
+	}
+	close(t.done)
+	t.mu.Unlock()
+	t.wg.Wait()
+	return t.bgErr
+}
+
+var _ MetadataIndex = (*TTLIndex)(nil)
+var _ OptionSearcher = (*TTLIndex)(nil)
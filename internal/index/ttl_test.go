@@ -0,0 +1,218 @@
+package index
+
+import (
+	v "VectorDatabase/internal/vector"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a settable clock for expiry tests
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)}
+}
+
+// Contract: expired vectors vanish from Get, Search and Size immediately, before any sweep.
+func TestTTLIndex_ExpiryHidesVectors(t *testing.T) {
+	clock := newFakeClock()
+	ti, err := NewTTLIndex(setupIndex(t, 2), TTLOptions{DefaultTTL: 24 * time.Hour, SweepInterval: time.Hour, Now: clock.Now})
+	if err != nil {
+		t.Fatalf("failed to create ttl index: %v", err)
+	}
+	defer ti.Close()
+	ti.Add("session", vec2(1, 0))
+	ti.AddWithTTL("short", vec2(1, 0.1), nil, time.Hour)
+	ti.AddWithTTL("forever", vec2(0, 1), nil, 0)
+	if _, err := ti.AddWithTTL("bad", vec2(1, 1), nil, -time.Second); err == nil {
+		t.Error("expected error for a negative ttl")
+	}
+	if left, ok := ti.TTL("short"); !ok || left != time.Hour {
+		t.Errorf("expected 1h left, got %v %v", left, ok)
+	}
+	if _, ok := ti.TTL("forever"); ok {
+		t.Error("expected no ttl for a vector added with zero ttl")
+	}
+
+	clock.Advance(2 * time.Hour)
+	if _, ok := ti.Get("short"); ok {
+		t.Error("expired vector returned by Get")
+	}
+	results, _ := ti.Search(vec2(1, 0), 2)
+	if len(results) != 2 || results[0].ID() != "session" || results[1].ID() != "forever" {
+		t.Errorf("unexpected results after expiry: %v", results)
+	}
+	if ti.Size() != 2 {
+		t.Errorf("expected size 2, got %d", ti.Size())
+	}
+
+	clock.Advance(24 * time.Hour)
+	results, _ = ti.Search(vec2(1, 0), 5)
+	if len(results) != 1 || results[0].ID() != "forever" {
+		t.Errorf("expected only the vector without ttl, got %v", results)
+	}
+	if exists, err := ti.AddWithTTL("short", vec2(1, 0), nil, time.Hour); exists || err != nil {
+		t.Errorf("expected an expired id to be added again, got %v %v", exists, err)
+	}
+}
+
+// Invariant: the sweeper deletes expired vectors through a persistent inner index,
+// and expiry times survive a reopen through the metadata.
+func TestTTLIndex_SweepPersists(t *testing.T) {
+	dir := t.TempDir()
+	clock := newFakeClock()
+	si := setupSegmented(t, dir)
+	ti, _ := NewTTLIndex(si, TTLOptions{SweepInterval: time.Hour, Now: clock.Now})
+	ti.AddWithTTL("a", vec2(1, 0), Metadata{"user": "u1"}, time.Hour)
+	ti.AddWithTTL("b", vec2(0, 1), nil, 3*time.Hour)
+	ti.Add("c", vec2(1, 1))
+	ti.Close()
+	si.Close()
+
+	si = setupSegmented(t, dir)
+	ti, _ = NewTTLIndex(si, TTLOptions{SweepInterval: time.Hour, Now: clock.Now})
+	if left, ok := ti.TTL("b"); !ok || left != 3*time.Hour {
+		t.Fatalf("expiry lost after reopen: %v %v", left, ok)
+	}
+	if md, _ := ti.Metadata("a"); md["user"] != "u1" {
+		t.Errorf("user metadata lost: %v", md)
+	}
+	clock.Advance(2 * time.Hour)
+	if removed, err := ti.Sweep(); removed != 1 || err != nil {
+		t.Fatalf("expected one vector swept, got %d %v", removed, err)
+	}
+	ti.Close()
+	si.Close()
+
+	si = setupSegmented(t, dir)
+	defer si.Close()
+	if _, ok := si.Get("a"); ok || si.Size() != 2 {
+		t.Errorf("swept vector came back after reopen, size %d", si.Size())
+	}
+}
+
+// Contract: the background sweeper runs on its interval and search options skip expired vectors.
+func TestTTLIndex_BackgroundSweep(t *testing.T) {
+	clock := newFakeClock()
+	li := setupIndex(t, 2)
+	ti, _ := NewTTLIndex(li, TTLOptions{DefaultTTL: time.Minute, SweepInterval: 5 * time.Millisecond, Now: clock.Now})
+	defer ti.Close()
+	ti.Add("a", vec2(1, 0))
+	ti.AddWithTTL("b", vec2(1, 0.2), nil, 0)
+	clock.Advance(time.Hour)
+
+	results, _, err := ti.SearchWithOptions(vec2(1, 0), 5, SearchOptions{})
+	if err != nil || len(results) != 1 || results[0].ID() != "b" {
+		t.Errorf("expected only b from option search, got %v %v", results, err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for li.Size() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("background sweeper didn't delete the expired vector")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// rangeGuard fails the test when Metadata is called from inside Range, where an index holds its read
+// lock and a waiting writer would deadlock the second RLock
+type rangeGuard struct {
+	*LinearIndex
+	t       *testing.T
+	inRange bool
+}
+
+func (g *rangeGuard) Range(fn func(id string, vec *v.Vector) bool) {
+	g.inRange = true
+	defer func() { g.inRange = false }()
+	g.LinearIndex.Range(fn)
+}
+
+func (g *rangeGuard) Metadata(id string) (Metadata, bool) {
+	if g.inRange {
+		g.t.Errorf("Metadata(%q) called inside Range", id)
+	}
+	return g.LinearIndex.Metadata(id)
+}
+
+// Post-condition: NewTTLIndex reloads stored expiry times without calling back into the inner index
+// from inside Range.
+func TestTTLIndex_ReloadOutsideRange(t *testing.T) {
+	clock := newFakeClock()
+	inner := &rangeGuard{LinearIndex: setupIndex(t, 2), t: t}
+	at := clock.Now().Add(time.Hour).Format(time.RFC3339Nano)
+	inner.AddWithMetadata("a", vec2(1, 0), Metadata{ExpiresAtField: at})
+	inner.Add("b", vec2(0, 1))
+	ti, err := NewTTLIndex(inner, TTLOptions{SweepInterval: time.Hour, Now: clock.Now})
+	if err != nil {
+		t.Fatalf("failed to create ttl index: %v", err)
+	}
+	defer ti.Close()
+	if left, ok := ti.TTL("a"); !ok || left != time.Hour {
+		t.Errorf("expected 1h left for a, got %v %v", left, ok)
+	}
+}
+
+// Invariant: the expired count Search and Size rely on matches a full scan of the expiry times
+// through adds, deletes, re-adds of expired ids and sweeps.
+func TestTTLIndex_ExpiredCount(t *testing.T) {
+	clock := newFakeClock()
+	ti, _ := NewTTLIndex(setupIndex(t, 2), TTLOptions{SweepInterval: time.Hour, Now: clock.Now})
+	defer ti.Close()
+	for i := 0; i < 50; i++ {
+		ti.AddWithTTL(fmt.Sprintf("v%d", i), vec2(1, float32(i)), nil, time.Duration(i%10+1)*time.Minute)
+	}
+	ti.AddWithTTL("forever", vec2(0, 1), nil, 0)
+	scan := func() int {
+		n := 0
+		for id := range ti.expires {
+			if ti.expired(id, clock.Now()) {
+				n++
+			}
+		}
+		return n
+	}
+	for step := 0; step < 12; step++ {
+		clock.Advance(time.Minute)
+		switch step {
+		case 2:
+			ti.Delete("v0")
+			ti.Delete("v5")
+		case 4:
+			ti.AddWithTTL("v1", vec2(1, 1), nil, 30*time.Minute)
+		case 6:
+			if _, err := ti.Sweep(); err != nil {
+				t.Fatalf("sweep failed: %v", err)
+			}
+		}
+		ti.mu.RLock()
+		got, want := ti.expiredCount(clock.Now()), scan()
+		ti.mu.RUnlock()
+		if got != want {
+			t.Errorf("step %d: expected %d expired, got %d", step, want, got)
+		}
+		if size := ti.Size(); size != ti.inner.Size()-want {
+			t.Errorf("step %d: expected size %d, got %d", step, ti.inner.Size()-want, size)
+		}
+	}
+	if len(ti.queue) != len(ti.expires) {
+		t.Errorf("queue holds %d entries, %d vectors expire", len(ti.queue), len(ti.expires))
+	}
+}
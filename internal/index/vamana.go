@@ -0,0 +1,729 @@
+package index
+
+import (
+	"VectorDatabase/internal/store"
+	v "VectorDatabase/internal/vector"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// VamanaOptions tune a VamanaIndex, zero values pick the defaults
+type VamanaOptions struct {
+	// MaxDegree (R) bounds the neighbor list of every node, default 32. Fixed once the graph file exists
+	MaxDegree int
+	// BuildListSize (L) is the candidate list size used to find neighbors on insert, default 64
+	BuildListSize int
+	// SearchListSize is the candidate list size of a query, raised to k when smaller, default 64
+	SearchListSize int
+	// Alpha > 1 keeps longer edges during pruning so the graph stays navigable, default 1.2
+	Alpha float64
+	// BeamWidth is how many node blocks a search round reads from disk, default 4
+	BeamWidth int
+	// PQSubspaces is the number of one byte PQ codes per vector (largest divisor of the dimension
+	// not above it), default 8
+	PQSubspaces int
+	// TrainSize is how many vectors are kept in memory before the product quantizer is trained, default 256
+	TrainSize int
+	// RepairThreshold is the tombstone ratio that wakes the background repair, default 0.1
+	RepairThreshold float64
+	// RebuildThreshold is the share of deleted nodes (repaired or not) above which a repair rebuilds
+	// the graph into fresh files instead of reconnecting it, default 0.5
+	RebuildThreshold float64
+}
+
+func (o VamanaOptions) withDefaults(dim int) VamanaOptions {
+	if o.MaxDegree <= 0 {
+		o.MaxDegree = 32
+	}
+	if o.BuildListSize <= 0 {
+		o.BuildListSize = 64
+	}
+	if o.SearchListSize <= 0 {
+		o.SearchListSize = 64
+	}
+	if o.Alpha < 1 {
+		o.Alpha = 1.2
+	}
+	if o.BeamWidth <= 0 {
+		o.BeamWidth = 4
+	}
+	if o.PQSubspaces <= 0 {
+		o.PQSubspaces = 8
+	}
+	o.PQSubspaces = pqSubspaces(dim, o.PQSubspaces)
+	if o.TrainSize <= 0 {
+		o.TrainSize = 256
+	}
+	if o.RepairThreshold <= 0 {
+		o.RepairThreshold = 0.1
+	}
+	if o.RebuildThreshold <= 0 {
+		o.RebuildThreshold = 0.5
+	}
+	return o
+}
+
+const (
+	vamanaGraphFile = "graph.vgrf"
+	vamanaIDFile    = "graph.ids"
+	vamanaPQFile    = "pq.bin"
+	vamanaMetaFile  = "vamana.json"
+	pqSeed          = 42
+)
+
+type vamanaMeta struct {
+	Config    IndexConfig `json:"config"`
+	MaxDegree int         `json:"max_degree"`
+	Entry     int         `json:"entry"`
+	// deleted nodes already disconnected from the graph
+	Purged []uint64 `json:"purged,omitempty"`
+}
+
+// VamanaIndex is a DiskANN style graph index for SSD resident collections: the graph and the full
+// precision vectors live on disk in one block per node, only the compressed PQ codes (and the id map)
+// stay in memory. A query walks the graph with a beam search ordered by PQ distance, reads the blocks of
+// the nodes it expands from disk and ranks them by their exact similarity.
+// Deleted vectors are tombstones: hidden from results but kept in the graph as waypoints until a
+// (background) Repair reconnects their neighbors around them
+type VamanaIndex struct {
+	mu     sync.RWMutex
+	dir    string
+	config IndexConfig
+	opts   VamanaOptions
+	graph  *store.GraphFile
+	ids    *store.IDLog
+	// nil until TrainSize vectors were added, raw holds their vectors until then
+	pq    *productQuantizer
+	raw   [][]float32
+	codes [][]byte
+	entry int
+	// every deleted slot, and the ones no live node links to anymore
+	deleted tombstones
+	purged  tombstones
+	closed  bool
+	repair  *repairWorker
+}
+
+// OpenVamanaIndex opens (or creates) the Vamana index stored in dir
+func OpenVamanaIndex(dir string, cfg IndexConfig, opts VamanaOptions) (*VamanaIndex, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to initialize vamana index: %w", err)
+	}
+	if err := recoverRebuild(dir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	vi := &VamanaIndex{dir: dir, config: cfg, opts: opts.withDefaults(cfg.Dimension())}
+	if err := vi.open(); err != nil {
+		return nil, err
+	}
+	vi.repair = startRepairWorker(vi.Repair)
+	return vi, nil
+}
+
+// open (re)loads the index files of vi.dir
+func (vi *VamanaIndex) open() error {
+	vi.entry, vi.pq, vi.raw, vi.codes = -1, nil, nil, nil
+	vi.deleted, vi.purged = tombstones{}, tombstones{}
+	data, err := os.ReadFile(filepath.Join(vi.dir, vamanaMetaFile))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		var meta vamanaMeta
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return fmt.Errorf("invalid vamana metadata: %w", err)
+		}
+		if meta.Config != vi.config {
+			return errors.New("index config doesn't match stored collection config")
+		}
+		vi.opts.MaxDegree = meta.MaxDegree
+		vi.entry = meta.Entry
+		vi.purged = tombstonesFrom(meta.Purged)
+	}
+	if vi.graph, err = store.OpenGraphFile(filepath.Join(vi.dir, vamanaGraphFile), vi.config.Dimension(), vi.opts.MaxDegree); err != nil {
+		return err
+	}
+	if vi.ids, err = store.OpenIDLog(filepath.Join(vi.dir, vamanaIDFile)); err != nil {
+		vi.graph.Close()
+		return err
+	}
+	if err := vi.load(); err != nil {
+		vi.graph.Close()
+		vi.ids.Close()
+		return err
+	}
+	return nil
+}
+
+// load rebuilds the in-memory state (PQ codes, entry point) from the files
+func (vi *VamanaIndex) load() error {
+	slots := vi.ids.Slots()
+	if slots > vi.graph.Nodes() {
+		return fmt.Errorf("%w: id mapping references %d nodes, graph holds %d", store.ErrCorrupt, slots, vi.graph.Nodes())
+	}
+	if vi.entry < 0 || vi.entry >= slots {
+		vi.entry = -1
+		if slots > 0 {
+			vi.entry = 0
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(vi.dir, vamanaPQFile))
+	if err == nil {
+		vi.pq = &productQuantizer{}
+		if err := vi.pq.UnmarshalBinary(data); err != nil {
+			return err
+		}
+		if vi.pq.dim != vi.config.Dimension() {
+			return errors.New("product quantizer dimension mismatch")
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	vi.codes = make([][]byte, 0, slots)
+	for slot := 0; slot < slots; slot++ {
+		values, _, err := vi.graph.ReadNode(slot)
+		if err != nil {
+			return err
+		}
+		vi.remember(values)
+		if _, ok := vi.ids.ID(slot); !ok {
+			vi.deleted.set(slot)
+		}
+	}
+	return vi.maybeTrain()
+}
+
+// remember keeps what navigation needs in memory for a new slot
+func (vi *VamanaIndex) remember(values []float32) {
+	if vi.pq != nil {
+		vi.codes = append(vi.codes, vi.pq.encode(values))
+		return
+	}
+	vi.codes = append(vi.codes, nil)
+	vi.raw = append(vi.raw, values)
+}
+
+// maybeTrain trains the product quantizer once enough vectors arrived and drops the raw vectors
+func (vi *VamanaIndex) maybeTrain() error {
+	if vi.pq != nil || len(vi.raw) < vi.opts.TrainSize {
+		return nil
+	}
+	pq, err := trainPQ(vi.raw, vi.config.Dimension(), vi.opts.PQSubspaces, pqSeed)
+	if err != nil {
+		return err
+	}
+	data, err := pq.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if err := store.WriteFileAtomic(filepath.Join(vi.dir, vamanaPQFile), data); err != nil {
+		return err
+	}
+	vi.pq = pq
+	for slot, values := range vi.raw {
+		vi.codes[slot] = pq.encode(values)
+	}
+	vi.raw = nil
+	return nil
+}
+
+// vamanaNode is a node read from disk
+type vamanaNode struct {
+	slot      int
+	dist      float64
+	values    []float32
+	neighbors []uint32
+}
+
+// cosine distance of normalized vectors
+func vamanaDistance(a, b []float32) float64 {
+	return 1 - v.DotProduct(a, b)
+}
+
+type vamanaCandidate struct {
+	slot     int
+	dist     float64
+	expanded bool
+}
+
+// approximator returns the in-memory distance estimate used to order the beam
+func (vi *VamanaIndex) approximator(query []float32) func(slot int) float64 {
+	if vi.pq == nil {
+		return func(slot int) float64 { return vamanaDistance(query, vi.raw[slot]) }
+	}
+	table := vi.pq.dotTable(query)
+	return func(slot int) float64 { return 1 - vi.pq.approxDot(table, vi.codes[slot]) }
+}
+
+// beamSearch walks the graph from the entry point keeping listSize candidates ordered by approximate
+// distance, each round reads the blocks of the BeamWidth closest unexpanded candidates from disk.
+// It returns every expanded node with its exact distance, closest first
+func (vi *VamanaIndex) beamSearch(query []float32, listSize int, stats *SearchStats) ([]vamanaNode, error) {
+	if vi.entry < 0 {
+		return nil, nil
+	}
+	approx := vi.approximator(query)
+	seen := map[int]bool{vi.entry: true}
+	frontier := []vamanaCandidate{{slot: vi.entry, dist: approx(vi.entry)}}
+	stats.distance()
+	var expanded []vamanaNode
+	for {
+		round := make([]int, 0, vi.opts.BeamWidth)
+		for i := range frontier {
+			if len(round) == vi.opts.BeamWidth {
+				break
+			}
+			if !frontier[i].expanded {
+				frontier[i].expanded = true
+				round = append(round, frontier[i].slot)
+			}
+		}
+		if len(round) == 0 {
+			break
+		}
+		for _, slot := range round {
+			values, neighbors, err := vi.graph.ReadNode(slot)
+			if err != nil {
+				return nil, err
+			}
+			stats.visit()
+			stats.distance()
+			expanded = append(expanded, vamanaNode{slot: slot, dist: vamanaDistance(query, values), values: values, neighbors: neighbors})
+			for _, nb := range neighbors {
+				n := int(nb)
+				if seen[n] || n >= len(vi.codes) {
+					continue
+				}
+				seen[n] = true
+				frontier = append(frontier, vamanaCandidate{slot: n, dist: approx(n)})
+				stats.distance()
+			}
+		}
+		slices.SortFunc(frontier, func(a, b vamanaCandidate) int { return cmp.Compare(a.dist, b.dist) })
+		if len(frontier) > listSize {
+			frontier = frontier[:listSize]
+		}
+	}
+	slices.SortFunc(expanded, func(a, b vamanaNode) int { return cmp.Compare(a.dist, b.dist) })
+	return expanded, nil
+}
+
+// robustPrune picks at most MaxDegree neighbors for a node from candidates (sorted by distance to it),
+// a candidate is skipped when an already picked neighbor is alpha times closer to it than the node is
+func (vi *VamanaIndex) robustPrune(slot int, candidates []vamanaNode) []uint32 {
+	picked := make([]vamanaNode, 0, vi.opts.MaxDegree)
+	for _, c := range candidates {
+		if len(picked) == vi.opts.MaxDegree {
+			break
+		}
+		if c.slot == slot {
+			continue
+		}
+		keep := true
+		for _, p := range picked {
+			if p.slot == c.slot || vi.opts.Alpha*vamanaDistance(p.values, c.values) <= c.dist {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			picked = append(picked, c)
+		}
+	}
+	neighbors := make([]uint32, len(picked))
+	for i, p := range picked {
+		neighbors[i] = uint32(p.slot)
+	}
+	return neighbors
+}
+
+// readNodes loads the blocks of slots with their distance to values, closest first
+func (vi *VamanaIndex) readNodes(values []float32, slots []uint32) ([]vamanaNode, error) {
+	nodes := make([]vamanaNode, 0, len(slots))
+	for _, s := range slots {
+		vals, nbrs, err := vi.graph.ReadNode(int(s))
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, vamanaNode{slot: int(s), dist: vamanaDistance(values, vals), values: vals, neighbors: nbrs})
+	}
+	slices.SortFunc(nodes, func(a, b vamanaNode) int { return cmp.Compare(a.dist, b.dist) })
+	return nodes, nil
+}
+
+func (vi *VamanaIndex) Dimension() int {
+	return vi.config.Dimension()
+}
+
+// Returns true if vector already exist else error
+func (vi *VamanaIndex) Add(id string, vec *v.Vector) (bool, error) {
+	if err := store.CheckID(id); err != nil {
+		return false, err
+	}
+	if vec == nil {
+		return false, errors.New("empty vector")
+	}
+	if vi.config.Dimension() != vec.Dimensions() {
+		return false, errors.New("dimension mismatch")
+	}
+	vi.mu.Lock()
+	defer vi.mu.Unlock()
+	if _, ok := vi.ids.Slot(id); ok {
+		return true, nil
+	}
+	values := vec.Values()
+	slot := vi.ids.Slots()
+	var neighbors []uint32
+	if vi.entry >= 0 {
+		visited, err := vi.beamSearch(values, vi.opts.BuildListSize, nil)
+		if err != nil {
+			return false, err
+		}
+		// no new edges into tombstones, the repair would have to drop them again
+		visited = slices.DeleteFunc(visited, func(n vamanaNode) bool { return vi.deleted.has(n.slot) })
+		neighbors = vi.robustPrune(slot, visited)
+	}
+	if err := vi.graph.WriteNode(slot, values, neighbors); err != nil {
+		return false, err
+	}
+	// the id log entry commits the node, a crash before it leaves a block the next add overwrites
+	if _, err := vi.ids.Add(id); err != nil {
+		return false, err
+	}
+	vi.remember(values)
+	if vi.entry < 0 {
+		vi.entry = slot
+	}
+	if err := vi.addBackEdges(slot, values, neighbors); err != nil {
+		return false, err
+	}
+	return false, vi.maybeTrain()
+}
+
+// addBackEdges links every new neighbor back to slot, pruning neighbor lists that overflow
+func (vi *VamanaIndex) addBackEdges(slot int, values []float32, neighbors []uint32) error {
+	for _, nb := range neighbors {
+		nbValues, nbNeighbors, err := vi.graph.ReadNode(int(nb))
+		if err != nil {
+			return err
+		}
+		if slices.Contains(nbNeighbors, uint32(slot)) {
+			continue
+		}
+		nbNeighbors = append(nbNeighbors, uint32(slot))
+		if len(nbNeighbors) > vi.opts.MaxDegree {
+			candidates, err := vi.readNodes(nbValues, nbNeighbors)
+			if err != nil {
+				return err
+			}
+			nbNeighbors = vi.robustPrune(int(nb), candidates)
+		}
+		if err := vi.graph.WriteNeighbors(int(nb), nbNeighbors); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete tombstones id: it is hidden from results while its node keeps routing searches through
+// the graph, crossing RepairThreshold wakes the background repair
+func (vi *VamanaIndex) Delete(id string) error {
+	vi.mu.Lock()
+	slot, ok := vi.ids.Slot(id)
+	if !ok {
+		vi.mu.Unlock()
+		return errors.New("vector doesn't exist in index")
+	}
+	if _, err := vi.ids.Delete(id); err != nil {
+		vi.mu.Unlock()
+		return err
+	}
+	vi.deleted.set(slot)
+	ratio := vi.tombstoneRatio()
+	vi.mu.Unlock()
+	if ratio >= vi.opts.RepairThreshold {
+		vi.repair.trigger()
+	}
+	return nil
+}
+
+func (vi *VamanaIndex) TombstoneRatio() float64 {
+	vi.mu.RLock()
+	defer vi.mu.RUnlock()
+	return vi.tombstoneRatio()
+}
+
+func (vi *VamanaIndex) tombstoneRatio() float64 {
+	if len(vi.codes) == 0 {
+		return 0
+	}
+	return float64(vi.deleted.len()-vi.purged.len()) / float64(len(vi.codes))
+}
+
+// Repair disconnects the unrepaired tombstones: every live node linking to one gets its neighbor list
+// re-pruned from its live neighbors plus the live neighbors of the deleted ones, so paths through
+// deleted nodes survive. Once RebuildThreshold of all nodes are deleted the graph is rebuilt instead,
+// which also gives the disk space of deleted nodes back. Searches wait while a repair runs
+func (vi *VamanaIndex) Repair() error {
+	vi.mu.Lock()
+	defer vi.mu.Unlock()
+	if vi.closed || vi.deleted.len() == vi.purged.len() {
+		return nil
+	}
+	if float64(vi.deleted.len()) >= vi.opts.RebuildThreshold*float64(len(vi.codes)) {
+		return vi.rebuild()
+	}
+	pending := func(slot int) bool { return vi.deleted.has(slot) && !vi.purged.has(slot) }
+	for slot := range vi.codes {
+		if vi.deleted.has(slot) {
+			continue
+		}
+		values, neighbors, err := vi.graph.ReadNode(slot)
+		if err != nil {
+			return err
+		}
+		if !slices.ContainsFunc(neighbors, func(nb uint32) bool { return pending(int(nb)) }) {
+			continue
+		}
+		var candidates []uint32
+		for _, nb := range neighbors {
+			if !pending(int(nb)) {
+				candidates = append(candidates, nb)
+				continue
+			}
+			_, through, err := vi.graph.ReadNode(int(nb))
+			if err != nil {
+				return err
+			}
+			candidates = append(candidates, through...)
+		}
+		candidates = slices.DeleteFunc(candidates, func(c uint32) bool { return int(c) == slot || vi.deleted.has(int(c)) })
+		slices.Sort(candidates)
+		nodes, err := vi.readNodes(values, slices.Compact(candidates))
+		if err != nil {
+			return err
+		}
+		if err := vi.graph.WriteNeighbors(slot, vi.robustPrune(slot, nodes)); err != nil {
+			return err
+		}
+	}
+	for slot := range vi.codes {
+		if pending(slot) {
+			vi.purged.set(slot)
+		}
+	}
+	if vi.deleted.has(vi.entry) {
+		vi.entry = -1
+		vi.ids.Range(func(slot int, _ string) bool {
+			vi.entry = slot
+			return false
+		})
+	}
+	return vi.flushLocked()
+}
+
+// rebuild inserts the live vectors into a fresh index next to dir and swaps the directories,
+// recoverRebuild finishes or rolls back a swap cut short by a crash
+func (vi *VamanaIndex) rebuild() error {
+	tmp, old := vi.dir+".rebuild", vi.dir+".old"
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	fresh, err := OpenVamanaIndex(tmp, vi.config, vi.opts)
+	if err != nil {
+		return err
+	}
+	var rangeErr error
+	vi.ids.Range(func(slot int, id string) bool {
+		values, _, err := vi.graph.ReadNode(slot)
+		if err != nil {
+			rangeErr = err
+			return false
+		}
+		vec, err := v.NewVector(values, vi.config.Dimension())
+		if err == nil {
+			_, err = fresh.Add(id, vec)
+		}
+		rangeErr = err
+		return err == nil
+	})
+	if err := errors.Join(rangeErr, fresh.Close()); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	if err := errors.Join(vi.graph.Close(), vi.ids.Close()); err != nil {
+		return err
+	}
+	if err := os.Rename(vi.dir, old); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, vi.dir); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(old); err != nil {
+		return err
+	}
+	return vi.open()
+}
+
+// recoverRebuild cleans up after a rebuild interrupted by a crash: the fresh index is only renamed
+// into place once it is complete, so a missing dir means the swap has to be finished
+func recoverRebuild(dir string) error {
+	tmp, old := dir+".rebuild", dir+".old"
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		if _, err := os.Stat(old); err == nil {
+			src := old
+			if _, err := os.Stat(tmp); err == nil {
+				src = tmp
+			}
+			if err := os.Rename(src, dir); err != nil {
+				return err
+			}
+		}
+	}
+	return errors.Join(os.RemoveAll(tmp), os.RemoveAll(old))
+}
+
+func (vi *VamanaIndex) Get(id string) (*v.Vector, bool) {
+	vi.mu.RLock()
+	defer vi.mu.RUnlock()
+	slot, ok := vi.ids.Slot(id)
+	if !ok {
+		return nil, false
+	}
+	values, _, err := vi.graph.ReadNode(slot)
+	if err != nil {
+		return nil, false
+	}
+	vec, err := v.NewVector(values, vi.config.Dimension())
+	if err != nil {
+		return nil, false
+	}
+	return vec, true
+}
+
+func (vi *VamanaIndex) Search(query *v.Vector, k int) ([]SearchResult, error) {
+	result, _, err := vi.SearchWithOptions(query, k, SearchOptions{})
+	return result, err
+}
+
+// SearchWithOptions filters the expanded nodes, so a very selective filter can return fewer than k
+// results; filters see nil metadata
+func (vi *VamanaIndex) SearchWithOptions(query *v.Vector, k int, opts SearchOptions) ([]SearchResult, *SearchStats, error) {
+	vi.mu.RLock()
+	defer vi.mu.RUnlock()
+	if vi.ids.Len() == 0 {
+		return nil, opts.newStats(), nil
+	}
+	if query == nil {
+		return nil, nil, errors.New("empty query input")
+	}
+	if vi.config.Dimension() != query.Dimensions() {
+		return nil, nil, errors.New("index and query dimension mismatched")
+	}
+	if k <= 0 {
+		return nil, nil, errors.New("invalid input for number of results")
+	}
+	stats := opts.newStats()
+	phase := stats.startPhase("beam search")
+	expanded, err := vi.beamSearch(query.Values(), max(vi.opts.SearchListSize, k), stats)
+	phase.end()
+	if err != nil {
+		return nil, nil, err
+	}
+	phase = stats.startPhase("collect")
+	result := make([]SearchResult, 0, k)
+	for _, node := range expanded {
+		if vi.deleted.has(node.slot) {
+			continue
+		}
+		id, ok := vi.ids.ID(node.slot)
+		if !ok {
+			continue
+		}
+		if opts.Filter != nil {
+			passed := opts.Filter(id, nil)
+			stats.filtered(passed)
+			if !passed {
+				continue
+			}
+		}
+		result = append(result, SearchResult{vecId: id, score: 1 - node.dist})
+	}
+	slices.SortFunc(result, compareResults)
+	if k < len(result) {
+		result = result[:k]
+	}
+	phase.end()
+	return result, stats, nil
+}
+
+// Range visits every live vector in slot order, reading each block from disk
+func (vi *VamanaIndex) Range(fn func(id string, vec *v.Vector) bool) {
+	vi.mu.RLock()
+	defer vi.mu.RUnlock()
+	vi.ids.Range(func(slot int, id string) bool {
+		values, _, err := vi.graph.ReadNode(slot)
+		if err != nil {
+			return false
+		}
+		vec, err := v.NewVector(values, vi.config.Dimension())
+		if err != nil {
+			return true
+		}
+		return fn(id, vec)
+	})
+}
+
+func (vi *VamanaIndex) Size() int {
+	return vi.ids.Len()
+}
+
+// Flush syncs the graph and id map and persists the entry point
+func (vi *VamanaIndex) Flush() error {
+	vi.mu.Lock()
+	defer vi.mu.Unlock()
+	return vi.flushLocked()
+}
+
+func (vi *VamanaIndex) flushLocked() error {
+	meta := vamanaMeta{Config: vi.config, MaxDegree: vi.opts.MaxDegree, Entry: vi.entry, Purged: vi.purged.words}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	if err := errors.Join(vi.graph.Sync(), vi.ids.Sync()); err != nil {
+		return err
+	}
+	return store.WriteFileAtomic(filepath.Join(vi.dir, vamanaMetaFile), data)
+}
+
+// Close waits for a running repair, it reports the last background repair error, if any
+func (vi *VamanaIndex) Close() error {
+	vi.mu.Lock()
+	if vi.closed {
+		vi.mu.Unlock()
+		return nil
+	}
+	vi.closed = true
+	vi.mu.Unlock()
+	bgErr := vi.repair.stop()
+	vi.mu.Lock()
+	defer vi.mu.Unlock()
+	return errors.Join(bgErr, vi.flushLocked(), vi.graph.Close(), vi.ids.Close())
+}
+
+var _ VectorIndex = (*VamanaIndex)(nil)
+var _ OptionSearcher = (*VamanaIndex)(nil)
+var _ RangeIndex = (*VamanaIndex)(nil)
+var _ RepairableIndex = (*VamanaIndex)(nil)
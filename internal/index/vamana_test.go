@@ -0,0 +1,241 @@
+package index
+
+import (
+	"VectorDatabase/internal/types"
+	v "VectorDatabase/internal/vector"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func setupVamana(t *testing.T, dir string, dim int) *VamanaIndex {
+	cfg, _ := NewIndexConfig(types.DiskANNIndex, types.Testmodel, types.Text, types.Cosine, dim)
+	vi, err := OpenVamanaIndex(dir, cfg, VamanaOptions{MaxDegree: 12, BuildListSize: 32, SearchListSize: 32, TrainSize: 100})
+	if err != nil {
+		t.Fatalf("failed to open vamana index: %v", err)
+	}
+	return vi
+}
+
+// recall@k of got against the exact results
+func recall(got, want []SearchResult) float64 {
+	ids := make(map[string]bool, len(want))
+	for _, r := range want {
+		ids[r.ID()] = true
+	}
+	hits := 0
+	for _, r := range got {
+		if ids[r.ID()] {
+			hits++
+		}
+	}
+	return float64(hits) / float64(len(want))
+}
+
+func fillVamana(t *testing.T, vi *VamanaIndex, li *LinearIndex, samples [][]float32) {
+	for i, vals := range samples {
+		vec, _ := v.NewVector(vals, len(vals))
+		id := fmt.Sprintf("vec-%d", i)
+		if _, err := vi.Add(id, vec); err != nil {
+			t.Fatalf("add %s failed: %v", id, err)
+		}
+		if li != nil {
+			li.Add(id, vec)
+		}
+	}
+}
+
+// Invariant: the graph search (PQ trained halfway through the inserts) finds almost all exact neighbors.
+func TestVamanaIndex_Recall(t *testing.T) {
+	const dim, k = 16, 10
+	vi := setupVamana(t, t.TempDir(), dim)
+	defer vi.Close()
+	li := setupIndex(t, dim)
+	fillVamana(t, vi, li, randomUnitVectors(500, dim, 3))
+	if vi.pq == nil {
+		t.Fatal("expected the product quantizer to be trained")
+	}
+
+	var total float64
+	queries := randomUnitVectors(20, dim, 4)
+	for _, q := range queries {
+		query, _ := v.NewVector(q, dim)
+		want, _ := li.Search(query, k)
+		got, err := vi.Search(query, k)
+		if err != nil {
+			t.Fatalf("search failed: %v", err)
+		}
+		if len(got) != k {
+			t.Fatalf("expected %d results, got %d", k, len(got))
+		}
+		total += recall(got, want)
+	}
+	if avg := total / float64(len(queries)); avg < 0.9 {
+		t.Errorf("recall@%d too low: %.2f", k, avg)
+	}
+}
+
+// Contract: deleted vectors are hidden, duplicates are reported and stats count blocks read.
+func TestVamanaIndex_DeleteAndStats(t *testing.T) {
+	vi := setupVamana(t, t.TempDir(), 8)
+	defer vi.Close()
+	samples := randomUnitVectors(50, 8, 5)
+	fillVamana(t, vi, nil, samples)
+	query, _ := v.NewVector(samples[7], 8)
+
+	if err := vi.Delete("vec-7"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if err := vi.Delete("vec-7"); err == nil {
+		t.Error("expected error deleting a missing vector")
+	}
+	if exists, _ := vi.Add("vec-1", query); !exists {
+		t.Error("expected exists=true for duplicate id")
+	}
+	results, stats, err := vi.SearchWithOptions(query, 5, SearchOptions{Explain: true})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	for _, r := range results {
+		if r.ID() == "vec-7" {
+			t.Error("deleted vector returned")
+		}
+	}
+	if vi.Size() != 49 || stats.NodesVisited == 0 || stats.DistanceComputations < stats.NodesVisited {
+		t.Errorf("unexpected size %d or stats %+v", vi.Size(), stats)
+	}
+}
+
+// Post-condition: the graph, id map and codebook reopen from disk with the same answers.
+func TestVamanaIndex_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	vi := setupVamana(t, dir, 8)
+	samples := randomUnitVectors(150, 8, 6)
+	fillVamana(t, vi, nil, samples)
+	vi.Delete("vec-3")
+	query, _ := v.NewVector(samples[10], 8)
+	before, _ := vi.Search(query, 5)
+	if err := vi.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	vi = setupVamana(t, dir, 8)
+	defer vi.Close()
+	if vi.Size() != 149 || vi.pq == nil {
+		t.Fatalf("unexpected state after reopen: size %d, trained %v", vi.Size(), vi.pq != nil)
+	}
+	after, _ := vi.Search(query, 5)
+	for i := range before {
+		if before[i].ID() != after[i].ID() {
+			t.Errorf("position %d: expected %s, got %s", i, before[i].ID(), after[i].ID())
+		}
+	}
+	if _, ok := vi.Get("vec-3"); ok {
+		t.Error("deleted vector came back after reopen")
+	}
+	if after[0].ID() != "vec-10" {
+		t.Errorf("expected vec-10 first, got %s", after[0].ID())
+	}
+}
+
+// Invariant: after a repair no live node links to a deleted one and search quality holds.
+func TestVamanaIndex_Repair(t *testing.T) {
+	const dim, k = 16, 10
+	dir := t.TempDir()
+	vi := setupVamana(t, dir, dim)
+	li := setupIndex(t, dim)
+	fillVamana(t, vi, li, randomUnitVectors(400, dim, 7))
+	for i := 0; i < 400; i += 3 {
+		id := fmt.Sprintf("vec-%d", i)
+		vi.Delete(id)
+		li.Delete(id)
+	}
+	if ratio := vi.TombstoneRatio(); ratio < 0.3 {
+		t.Fatalf("expected a tombstone ratio above 0.3, got %.2f", ratio)
+	}
+	if err := vi.Repair(); err != nil {
+		t.Fatalf("repair failed: %v", err)
+	}
+	if ratio := vi.TombstoneRatio(); ratio != 0 {
+		t.Errorf("expected no unrepaired tombstones, got ratio %.2f", ratio)
+	}
+	for slot := range vi.codes {
+		if vi.deleted.has(slot) {
+			continue
+		}
+		_, neighbors, _ := vi.graph.ReadNode(slot)
+		for _, nb := range neighbors {
+			if vi.deleted.has(int(nb)) {
+				t.Fatalf("live node %d still links to deleted node %d", slot, nb)
+			}
+		}
+	}
+
+	var total float64
+	queries := randomUnitVectors(20, dim, 8)
+	for _, q := range queries {
+		query, _ := v.NewVector(q, dim)
+		want, _ := li.Search(query, k)
+		got, _ := vi.Search(query, k)
+		total += recall(got, want)
+	}
+	if avg := total / float64(len(queries)); avg < 0.9 {
+		t.Errorf("recall@%d after repair too low: %.2f", k, avg)
+	}
+
+	vi.Close()
+	vi = setupVamana(t, dir, dim)
+	defer vi.Close()
+	if ratio := vi.TombstoneRatio(); ratio != 0 {
+		t.Errorf("repaired tombstones pending again after reopen: ratio %.2f", ratio)
+	}
+}
+
+// Post-condition: past the rebuild threshold the graph is rebuilt with only the live vectors.
+func TestVamanaIndex_Rebuild(t *testing.T) {
+	dir := t.TempDir()
+	vi := setupVamana(t, dir, 8)
+	samples := randomUnitVectors(200, 8, 9)
+	fillVamana(t, vi, nil, samples)
+	for i := 0; i < 120; i++ {
+		vi.Delete(fmt.Sprintf("vec-%d", i))
+	}
+	if err := vi.Repair(); err != nil {
+		t.Fatalf("repair failed: %v", err)
+	}
+	if vi.graph.Nodes() != 80 || vi.Size() != 80 || vi.TombstoneRatio() != 0 {
+		t.Fatalf("expected 80 live nodes after rebuild, got %d nodes and size %d", vi.graph.Nodes(), vi.Size())
+	}
+	query, _ := v.NewVector(samples[150], 8)
+	results, err := vi.Search(query, 3)
+	if err != nil || len(results) != 3 || results[0].ID() != "vec-150" {
+		t.Fatalf("unexpected results after rebuild: %v %v", results, err)
+	}
+	if _, ok := vi.Get("vec-10"); ok {
+		t.Error("deleted vector survived the rebuild")
+	}
+	if err := vi.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	if _, err := os.Stat(dir + ".rebuild"); !os.IsNotExist(err) {
+		t.Error("rebuild directory left behind")
+	}
+}
+
+// Contract: crossing the repair threshold repairs the graph in the background.
+func TestVamanaIndex_BackgroundRepair(t *testing.T) {
+	vi := setupVamana(t, t.TempDir(), 8)
+	defer vi.Close()
+	fillVamana(t, vi, nil, randomUnitVectors(100, 8, 10))
+	for i := 0; i < 20; i++ {
+		vi.Delete(fmt.Sprintf("vec-%d", i))
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for vi.TombstoneRatio() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("background repair didn't run, ratio %.2f", vi.TombstoneRatio())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,102 @@
+package ingest
+
+import (
+	"VectorDatabase/internal/index"
+	"VectorDatabase/internal/vector"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+)
+
+// DedupAction is what an Ingester does with a near-duplicate
+type DedupAction int
+
+const (
+	// DedupReject fails the insert with ErrNearDuplicate
+	DedupReject DedupAction = iota + 1
+	// DedupMerge stores nothing and answers with the existing vector's id, as if it had been inserted
+	DedupMerge
+	// DedupFlag stores the vector anyway with the duplicate's id as MetaDuplicateOf metadata
+	DedupFlag
+)
+
+func (a DedupAction) Valid() bool {
+	return a >= DedupReject && a <= DedupFlag
+}
+
+// MetaDuplicateOf is the metadata key DedupFlag stores the duplicate's id under
+const MetaDuplicateOf = "duplicate_of"
+
+// ErrNearDuplicate is returned by DedupReject, the InsertResult names the duplicate
+var ErrNearDuplicate = errors.New("near duplicate of an existing vector")
+
+// DedupPolicy makes an Ingester search its index before every insert: an existing vector scoring at or
+// above Threshold against the new one is a near-duplicate. Scores are the index scores, higher is more
+// similar for every metric (a cosine threshold is around 0.95)
+type DedupPolicy struct {
+	Threshold float64
+	Action    DedupAction
+}
+
+// dedup is shared by the copies of an Ingester, mu makes search and insert one step so two concurrent
+// near-duplicates can't both get in
+type dedup struct {
+	policy DedupPolicy
+	mu     sync.Mutex
+}
+
+// WithDedup returns a copy of the Ingester applying policy to Insert and InsertPreEmbed, documents
+// inserted through a DocumentIngester aren't deduplicated
+func (ing *Ingester) WithDedup(policy DedupPolicy) (*Ingester, error) {
+	if !policy.Action.Valid() {
+		return nil, errors.New("invalid dedup action")
+	}
+	if policy.Action == DedupFlag {
+		if _, ok := ing.index.(index.MetadataIndex); !ok {
+			return nil, errors.New("index can't store the duplicate flag")
+		}
+	}
+	deduped := *ing
+	deduped.dedup = &dedup{policy: policy}
+	return &deduped, nil
+}
+
+// add stores vec like store does, after checking it against the dedup policy
+func (ing *Ingester) add(ctx context.Context, vec *vector.Vector, md index.Metadata) (InsertResult, error) {
+	if ing.dedup == nil {
+		return ing.store(ctx, vec, md)
+	}
+	ing.dedup.mu.Lock()
+	defer ing.dedup.mu.Unlock()
+	if id, ok := ExternalIDFrom(ctx); ok {
+		if _, exists := ing.index.Get(id); exists {
+			// the same vector again, not a near-duplicate
+			return InsertResult{ExternalId: id, AlreadyExist: true}, nil
+		}
+	}
+	results, err := ing.index.Search(vec, 1)
+	if err != nil {
+		return InsertResult{}, fmt.Errorf("duplicate search: %w", err)
+	}
+	if len(results) == 0 || results[0].Score() < ing.dedup.policy.Threshold {
+		return ing.store(ctx, vec, md)
+	}
+	dup, score := results[0].ID(), results[0].Score()
+	switch ing.dedup.policy.Action {
+	case DedupReject:
+		return InsertResult{DuplicateOf: dup, Similarity: score}, fmt.Errorf("%w %s (score %.4f)", ErrNearDuplicate, dup, score)
+	case DedupMerge:
+		return InsertResult{ExternalId: dup, AlreadyExist: true, DuplicateOf: dup, Similarity: score}, nil
+	default:
+		flagged := maps.Clone(md)
+		if flagged == nil {
+			flagged = index.Metadata{}
+		}
+		flagged[MetaDuplicateOf] = dup
+		res, err := ing.store(ctx, vec, flagged)
+		res.DuplicateOf, res.Similarity = dup, score
+		return res, err
+	}
+}
@@ -0,0 +1,100 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// Contract: a vector scoring at or above the threshold against an existing one is rejected, merged or
+// flagged per the policy and the result names the duplicate; vectors below the threshold are inserted.
+func TestIngester_Dedup(t *testing.T) {
+	tests := []struct {
+		name   string
+		action DedupAction
+		check  func(t *testing.T, first, res InsertResult, err error, size int)
+	}{
+		{"reject", DedupReject, func(t *testing.T, first, res InsertResult, err error, size int) {
+			if !errors.Is(err, ErrNearDuplicate) || res.ExternalId != "" || size != 1 {
+				t.Errorf("expected a rejection, got %+v (err %v), size %d", res, err, size)
+			}
+		}},
+		{"merge", DedupMerge, func(t *testing.T, first, res InsertResult, err error, size int) {
+			if err != nil || res.ExternalId != first.ExternalId || !res.AlreadyExist || size != 1 {
+				t.Errorf("expected a merge into %s, got %+v (err %v), size %d", first.ExternalId, res, err, size)
+			}
+		}},
+		{"flag", DedupFlag, func(t *testing.T, first, res InsertResult, err error, size int) {
+			if err != nil || res.ExternalId == first.ExternalId || size != 2 {
+				t.Errorf("expected a flagged insert, got %+v (err %v), size %d", res, err, size)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, idx := newTestIngester(t, hashingEmbedder(t, 64))
+			ing, err := base.WithDedup(DedupPolicy{Threshold: 0.95, Action: tt.action})
+			if err != nil {
+				t.Fatalf("WithDedup failed: %v", err)
+			}
+			ctx := context.Background()
+			first, err := ing.Insert(ctx, "the quick brown fox jumps")
+			if err != nil || first.DuplicateOf != "" {
+				t.Fatalf("first insert: %+v (err %v)", first, err)
+			}
+			res, err := ing.Insert(ctx, "The quick brown fox jumps!")
+			if res.DuplicateOf != first.ExternalId || res.Similarity < 0.95 {
+				t.Errorf("expected the duplicate reported, got %+v", res)
+			}
+			tt.check(t, first, res, err, idx.Size())
+			if tt.action == DedupFlag {
+				if md, _ := idx.Metadata(res.ExternalId); md[MetaDuplicateOf] != first.ExternalId {
+					t.Errorf("expected the flag in metadata, got %v", md)
+				}
+			}
+			if other, err := ing.Insert(ctx, "stock markets fell sharply"); err != nil || other.DuplicateOf != "" {
+				t.Errorf("distinct text treated as duplicate: %+v (err %v)", other, err)
+			}
+			if _, err := base.Insert(ctx, "the quick brown fox jumps"); err != nil {
+				t.Errorf("the ingester without dedup was affected: %v", err)
+			}
+		})
+	}
+	base, _ := newTestIngester(t, hashingEmbedder(t, 64))
+	if _, err := base.WithDedup(DedupPolicy{Threshold: 0.9}); err == nil {
+		t.Error("expected a policy without action to fail")
+	}
+}
+
+// Contract: re-inserting an existing external id is AlreadyExist, not a near-duplicate of itself.
+func TestIngester_DedupExternalID(t *testing.T) {
+	base, _ := newTestIngester(t, hashingEmbedder(t, 64))
+	ing, _ := base.WithDedup(DedupPolicy{Threshold: 0.9, Action: DedupReject})
+	ctx := WithExternalID(context.Background(), "page-1")
+	ing.Insert(ctx, "crawled page")
+	res, err := ing.Insert(ctx, "crawled page")
+	if err != nil || !res.AlreadyExist || res.DuplicateOf != "" {
+		t.Errorf("expected AlreadyExist, got %+v (err %v)", res, err)
+	}
+}
+
+// Invariant: concurrent near-duplicates through a Pool can't both get in.
+func TestIngester_DedupConcurrent(t *testing.T) {
+	base, idx := newTestIngester(t, hashingEmbedder(t, 64))
+	ing, _ := base.WithDedup(DedupPolicy{Threshold: 0.95, Action: DedupReject})
+	pool, _ := NewPool(ing, PoolConfig{BatchSize: 4})
+	defer pool.Close()
+	var wg sync.WaitGroup
+	for range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pool.Insert(context.Background(), "same crawled page")
+		}()
+	}
+	wg.Wait()
+	if idx.Size() != 1 {
+		t.Errorf("expected 1 vector, got %d", idx.Size())
+	}
+}
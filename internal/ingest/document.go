@@ -0,0 +1,114 @@
+package ingest
+
+import (
+	"VectorDatabase/internal/chunk"
+	"VectorDatabase/internal/embedder"
+	"VectorDatabase/internal/index"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Metadata keys of the chunks a DocumentIngester stores, offsets are byte offsets into the document
+const (
+	MetaParentID   = "parent_id"
+	MetaChunkIndex = "chunk_index"
+	MetaChunkStart = "chunk_start"
+	MetaChunkEnd   = "chunk_end"
+	// MetaHeading is only set for chunks under a Markdown heading
+	MetaHeading = "heading"
+)
+
+// DocumentIngester splits documents into chunks and stores every chunk as its own vector, with the
+// parent document id and the chunk position as metadata
+type DocumentIngester struct {
+	ing     *Ingester
+	chunker chunk.Chunker
+}
+
+// DocumentResult is the outcome of InsertDocument, Chunks are in document order
+type DocumentResult struct {
+	ParentID string
+	Chunks   []InsertResult
+	// Replaced counts the chunks of an earlier version of the document that were deleted
+	Replaced int
+}
+
+// NewDocumentIngester needs an index that stores metadata and can enumerate its vectors
+func NewDocumentIngester(ing *Ingester, c chunk.Chunker) (*DocumentIngester, error) {
+	if ing == nil || c == nil {
+		return nil, errors.New("ingester and chunker are required")
+	}
+	if _, ok := ing.Index().(index.MetadataIndex); !ok {
+		return nil, errors.New("index can't store metadata")
+	}
+	if _, ok := ing.Index().(index.RangeIndex); !ok {
+		return nil, errors.New("index can't enumerate its vectors")
+	}
+	return &DocumentIngester{ing: ing, chunker: c}, nil
+}
+
+// InsertDocument chunks text, embeds the chunks together (one EmbedBatch call for a BatchEmbedder) and
+// stores them. A document already stored under parentID is replaced once the new chunks are in. When a
+// chunk fails the chunks stored so far are deleted again and the old version stays
+func (d *DocumentIngester) InsertDocument(ctx context.Context, parentID string, text string) (DocumentResult, error) {
+	if parentID == "" {
+		return DocumentResult{}, errors.New("parent id is required")
+	}
+	chunks := d.chunker.Chunk(text)
+	if len(chunks) == 0 {
+		return DocumentResult{}, errors.New("document has no text to embed")
+	}
+	inputs := make([]any, len(chunks))
+	for i, ch := range chunks {
+		inputs[i] = ch.Text
+	}
+	vecs, err := embedder.EmbedAll(ctx, d.ing.Embedder(), inputs)
+	if err != nil {
+		return DocumentResult{}, &EmbedError{err}
+	}
+	res := DocumentResult{ParentID: parentID, Chunks: make([]InsertResult, 0, len(chunks))}
+	added := make(map[string]bool, len(chunks))
+	for i, ch := range chunks {
+		md := index.Metadata{
+			MetaParentID:   parentID,
+			MetaChunkIndex: float64(i),
+			MetaChunkStart: float64(ch.Start),
+			MetaChunkEnd:   float64(ch.End),
+		}
+		if ch.Heading != "" {
+			md[MetaHeading] = ch.Heading
+		}
+		// chunks always get generated ids, parentID is what the caller names. Dedup would find the old
+		// version of the document, store skips it
+		inserted, err := d.ing.store(context.Background(), vecs[i], md)
+		if err != nil {
+			for id := range added {
+				d.ing.Index().Delete(id)
+			}
+			return DocumentResult{}, fmt.Errorf("chunk %d: %w", i, err)
+		}
+		added[inserted.ExternalId] = true
+		res.Chunks = append(res.Chunks, inserted)
+	}
+	isParent := ParentFilter(parentID)
+	res.Replaced, err = index.DeleteWhere(d.ing.Index(), func(id string, md index.Metadata) bool {
+		return !added[id] && isParent(id, md)
+	})
+	if err != nil {
+		return res, fmt.Errorf("delete old chunks: %w", err)
+	}
+	return res, nil
+}
+
+// DeleteDocument deletes every chunk of parentID and returns how many there were
+func (d *DocumentIngester) DeleteDocument(parentID string) (int, error) {
+	return index.DeleteWhere(d.ing.Index(), ParentFilter(parentID))
+}
+
+// ParentFilter accepts the chunks of parentID, to delete them or to search within one document
+func ParentFilter(parentID string) index.Filter {
+	return func(_ string, md index.Metadata) bool {
+		return md[MetaParentID] == parentID
+	}
+}
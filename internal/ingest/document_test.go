@@ -0,0 +1,81 @@
+package ingest
+
+import (
+	"VectorDatabase/internal/chunk"
+	"VectorDatabase/internal/index"
+	"context"
+	"testing"
+)
+
+func newDocumentIngester(t *testing.T, c chunk.Chunker) (*DocumentIngester, *index.LinearIndex) {
+	t.Helper()
+	ing, idx := newTestIngester(t, hashingEmbedder(t, 32))
+	d, err := NewDocumentIngester(ing, c)
+	if err != nil {
+		t.Fatalf("NewDocumentIngester failed: %v", err)
+	}
+	return d, idx
+}
+
+// Post-condition: every chunk is stored with its parent id, index, offsets and heading, and the offsets
+// point back into the document.
+func TestDocumentIngester_Insert(t *testing.T) {
+	c, _ := chunk.NewMarkdownChunker(0)
+	d, idx := newDocumentIngester(t, c)
+	doc := "# Cats\nCats purr.\n# Dogs\nDogs bark."
+	res, err := d.InsertDocument(context.Background(), "doc-1", doc)
+	if err != nil {
+		t.Fatalf("InsertDocument failed: %v", err)
+	}
+	if len(res.Chunks) != 2 || res.Replaced != 0 || idx.Size() != 2 {
+		t.Fatalf("expected 2 new chunks, got %+v, size %d", res, idx.Size())
+	}
+	for i, want := range []struct{ heading, text string }{{"Cats", "# Cats\nCats purr."}, {"Dogs", "# Dogs\nDogs bark."}} {
+		md, ok := idx.Metadata(res.Chunks[i].ExternalId)
+		if !ok || md[MetaParentID] != "doc-1" || md[MetaChunkIndex] != float64(i) || md[MetaHeading] != want.heading {
+			t.Errorf("chunk %d: unexpected metadata %v", i, md)
+			continue
+		}
+		start, end := int(md[MetaChunkStart].(float64)), int(md[MetaChunkEnd].(float64))
+		if doc[start:end] != want.text {
+			t.Errorf("chunk %d: offsets point to %q", i, doc[start:end])
+		}
+	}
+	if _, err := d.InsertDocument(context.Background(), "doc-2", " \n "); err == nil {
+		t.Error("expected a blank document to fail")
+	}
+	if _, err := d.InsertDocument(context.Background(), "", doc); err == nil {
+		t.Error("expected a missing parent id to fail")
+	}
+}
+
+// Contract: inserting a document again replaces its chunks, DeleteDocument removes only the chunks of
+// its parent, and ParentFilter restricts a search to one document.
+func TestDocumentIngester_ReplaceAndDelete(t *testing.T) {
+	c, _ := chunk.NewTokenChunker(3, 1)
+	d, idx := newDocumentIngester(t, c)
+	ctx := context.Background()
+	d.InsertDocument(ctx, "a", "one two three four five six seven")
+	d.InsertDocument(ctx, "b", "alpha beta gamma")
+	res, err := d.InsertDocument(ctx, "a", "one two three")
+	if err != nil || res.Replaced != 3 || len(res.Chunks) != 1 {
+		t.Fatalf("expected 1 chunk replacing 3, got %+v (err %v)", res, err)
+	}
+	if idx.Size() != 2 {
+		t.Errorf("expected 2 chunks left, got %d", idx.Size())
+	}
+
+	query, _ := d.ing.Embedder().Embed(ctx, "alpha beta")
+	results, _, err := idx.SearchWithOptions(query, 5, index.SearchOptions{Filter: ParentFilter("a")})
+	if err != nil || len(results) != 1 || results[0].ID() != res.Chunks[0].ExternalId {
+		t.Errorf("expected only the chunk of a, got %v (err %v)", results, err)
+	}
+
+	deleted, err := d.DeleteDocument("a")
+	if err != nil || deleted != 1 || idx.Size() != 1 {
+		t.Errorf("expected 1 chunk deleted and 1 left, got %d, %d (err %v)", deleted, idx.Size(), err)
+	}
+	if deleted, _ := d.DeleteDocument("missing"); deleted != 0 {
+		t.Errorf("expected nothing deleted for an unknown parent, got %d", deleted)
+	}
+}
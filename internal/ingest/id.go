@@ -1,11 +1,57 @@
 package ingest
 
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
 type IDGenerator interface {
 	NewID() string
 }
 
-type UUIDv7Generator struct{}
+// UUIDv7Generator generates RFC 9562 version 7 UUIDs: a millisecond timestamp followed by random bits,
+// so ids sort by creation time. Within a millisecond the 12 bit rand_a field counts up, ids of one
+// generator are strictly increasing
+type UUIDv7Generator struct {
+	mu     sync.Mutex
+	lastMs int64
+	seq    uint16
+}
+
+func (uid *UUIDv7Generator) NewID() string {
+	var b [16]byte
+	rand.Read(b[6:])
+	uid.mu.Lock()
+	ms := time.Now().UnixMilli()
+	if ms > uid.lastMs {
+		uid.lastMs = ms
+		uid.seq = binary.BigEndian.Uint16(b[6:]) & 0x7ff // leave room to count up
+	} else if uid.seq < 0xfff {
+		uid.seq++
+	} else {
+		// rand_a ran out, borrow the next millisecond
+		uid.lastMs++
+		uid.seq = 0
+	}
+	ms, seq := uid.lastMs, uid.seq
+	uid.mu.Unlock()
+
+	binary.BigEndian.PutUint64(b[:8], uint64(ms)<<16)
+	binary.BigEndian.PutUint16(b[6:], 0x7000|seq)
+	b[8] = b[8]&0x3f | 0x80 // variant 10
 
-func (uid UUIDv7Generator) NewID() string {
-	return "id"
+	var out [36]byte
+	hex.Encode(out[0:8], b[0:4])
+	out[8] = '-'
+	hex.Encode(out[9:13], b[4:6])
+	out[13] = '-'
+	hex.Encode(out[14:18], b[6:8])
+	out[18] = '-'
+	hex.Encode(out[19:23], b[8:10])
+	out[23] = '-'
+	hex.Encode(out[24:], b[10:])
+	return string(out[:])
 }
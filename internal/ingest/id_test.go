@@ -0,0 +1,23 @@
+package ingest
+
+import (
+	"regexp"
+	"testing"
+)
+
+// Invariant: ids are RFC 9562 version 7 UUIDs, unique and strictly increasing per generator.
+func TestUUIDv7Generator(t *testing.T) {
+	format := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	gen := &UUIDv7Generator{}
+	prev := ""
+	for range 10000 {
+		id := gen.NewID()
+		if !format.MatchString(id) {
+			t.Fatalf("not a version 7 uuid: %s", id)
+		}
+		if id <= prev {
+			t.Fatalf("ids not increasing: %s after %s", id, prev)
+		}
+		prev = id
+	}
+}
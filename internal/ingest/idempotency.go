@@ -0,0 +1,201 @@
+package ingest
+
+import (
+	"VectorDatabase/internal/types"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"math"
+	"sync"
+	"time"
+)
+
+type contextKey int
+
+const (
+	externalIDKey contextKey = iota
+	idempotencyKey
+	embedHookKey
+)
+
+// WithExternalID makes an insert store its vector under id instead of a generated one. Inserting
+// the same id again keeps the stored vector and reports AlreadyExist
+func WithExternalID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, externalIDKey, id)
+}
+
+// ExternalIDFrom returns the external id set with WithExternalID
+func ExternalIDFrom(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(externalIDKey).(string)
+	return id, ok && id != ""
+}
+
+// WithIdempotencyKey tags an insert for an IdempotentInserter, a retry with the same key gets the
+// result of the first insert instead of a second vector
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey, key)
+}
+
+// IdempotencyKeyFrom returns the key set with WithIdempotencyKey
+func IdempotencyKeyFrom(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(idempotencyKey).(string)
+	return key, ok && key != ""
+}
+
+// ErrIdempotencyKeyReused is returned when a key comes back with a different input
+var ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different input")
+
+const defaultRetention = 24 * time.Hour
+
+// IdempotentInserter deduplicates inserts by idempotency key: the first insert with a key runs, a replay
+// within the retention window returns its InsertResult with Replayed set, and a replay while the first is
+// still running waits for it. Failed inserts aren't remembered, they can be retried with the same key.
+// Inserts without a key pass straight through. Keys live in memory, an external id (WithExternalID)
+// is the deduplication that survives a restart
+type IdempotentInserter struct {
+	next      Inserter
+	retention time.Duration
+	now       func() time.Time
+
+	mu   sync.Mutex
+	keys map[string]*idempotentEntry
+	// done holds the finished entries in completion order, so also in expiry order
+	done []*idempotentEntry
+}
+
+type idempotentEntry struct {
+	key         string
+	fingerprint [sha256.Size]byte
+	finished    chan struct{}
+	result      InsertResult
+	err         error
+	expires     time.Time
+}
+
+// NewIdempotentInserter wraps next, retention zero keeps keys for 24 hours
+func NewIdempotentInserter(next Inserter, retention time.Duration) (*IdempotentInserter, error) {
+	if next == nil {
+		return nil, errors.New("inserter is required")
+	}
+	if retention < 0 {
+		return nil, errors.New("retention can't be negative")
+	}
+	if retention == 0 {
+		retention = defaultRetention
+	}
+	return &IdempotentInserter{next: next, retention: retention, now: time.Now, keys: make(map[string]*idempotentEntry)}, nil
+}
+
+func (in *IdempotentInserter) Insert(ctx context.Context, inputData any) (InsertResult, error) {
+	key, ok := IdempotencyKeyFrom(ctx)
+	if !ok {
+		return in.next.Insert(ctx, inputData)
+	}
+	h := sha256.New()
+	switch data := inputData.(type) {
+	case string:
+		h.Write([]byte(data))
+	case []byte:
+		h.Write(data)
+	default:
+		fmt.Fprintf(h, "%T:%#v", data, data)
+	}
+	return in.do(ctx, key, h, func() (InsertResult, error) {
+		return in.next.Insert(ctx, inputData)
+	})
+}
+
+func (in *IdempotentInserter) InsertPreEmbed(
+	ctx context.Context,
+	vec []float32,
+	inputDataType types.DataType,
+	simMetric types.SimilarityMetric,
+	model string) (
+	InsertResult, error) {
+	key, ok := IdempotencyKeyFrom(ctx)
+	if !ok {
+		return in.next.InsertPreEmbed(ctx, vec, inputDataType, simMetric, model)
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%s/%s/%s/", inputDataType, simMetric, model)
+	for _, val := range vec {
+		binary.Write(h, binary.LittleEndian, math.Float32bits(val))
+	}
+	return in.do(ctx, key, h, func() (InsertResult, error) {
+		return in.next.InsertPreEmbed(ctx, vec, inputDataType, simMetric, model)
+	})
+}
+
+func (in *IdempotentInserter) do(ctx context.Context, key string, h hash.Hash, insert func() (InsertResult, error)) (InsertResult, error) {
+	var fingerprint [sha256.Size]byte
+	h.Sum(fingerprint[:0])
+	for {
+		in.mu.Lock()
+		in.expire()
+		entry, seen := in.keys[key]
+		if !seen {
+			entry = &idempotentEntry{key: key, fingerprint: fingerprint, finished: make(chan struct{})}
+			in.keys[key] = entry
+		}
+		in.mu.Unlock()
+
+		if !seen {
+			return in.run(entry, insert)
+		}
+		if entry.fingerprint != fingerprint {
+			return InsertResult{}, ErrIdempotencyKeyReused
+		}
+		select {
+		case <-entry.finished:
+		case <-ctx.Done():
+			return InsertResult{}, ctx.Err()
+		}
+		if entry.err != nil {
+			// the first attempt failed and is forgotten, try again
+			continue
+		}
+		result := entry.result
+		result.Replayed = true
+		return result, nil
+	}
+}
+
+func (in *IdempotentInserter) run(entry *idempotentEntry, insert func() (InsertResult, error)) (InsertResult, error) {
+	result, err := insert()
+	in.mu.Lock()
+	entry.result, entry.err = result, err
+	if err != nil {
+		delete(in.keys, entry.key)
+	} else {
+		entry.expires = in.now().Add(in.retention)
+		in.done = append(in.done, entry)
+	}
+	close(entry.finished)
+	in.mu.Unlock()
+	return result, err
+}
+
+// expire forgets the keys past their retention, in.mu must be held
+func (in *IdempotentInserter) expire() {
+	now := in.now()
+	n := 0
+	for n < len(in.done) && !in.done[n].expires.After(now) {
+		delete(in.keys, in.done[n].key)
+		in.done[n] = nil
+		n++
+	}
+	in.done = in.done[n:]
+}
+
+// Len is the number of keys remembered, running inserts included
+func (in *IdempotentInserter) Len() int {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.expire()
+	return len(in.keys)
+}
+
+var _ Inserter = (*IdempotentInserter)(nil)
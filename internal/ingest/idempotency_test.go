@@ -0,0 +1,145 @@
+package ingest
+
+import (
+	"VectorDatabase/internal/types"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyInserter fails the first fail inserts, then hands out ids id-1, id-2, ... after an optional delay
+type flakyInserter struct {
+	fail  int32
+	delay time.Duration
+	calls atomic.Int32
+}
+
+func (f *flakyInserter) Insert(ctx context.Context, inputData any) (InsertResult, error) {
+	n := f.calls.Add(1)
+	time.Sleep(f.delay)
+	if n <= f.fail {
+		return InsertResult{}, errors.New("timeout")
+	}
+	return InsertResult{ExternalId: fmt.Sprint("id-", n)}, nil
+}
+
+func (f *flakyInserter) InsertPreEmbed(ctx context.Context, vec []float32, _ types.DataType, _ types.SimilarityMetric, _ string) (InsertResult, error) {
+	return f.Insert(ctx, vec)
+}
+
+// Contract: a repeated key returns the first result marked Replayed without inserting again, the same key
+// with another input fails, and inserts without a key always run.
+func TestIdempotentInserter_Replay(t *testing.T) {
+	next := &flakyInserter{}
+	in, _ := NewIdempotentInserter(next, time.Hour)
+	ctx := WithIdempotencyKey(context.Background(), "req-1")
+	first, err := in.Insert(ctx, "payload")
+	if err != nil || first.Replayed {
+		t.Fatalf("unexpected first result %+v (err %v)", first, err)
+	}
+	again, err := in.Insert(ctx, []byte("payload"))
+	if err != nil || !again.Replayed || again.ExternalId != first.ExternalId || next.calls.Load() != 1 {
+		t.Errorf("expected a replay of %+v, got %+v (err %v, %d calls)", first, again, err, next.calls.Load())
+	}
+	if _, err := in.Insert(ctx, "other payload"); !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Errorf("expected ErrIdempotencyKeyReused, got %v", err)
+	}
+	pre := WithIdempotencyKey(context.Background(), "req-2")
+	in.InsertPreEmbed(pre, []float32{1, 2}, types.Text, types.Cosine, "m")
+	if res, _ := in.InsertPreEmbed(pre, []float32{1, 2}, types.Text, types.Cosine, "m"); !res.Replayed {
+		t.Error("expected a pre-embedded replay")
+	}
+	if _, err := in.InsertPreEmbed(pre, []float32{1, 3}, types.Text, types.Cosine, "m"); !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Errorf("expected ErrIdempotencyKeyReused for another vector, got %v", err)
+	}
+	in.Insert(context.Background(), "payload")
+	in.Insert(context.Background(), "payload")
+	if next.calls.Load() != 4 {
+		t.Errorf("expected inserts without a key to pass through, %d calls", next.calls.Load())
+	}
+}
+
+// Contract: a failed insert isn't remembered, the retry with the same key runs; keys are forgotten after
+// the retention window.
+func TestIdempotentInserter_FailureAndRetention(t *testing.T) {
+	next := &flakyInserter{fail: 1}
+	in, _ := NewIdempotentInserter(next, time.Minute)
+	clock := time.Unix(1000, 0)
+	in.now = func() time.Time { return clock }
+	ctx := WithIdempotencyKey(context.Background(), "k")
+	if _, err := in.Insert(ctx, "x"); err == nil {
+		t.Fatal("expected the first attempt to fail")
+	}
+	first, err := in.Insert(ctx, "x")
+	if err != nil || first.Replayed {
+		t.Fatalf("expected the retry to run, got %+v (err %v)", first, err)
+	}
+	clock = clock.Add(59 * time.Second)
+	if res, _ := in.Insert(ctx, "x"); !res.Replayed {
+		t.Error("key forgotten within the retention window")
+	}
+	clock = clock.Add(time.Second)
+	if in.Len() != 0 {
+		t.Errorf("expected the key to expire, %d remembered", in.Len())
+	}
+	if res, _ := in.Insert(ctx, "x"); res.Replayed || res.ExternalId == first.ExternalId {
+		t.Errorf("expected an expired key to insert again, got %+v", res)
+	}
+}
+
+// Invariant: concurrent inserts with one key insert once and all get the same id.
+func TestIdempotentInserter_Concurrent(t *testing.T) {
+	next := &flakyInserter{delay: 20 * time.Millisecond}
+	in, _ := NewIdempotentInserter(next, 0)
+	ctx := WithIdempotencyKey(context.Background(), "same")
+	ids := make([]string, 10)
+	var wg sync.WaitGroup
+	for i := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := in.Insert(ctx, "payload")
+			if err != nil {
+				t.Error(err)
+			}
+			ids[i] = res.ExternalId
+		}()
+	}
+	wg.Wait()
+	for _, id := range ids {
+		if id != ids[0] {
+			t.Fatalf("concurrent inserts got different ids %v", ids)
+		}
+	}
+	if next.calls.Load() != 1 {
+		t.Errorf("expected 1 insert, got %d", next.calls.Load())
+	}
+}
+
+// Contract: an external id names the vector, inserting it again reports AlreadyExist, through the Ingester
+// and through the Pool.
+func TestExternalID(t *testing.T) {
+	ing, idx := newTestIngester(t, hashingEmbedder(t, 16))
+	pool, _ := NewPool(ing, PoolConfig{})
+	defer pool.Close()
+	for name, ins := range map[string]Inserter{"ingester": ing, "pool": pool} {
+		t.Run(name, func(t *testing.T) {
+			ctx := WithExternalID(context.Background(), "doc-"+name)
+			res, err := ins.Insert(ctx, "first version")
+			if err != nil || res.ExternalId != "doc-"+name || res.AlreadyExist {
+				t.Fatalf("unexpected result %+v (err %v)", res, err)
+			}
+			again, err := ins.Insert(ctx, "second version")
+			if err != nil || again.ExternalId != "doc-"+name || !again.AlreadyExist {
+				t.Errorf("expected AlreadyExist, got %+v (err %v)", again, err)
+			}
+		})
+	}
+	if idx.Size() != 2 {
+		t.Errorf("expected 2 vectors, got %d", idx.Size())
+	}
+}
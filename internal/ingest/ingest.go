@@ -1,21 +1,83 @@
 package ingest
 
 import (
-	v "VectorDatabase/internal/vector"
+	"VectorDatabase/internal/embedder"
+	"VectorDatabase/internal/index"
+	"VectorDatabase/internal/types"
 	"context"
+	"fmt"
 )
 
 type InsertResult struct {
 	ExternalId   string
 	AlreadyExist bool
+	// Replayed is set when an IdempotentInserter answered a repeated idempotency key with the first result
+	Replayed bool
+	// DuplicateOf is the near-duplicate a DedupPolicy found, with its Similarity score
+	DuplicateOf string
+	Similarity  float64
 }
 type Inserter interface {
 	Insert(ctx context.Context, inputData any) (InsertResult, error)
 	InsertPreEmbed(
 		ctx context.Context,
 		vec []float32,
-		inputDataType v.DataType,
-		simMetric v.SimilarityMetric,
+		inputDataType types.DataType,
+		simMetric types.SimilarityMetric,
 		model string) (
 		InsertResult, error)
 }
+
+// EmbedError is an insert that failed in the embedder, before anything reached the index
+type EmbedError struct {
+	Err error
+}
+
+func (e *EmbedError) Error() string { return "embed: " + e.Err.Error() }
+func (e *EmbedError) Unwrap() error { return e.Err }
+
+// CheckEmbedder fails when the vectors e produces don't belong in an index with cfg: the index must be
+// for the model e runs (its Name), and e must agree with the registered model and with cfg
+func CheckEmbedder(e embedder.Embedder, cfg index.IndexConfig) error {
+	model := types.ModelType(e.Name())
+	if model != cfg.ModelType() {
+		return fmt.Errorf("embedder %s: index is for model %s", e.Name(), cfg.ModelType())
+	}
+	return checkModel(model, cfg, e.DataType(), e.Metric(), e.Dimension())
+}
+
+// CheckPreEmbedded fails when a vector of dim values some caller embedded with model doesn't belong
+// in an index with cfg, an index for types.ExternalModel takes vectors of any registered model
+func CheckPreEmbedded(cfg index.IndexConfig, dim int, dataType types.DataType, metric types.SimilarityMetric, model string) error {
+	if types.ModelType(model) != cfg.ModelType() && cfg.ModelType() != types.ExternalModel {
+		return fmt.Errorf("model %s: index is for model %s", model, cfg.ModelType())
+	}
+	return checkModel(types.ModelType(model), cfg, dataType, metric, dim)
+}
+
+func checkModel(model types.ModelType, cfg index.IndexConfig, dataType types.DataType, metric types.SimilarityMetric, dim int) error {
+	info, ok := types.LookupModel(model)
+	if !ok {
+		return fmt.Errorf("model %s isn't registered", model)
+	}
+	if err := info.Accepts(dataType, metric, dim); err != nil {
+		return err
+	}
+	if err := cfg.CheckEmbedding(dataType, metric, dim); err != nil {
+		return fmt.Errorf("model %s: %w", model, err)
+	}
+	return nil
+}
+
+// WithEmbedHook makes an insert call fn once its input is embedded, before the vector is stored.
+// Pre-embedded inserts and replayed ones don't call it
+func WithEmbedHook(ctx context.Context, fn func()) context.Context {
+	return context.WithValue(ctx, embedHookKey, fn)
+}
+
+// embedded runs the hook set with WithEmbedHook
+func embedded(ctx context.Context) {
+	if fn, ok := ctx.Value(embedHookKey).(func()); ok && fn != nil {
+		fn()
+	}
+}
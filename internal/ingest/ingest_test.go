@@ -0,0 +1,124 @@
+package ingest
+
+import (
+	"VectorDatabase/internal/embedder"
+	"VectorDatabase/internal/index"
+	"VectorDatabase/internal/types"
+	"VectorDatabase/internal/vector"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// stubEmbedder reports fixed properties, Embed is never called
+type stubEmbedder struct {
+	name     string
+	dim      int
+	dataType types.DataType
+	metric   types.SimilarityMetric
+}
+
+func (s stubEmbedder) Embed(context.Context, any) (*vector.Vector, error) {
+	return nil, errors.New("not implemented")
+}
+func (s stubEmbedder) Dimension() int                 { return s.dim }
+func (s stubEmbedder) DataType() types.DataType       { return s.dataType }
+func (s stubEmbedder) Metric() types.SimilarityMetric { return s.metric }
+func (s stubEmbedder) Name() string                   { return s.name }
+
+func registerTestModel(t *testing.T) {
+	err := types.RegisterModel(types.ModelInfo{Name: "ingest-test-model", Version: "1", Dimension: 4, DataType: types.Text, Metric: types.Cosine})
+	if err != nil {
+		t.Fatalf("failed to register model: %v", err)
+	}
+}
+
+// Contract: an embedder only fits an index for its own registered model with matching properties.
+func TestCheckEmbedder(t *testing.T) {
+	registerTestModel(t)
+	cfg, err := index.NewIndexConfig(types.LinearIndex, "ingest-test-model", types.Text, types.Cosine, 4)
+	if err != nil {
+		t.Fatalf("config failed: %v", err)
+	}
+	good := stubEmbedder{"ingest-test-model", 4, types.Text, types.Cosine}
+	tests := []struct {
+		name     string
+		e        stubEmbedder
+		errorMsg string
+	}{
+		{"match", good, ""},
+		{"other model", stubEmbedder{"other", 4, types.Text, types.Cosine}, "index is for model ingest-test-model"},
+		{"embedder disagrees with registration", stubEmbedder{"ingest-test-model", 8, types.Text, types.Cosine}, "produces dimension 4, not 8"},
+		{"metric", stubEmbedder{"ingest-test-model", 4, types.Text, types.Dot}, "trained for cosine, not dot"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckEmbedder(tt.e, cfg)
+			if (err == nil) != (tt.errorMsg == "") || (err != nil && !strings.Contains(err.Error(), tt.errorMsg)) {
+				t.Errorf("expected %q, got %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+// Contract: pre-embedded vectors need a registered model matching the index, an external index takes any.
+func TestCheckPreEmbedded(t *testing.T) {
+	registerTestModel(t)
+	external, _ := index.NewIndexConfig(types.LinearIndex, types.ExternalModel, types.Text, types.Cosine, 4)
+	if err := CheckPreEmbedded(external, 4, types.Text, types.Cosine, "ingest-test-model"); err != nil {
+		t.Errorf("external index rejected a registered model: %v", err)
+	}
+	if err := CheckPreEmbedded(external, 4, types.Text, types.Cosine, "unregistered"); err == nil {
+		t.Error("expected an unregistered model to fail")
+	}
+	if err := CheckPreEmbedded(external, 3, types.Text, types.Cosine, string(types.ExternalModel)); err == nil {
+		t.Error("expected a dimension mismatch with the index to fail")
+	}
+	model, _ := index.NewIndexConfig(types.LinearIndex, "ingest-test-model", types.Text, types.Cosine, 4)
+	if err := CheckPreEmbedded(model, 4, types.Text, types.Cosine, string(types.ExternalModel)); err == nil {
+		t.Error("expected a vector of another model to fail")
+	}
+}
+
+// Post-condition: a local embedder registered with embedder.Info passes CheckEmbedder, and the vectors it
+// embeds go into the index and find the closest text first, with no model or network involved.
+func TestLocalEmbedderInsertPath(t *testing.T) {
+	e, err := embedder.NewHashingEmbedder(32)
+	if err != nil {
+		t.Fatalf("NewHashingEmbedder failed: %v", err)
+	}
+	if err := types.RegisterModel(embedder.Info(e, "1")); err != nil {
+		t.Fatalf("failed to register model: %v", err)
+	}
+	cfg, err := index.NewIndexConfig(types.LinearIndex, types.ModelType(e.Name()), e.DataType(), e.Metric(), e.Dimension())
+	if err != nil {
+		t.Fatalf("NewIndexConfig failed: %v", err)
+	}
+	if err := CheckEmbedder(e, cfg); err != nil {
+		t.Fatalf("CheckEmbedder failed: %v", err)
+	}
+	idx, err := index.NewLinearIndex(cfg)
+	if err != nil {
+		t.Fatalf("NewLinearIndex failed: %v", err)
+	}
+	docs := map[string]string{
+		"cats":  "cats chase mice around the barn",
+		"banks": "banks raise interest rates again",
+		"space": "rockets launch satellites into orbit",
+	}
+	for id, text := range docs {
+		vec, err := e.Embed(context.Background(), text)
+		if err != nil {
+			t.Fatalf("embed %s failed: %v", id, err)
+		}
+		if _, err := idx.Add(id, vec); err != nil {
+			t.Fatalf("add %s failed: %v", id, err)
+		}
+	}
+	query, _ := e.Embed(context.Background(), "which rates do banks raise")
+	results, err := idx.Search(query, 1)
+	if err != nil || len(results) != 1 || results[0].ID() != "banks" {
+		t.Errorf("expected banks first, got %v (err %v)", results, err)
+	}
+}
@@ -0,0 +1,90 @@
+package ingest
+
+import (
+	"VectorDatabase/internal/embedder"
+	"VectorDatabase/internal/index"
+	"VectorDatabase/internal/types"
+	"VectorDatabase/internal/vector"
+	"context"
+	"errors"
+)
+
+// Ingester is the synchronous Inserter: it embeds every input on the caller's goroutine and adds
+// the vector to one index under a fresh id
+type Ingester struct {
+	embedder embedder.Embedder
+	cfg      index.IndexConfig
+	index    index.VectorIndex
+	ids      IDGenerator
+	// dedup is set by WithDedup
+	dedup *dedup
+}
+
+// NewIngester checks that e fits the index with cfg, ids nil uses a UUIDv7Generator
+func NewIngester(e embedder.Embedder, cfg index.IndexConfig, idx index.VectorIndex, ids IDGenerator) (*Ingester, error) {
+	if e == nil || idx == nil {
+		return nil, errors.New("embedder and index are required")
+	}
+	if err := CheckEmbedder(e, cfg); err != nil {
+		return nil, err
+	}
+	if ids == nil {
+		ids = &UUIDv7Generator{}
+	}
+	return &Ingester{embedder: e, cfg: cfg, index: idx, ids: ids}, nil
+}
+
+func (ing *Ingester) Embedder() embedder.Embedder { return ing.embedder }
+func (ing *Ingester) Config() index.IndexConfig   { return ing.cfg }
+func (ing *Ingester) Index() index.VectorIndex    { return ing.index }
+
+func (ing *Ingester) Insert(ctx context.Context, inputData any) (InsertResult, error) {
+	vec, err := ing.embedder.Embed(ctx, inputData)
+	if err != nil {
+		return InsertResult{}, &EmbedError{err}
+	}
+	embedded(ctx)
+	return ing.add(ctx, vec, nil)
+}
+
+func (ing *Ingester) InsertPreEmbed(
+	ctx context.Context,
+	vec []float32,
+	inputDataType types.DataType,
+	simMetric types.SimilarityMetric,
+	model string) (
+	InsertResult, error) {
+	if err := CheckPreEmbedded(ing.cfg, len(vec), inputDataType, simMetric, model); err != nil {
+		return InsertResult{}, err
+	}
+	v, err := vector.NewVector(vec, ing.cfg.Dimension())
+	if err != nil {
+		return InsertResult{}, err
+	}
+	return ing.add(ctx, v, nil)
+}
+
+// store adds an embedded vector under the external id of ctx or a new one, with md when it isn't empty
+func (ing *Ingester) store(ctx context.Context, vec *vector.Vector, md index.Metadata) (InsertResult, error) {
+	id, ok := ExternalIDFrom(ctx)
+	if !ok {
+		id = ing.ids.NewID()
+	}
+	var exists bool
+	var err error
+	if len(md) > 0 {
+		mdIndex, ok := ing.index.(index.MetadataIndex)
+		if !ok {
+			return InsertResult{}, errors.New("index can't store metadata")
+		}
+		exists, err = mdIndex.AddWithMetadata(id, vec, md)
+	} else {
+		exists, err = ing.index.Add(id, vec)
+	}
+	if err != nil {
+		return InsertResult{}, err
+	}
+	return InsertResult{ExternalId: id, AlreadyExist: exists}, nil
+}
+
+var _ Inserter = (*Ingester)(nil)
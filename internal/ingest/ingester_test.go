@@ -0,0 +1,102 @@
+package ingest
+
+import (
+	"VectorDatabase/internal/embedder"
+	"VectorDatabase/internal/index"
+	"VectorDatabase/internal/types"
+	"context"
+	"testing"
+)
+
+// newTestIngester registers e and returns an Ingester into a fresh linear index for it
+func newTestIngester(t *testing.T, e embedder.Embedder) (*Ingester, *index.LinearIndex) {
+	t.Helper()
+	if err := types.RegisterModel(embedder.Info(e, "1")); err != nil {
+		t.Fatalf("failed to register model: %v", err)
+	}
+	cfg, err := index.NewIndexConfig(types.LinearIndex, types.ModelType(e.Name()), e.DataType(), e.Metric(), e.Dimension())
+	if err != nil {
+		t.Fatalf("NewIndexConfig failed: %v", err)
+	}
+	idx, err := index.NewLinearIndex(cfg)
+	if err != nil {
+		t.Fatalf("NewLinearIndex failed: %v", err)
+	}
+	ing, err := NewIngester(e, cfg, idx, nil)
+	if err != nil {
+		t.Fatalf("NewIngester failed: %v", err)
+	}
+	return ing, idx
+}
+
+func hashingEmbedder(t *testing.T, dim int) *embedder.HashingEmbedder {
+	t.Helper()
+	e, err := embedder.NewHashingEmbedder(dim)
+	if err != nil {
+		t.Fatalf("NewHashingEmbedder failed: %v", err)
+	}
+	return e
+}
+
+// Post-condition: Insert embeds the input and stores it under a new id, the embedding error of a bad input
+// is returned and nothing is stored.
+func TestIngester_Insert(t *testing.T) {
+	e := hashingEmbedder(t, 16)
+	ing, idx := newTestIngester(t, e)
+	res, err := ing.Insert(context.Background(), "the quick brown fox")
+	if err != nil || res.ExternalId == "" || res.AlreadyExist {
+		t.Fatalf("unexpected result %+v (err %v)", res, err)
+	}
+	stored, ok := idx.Get(res.ExternalId)
+	want, _ := e.Embed(context.Background(), "the quick brown fox")
+	if !ok || stored.Values()[0] != want.Values()[0] {
+		t.Error("inserted vector isn't in the index")
+	}
+	second, _ := ing.Insert(context.Background(), "the quick brown fox")
+	if second.ExternalId == res.ExternalId {
+		t.Error("inserts share an id")
+	}
+	if _, err := ing.Insert(context.Background(), ""); err == nil || idx.Size() != 2 {
+		t.Errorf("expected an empty input to fail without storing, err %v, size %d", err, idx.Size())
+	}
+}
+
+// Contract: InsertPreEmbed stores vectors of the index model and rejects vectors the index can't hold.
+func TestIngester_InsertPreEmbed(t *testing.T) {
+	e := hashingEmbedder(t, 4)
+	ing, idx := newTestIngester(t, e)
+	tests := []struct {
+		name    string
+		vec     []float32
+		model   string
+		wantErr bool
+	}{
+		{"match", []float32{1, 2, 3, 4}, e.Name(), false},
+		{"other model", []float32{1, 2, 3, 4}, "ingest-test-model", true},
+		{"dimension", []float32{1, 2, 3}, e.Name(), true},
+		{"zero vector", []float32{0, 0, 0, 0}, e.Name(), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := ing.InsertPreEmbed(context.Background(), tt.vec, types.Text, types.Cosine, tt.model)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr {
+				if _, ok := idx.Get(res.ExternalId); !ok {
+					t.Error("vector not stored")
+				}
+			}
+		})
+	}
+}
+
+// Contract: NewIngester rejects an embedder that doesn't fit the index.
+func TestNewIngester_Mismatch(t *testing.T) {
+	e := hashingEmbedder(t, 8)
+	ing, _ := newTestIngester(t, e)
+	other := hashingEmbedder(t, 12)
+	if _, err := NewIngester(other, ing.Config(), ing.Index(), nil); err == nil {
+		t.Error("expected an embedder of another model to be rejected")
+	}
+}
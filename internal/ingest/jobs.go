@@ -0,0 +1,281 @@
+package ingest
+
+import (
+	"VectorDatabase/internal/types"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultJobConcurrency = 8
+	defaultJobRetention   = time.Hour
+	maxJobErrors          = 100
+)
+
+// JobState is where a job is in its life, the last three are final
+type JobState string
+
+const (
+	JobRunning   JobState = "running"
+	JobDone      JobState = "done"
+	JobFailed    JobState = "failed"
+	JobCancelled JobState = "cancelled"
+)
+
+// ErrJobNotFound is returned for unknown job ids and jobs past their retention
+var ErrJobNotFound = errors.New("job not found")
+
+// JobItem is one insert of a job: Input goes through Insert, a Vector through InsertPreEmbed
+type JobItem struct {
+	Input    any
+	Vector   []float32
+	DataType types.DataType
+	Metric   types.SimilarityMetric
+	Model    string
+	// ExternalID and IdempotencyKey are passed on with WithExternalID and WithIdempotencyKey
+	ExternalID     string
+	IdempotencyKey string
+}
+
+// JobError is the failure of one item
+type JobError struct {
+	Item    int    `json:"item"`
+	Message string `json:"message"`
+}
+
+// JobStatus is a snapshot of a job. Accepted items were queued at submit, Embedded ones got their vector
+// (pre-embedded items count from the submit, the others as soon as the embedder returns, before they are
+// stored), Indexed ones are in the index, Failed ones gave up at either step. Errors keeps the first 100
+// failures
+type JobStatus struct {
+	ID       string     `json:"id"`
+	State    JobState   `json:"state"`
+	Accepted int        `json:"accepted"`
+	Embedded int        `json:"embedded"`
+	Indexed  int        `json:"indexed"`
+	Failed   int        `json:"failed"`
+	Errors   []JobError `json:"errors,omitempty"`
+	Created  time.Time  `json:"created"`
+	Finished time.Time  `json:"finished,omitzero"`
+}
+
+// JobConfig configures a JobManager, zero values pick the defaults
+type JobConfig struct {
+	// Concurrency is the most inserts of one job in flight, default 8. A Pool as the Inserter batches them
+	Concurrency int
+	// Retention is how long a finished job can still be polled, default 1 hour
+	Retention time.Duration
+}
+
+// JobManager runs bulk inserts in the background: Submit returns a job id at once, the items go
+// through the Inserter while clients poll Status, and Cancel stops a job between items. Jobs live in
+// memory, a restart loses them (and the items not inserted yet)
+type JobManager struct {
+	ins Inserter
+	cfg JobConfig
+	ids IDGenerator
+	now func() time.Time
+
+	mu     sync.Mutex
+	jobs   map[string]*job
+	closed bool
+	wg     sync.WaitGroup
+}
+
+type job struct {
+	status JobStatus // guarded by JobManager.mu
+	cancel context.CancelFunc
+}
+
+func NewJobManager(ins Inserter, cfg JobConfig) (*JobManager, error) {
+	if ins == nil {
+		return nil, errors.New("inserter is required")
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = defaultJobConcurrency
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = defaultJobRetention
+	}
+	return &JobManager{ins: ins, cfg: cfg, ids: &UUIDv7Generator{}, now: time.Now, jobs: make(map[string]*job)}, nil
+}
+
+// Submit starts a job for items and returns its id
+func (m *JobManager) Submit(items []JobItem) (string, error) {
+	if len(items) == 0 {
+		return "", errors.New("job has no items")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return "", errors.New("job manager is closed")
+	}
+	m.expire()
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &job{
+		status: JobStatus{ID: m.ids.NewID(), State: JobRunning, Accepted: len(items), Created: m.now()},
+		cancel: cancel,
+	}
+	for _, item := range items {
+		if item.Vector != nil {
+			j.status.Embedded++
+		}
+	}
+	m.jobs[j.status.ID] = j
+	m.wg.Add(1)
+	go m.run(ctx, j, items)
+	return j.status.ID, nil
+}
+
+// Status returns a snapshot of the job
+func (m *JobManager) Status(id string) (JobStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expire()
+	j, ok := m.jobs[id]
+	if !ok {
+		return JobStatus{}, ErrJobNotFound
+	}
+	status := j.status
+	status.Errors = append([]JobError(nil), j.status.Errors...)
+	return status, nil
+}
+
+// Jobs returns a snapshot of every job still retained, without their errors
+func (m *JobManager) Jobs() []JobStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expire()
+	out := make([]JobStatus, 0, len(m.jobs))
+	for _, j := range m.jobs {
+		status := j.status
+		status.Errors = nil
+		out = append(out, status)
+	}
+	return out
+}
+
+// Cancel stops a running job: no new items start, items in flight finish. Cancelling a finished job
+// is a no-op
+func (m *JobManager) Cancel(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j, ok := m.jobs[id]
+	if !ok {
+		return ErrJobNotFound
+	}
+	if j.status.State == JobRunning {
+		j.status.State = JobCancelled
+		j.cancel()
+	}
+	return nil
+}
+
+// Close cancels every running job and waits for them to stop
+func (m *JobManager) Close() error {
+	m.mu.Lock()
+	m.closed = true
+	for _, j := range m.jobs {
+		if j.status.State == JobRunning {
+			j.status.State = JobCancelled
+			j.cancel()
+		}
+	}
+	m.mu.Unlock()
+	m.wg.Wait()
+	return nil
+}
+
+func (m *JobManager) run(ctx context.Context, j *job, items []JobItem) {
+	defer m.wg.Done()
+	defer j.cancel()
+	slots := make(chan struct{}, m.cfg.Concurrency)
+	var wg sync.WaitGroup
+	for i, item := range items {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			hooked, err := m.insert(ctx, j, item)
+			m.record(ctx, j, i, item.Vector != nil || hooked, err)
+		}()
+	}
+	wg.Wait()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if j.status.State == JobRunning {
+		j.status.State = JobDone
+		if j.status.Failed == j.status.Accepted {
+			j.status.State = JobFailed
+		}
+	}
+	j.status.Finished = m.now()
+}
+
+// insert runs one item, hooked reports whether the embed hook already counted it as embedded
+func (m *JobManager) insert(ctx context.Context, j *job, item JobItem) (hooked bool, err error) {
+	if item.ExternalID != "" {
+		ctx = WithExternalID(ctx, item.ExternalID)
+	}
+	if item.IdempotencyKey != "" {
+		ctx = WithIdempotencyKey(ctx, item.IdempotencyKey)
+	}
+	if item.Vector != nil {
+		_, err = m.ins.InsertPreEmbed(ctx, item.Vector, item.DataType, item.Metric, item.Model)
+		return false, err
+	}
+	var counted atomic.Bool
+	ctx = WithEmbedHook(ctx, func() {
+		if counted.CompareAndSwap(false, true) {
+			m.mu.Lock()
+			j.status.Embedded++
+			m.mu.Unlock()
+		}
+	})
+	_, err = m.ins.Insert(ctx, item.Input)
+	return counted.Load(), err
+}
+
+// record counts the outcome of item i, an item cut short by Cancel isn't a failure. An item not counted
+// as embedded yet (an Inserter without the embed hook, a replay) is counted here unless embedding failed
+func (m *JobManager) record(ctx context.Context, j *job, i int, counted bool, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var embedErr *EmbedError
+	switch {
+	case err == nil:
+		if !counted {
+			j.status.Embedded++
+		}
+		j.status.Indexed++
+		return
+	case ctx.Err() != nil && errors.Is(err, ctx.Err()):
+		return
+	case !counted && !errors.As(err, &embedErr):
+		j.status.Embedded++
+	}
+	j.status.Failed++
+	if len(j.status.Errors) < maxJobErrors {
+		j.status.Errors = append(j.status.Errors, JobError{Item: i, Message: err.Error()})
+	}
+}
+
+// expire drops the jobs finished longer than Retention ago, m.mu must be held
+func (m *JobManager) expire() {
+	cutoff := m.now().Add(-m.cfg.Retention)
+	for id, j := range m.jobs {
+		if j.status.State != JobRunning && !j.status.Finished.IsZero() && j.status.Finished.Before(cutoff) {
+			delete(m.jobs, id)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package ingest
+
+import (
+	"VectorDatabase/internal/types"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// embeddedInserter embeds at once (running the embed hook) and stores once gate is closed
+type embeddedInserter struct {
+	gate chan struct{}
+}
+
+func (e *embeddedInserter) Insert(ctx context.Context, _ any) (InsertResult, error) {
+	embedded(ctx)
+	<-e.gate
+	return InsertResult{ExternalId: "id"}, nil
+}
+
+func (e *embeddedInserter) InsertPreEmbed(ctx context.Context, _ []float32, _ types.DataType, _ types.SimilarityMetric, _ string) (InsertResult, error) {
+	<-e.gate
+	return InsertResult{ExternalId: "id"}, nil
+}
+
+// waitJob polls the job until it leaves JobRunning
+func waitJob(t *testing.T, m *JobManager, id string) JobStatus {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		status, err := m.Status(id)
+		if err != nil {
+			t.Fatalf("Status failed: %v", err)
+		}
+		if status.State != JobRunning && !status.Finished.IsZero() {
+			return status
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s didn't finish", id)
+	return JobStatus{}
+}
+
+// Post-condition: a finished job counts every item: embedding failures as failed only, index failures as
+// embedded and failed, the rest as embedded and indexed, with the failing items in Errors.
+func TestJobManager_Counts(t *testing.T) {
+	e := hashingEmbedder(t, 4)
+	ing, idx := newTestIngester(t, e)
+	m, _ := NewJobManager(ing, JobConfig{Concurrency: 2})
+	defer m.Close()
+	tests := []struct {
+		name  string
+		items []JobItem
+		want  JobStatus
+	}{
+		{"text", []JobItem{{Input: "alpha"}, {Input: "beta"}, {Input: "gamma", ExternalID: "g"}},
+			JobStatus{State: JobDone, Accepted: 3, Embedded: 3, Indexed: 3}},
+		{"pre-embedded", []JobItem{{Vector: []float32{1, 2, 3, 4}, DataType: types.Text, Metric: types.Cosine, Model: e.Name()}},
+			JobStatus{State: JobDone, Accepted: 1, Embedded: 1, Indexed: 1}},
+		{"partial", []JobItem{{Input: "delta"}, {Input: ""}, {Vector: []float32{0, 0, 0, 0}, DataType: types.Text, Metric: types.Cosine, Model: e.Name()}},
+			JobStatus{State: JobDone, Accepted: 3, Embedded: 2, Indexed: 1, Failed: 2}},
+		{"all failed", []JobItem{{Input: ""}, {Input: ""}},
+			JobStatus{State: JobFailed, Accepted: 2, Failed: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := m.Submit(tt.items)
+			if err != nil {
+				t.Fatalf("Submit failed: %v", err)
+			}
+			got := waitJob(t, m, id)
+			if got.State != tt.want.State || got.Accepted != tt.want.Accepted || got.Embedded != tt.want.Embedded ||
+				got.Indexed != tt.want.Indexed || got.Failed != tt.want.Failed {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+			if len(got.Errors) != tt.want.Failed {
+				t.Errorf("expected %d errors, got %v", tt.want.Failed, got.Errors)
+			}
+		})
+	}
+	if _, ok := idx.Get("g"); !ok {
+		t.Error("external id not passed on")
+	}
+	if _, err := m.Submit(nil); err == nil {
+		t.Error("expected an empty job to fail")
+	}
+}
+
+// Post-condition: while a job runs Embedded counts pre-embedded items from the submit and the others as
+// soon as they are embedded, ahead of Indexed.
+func TestJobManager_EmbedProgress(t *testing.T) {
+	ins := &embeddedInserter{gate: make(chan struct{})}
+	m, _ := NewJobManager(ins, JobConfig{Concurrency: 4})
+	defer m.Close()
+	id, _ := m.Submit([]JobItem{{Input: "a"}, {Input: "b"}, {Vector: []float32{1, 0}}})
+	deadline := time.Now().Add(5 * time.Second)
+	var status JobStatus
+	for time.Now().Before(deadline) {
+		status, _ = m.Status(id)
+		if status.Embedded == 3 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if status.Embedded != 3 || status.Indexed != 0 || status.State != JobRunning {
+		t.Errorf("expected 3 embedded and none indexed while storing is held, got %+v", status)
+	}
+	close(ins.gate)
+	if got := waitJob(t, m, id); got.Embedded != 3 || got.Indexed != 3 {
+		t.Errorf("expected every item counted once, got %+v", got)
+	}
+}
+
+// Contract: Cancel stops a job between items and leaves it cancelled, the items never started aren't
+// counted; unknown ids are ErrJobNotFound.
+func TestJobManager_Cancel(t *testing.T) {
+	next := &flakyInserter{delay: 20 * time.Millisecond}
+	m, _ := NewJobManager(next, JobConfig{Concurrency: 1})
+	defer m.Close()
+	id, _ := m.Submit(make([]JobItem, 100))
+	time.Sleep(30 * time.Millisecond)
+	if err := m.Cancel(id); err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+	got := waitJob(t, m, id)
+	if got.State != JobCancelled || got.Indexed == 0 || got.Indexed >= 100 || got.Failed != 0 {
+		t.Errorf("expected a cancelled job part way through, got %+v", got)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if int(next.calls.Load()) != got.Indexed {
+		t.Errorf("items started after the job stopped: %d calls, %d indexed", next.calls.Load(), got.Indexed)
+	}
+	if err := m.Cancel("missing"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("expected ErrJobNotFound, got %v", err)
+	}
+	if _, err := m.Status("missing"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("expected ErrJobNotFound, got %v", err)
+	}
+}
+
+// Contract: finished jobs are dropped after the retention window, running ones never are.
+func TestJobManager_Retention(t *testing.T) {
+	next := &flakyInserter{}
+	m, _ := NewJobManager(next, JobConfig{Retention: time.Minute})
+	defer m.Close()
+	clock := time.Unix(1000, 0)
+	m.now = func() time.Time { return clock }
+	id, _ := m.Submit([]JobItem{{Input: "x"}})
+	waitJob(t, m, id)
+	clock = clock.Add(59 * time.Second)
+	if _, err := m.Status(id); err != nil {
+		t.Errorf("job dropped within the retention window: %v", err)
+	}
+	clock = clock.Add(2 * time.Second)
+	if _, err := m.Status(id); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("expected the job to expire, got %v", err)
+	}
+	if len(m.Jobs()) != 0 {
+		t.Errorf("expected no jobs, got %v", m.Jobs())
+	}
+}
+
+// Post-condition: Close cancels running jobs, waits for them and refuses new ones.
+func TestJobManager_Close(t *testing.T) {
+	next := &flakyInserter{delay: 10 * time.Millisecond}
+	m, _ := NewJobManager(next, JobConfig{Concurrency: 1})
+	id, _ := m.Submit(make([]JobItem, 1000))
+	time.Sleep(20 * time.Millisecond)
+	m.Close()
+	status, err := m.Status(id)
+	if err != nil || status.State != JobCancelled || status.Finished.IsZero() {
+		t.Errorf("expected a stopped cancelled job, got %+v (err %v)", status, err)
+	}
+	if _, err := m.Submit([]JobItem{{Input: "x"}}); err == nil {
+		t.Error("expected Submit after Close to fail")
+	}
+}
@@ -0,0 +1,274 @@
+package ingest
+
+import (
+	"VectorDatabase/internal/embedder"
+	"VectorDatabase/internal/types"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultPoolBatchSize  = 32
+	defaultPoolMaxLatency = 10 * time.Millisecond
+	defaultPoolWorkers    = 4
+	defaultPoolQueueSize  = 1024
+)
+
+// ErrPoolClosed is returned by Insert after Close
+var ErrPoolClosed = errors.New("ingest pool is closed")
+
+// PoolConfig configures a Pool, zero values pick the defaults
+type PoolConfig struct {
+	// BatchSize is the most inputs embedded together, default 32
+	BatchSize int
+	// MaxLatency is how long the first input of a batch waits for more, default 10ms
+	MaxLatency time.Duration
+	// Workers is the most batches embedded at the same time, default 4
+	Workers int
+	// QueueSize is the most inputs waiting for a batch, Insert blocks while the queue is full, default 1024
+	QueueSize int
+}
+
+// Pool is an Inserter grouping concurrent Insert calls into micro-batches: a batch closes when it has
+// BatchSize inputs or its first input waited MaxLatency, and up to Workers batches are embedded at once
+// (with one EmbedBatch call for a BatchEmbedder). When the workers fall behind the queue fills up and
+// Insert blocks, pushing back on callers instead of buffering without bound
+type Pool struct {
+	ing *Ingester
+	cfg PoolConfig
+
+	// mu guards closed, Insert holds it shared while queueing so Close can't close the queue under it
+	mu      sync.RWMutex
+	closed  bool
+	queue   chan *poolRequest
+	batches chan []*poolRequest
+	wg      sync.WaitGroup
+	// ctx ends at Close, cutting short the embedder calls in flight
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+type poolRequest struct {
+	ctx    context.Context
+	input  any
+	result InsertResult
+	err    error
+	done   chan struct{}
+}
+
+// NewPool starts the batching and worker goroutines, Close stops them
+func NewPool(ing *Ingester, cfg PoolConfig) (*Pool, error) {
+	if ing == nil {
+		return nil, errors.New("ingester is required")
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultPoolBatchSize
+	}
+	if cfg.MaxLatency <= 0 {
+		cfg.MaxLatency = defaultPoolMaxLatency
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = defaultPoolWorkers
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultPoolQueueSize
+	}
+	p := &Pool{
+		ing:     ing,
+		cfg:     cfg,
+		queue:   make(chan *poolRequest, cfg.QueueSize),
+		batches: make(chan []*poolRequest),
+	}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	p.wg.Add(1 + cfg.Workers)
+	go p.batch()
+	for range cfg.Workers {
+		go p.work()
+	}
+	return p, nil
+}
+
+// Insert queues inputData and waits for its batch. When ctx ends first Insert returns ctx.Err(), an
+// input already embedding may still be inserted
+func (p *Pool) Insert(ctx context.Context, inputData any) (InsertResult, error) {
+	req := &poolRequest{ctx: ctx, input: inputData, done: make(chan struct{})}
+	if err := p.enqueue(ctx, req); err != nil {
+		return InsertResult{}, err
+	}
+	select {
+	case <-req.done:
+		return req.result, req.err
+	case <-ctx.Done():
+		return InsertResult{}, ctx.Err()
+	}
+}
+
+func (p *Pool) enqueue(ctx context.Context, req *poolRequest) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrPoolClosed
+	}
+	select {
+	case p.queue <- req:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// InsertPreEmbed needs no embedder, it goes straight to the Ingester
+func (p *Pool) InsertPreEmbed(
+	ctx context.Context,
+	vec []float32,
+	inputDataType types.DataType,
+	simMetric types.SimilarityMetric,
+	model string) (
+	InsertResult, error) {
+	return p.ing.InsertPreEmbed(ctx, vec, inputDataType, simMetric, model)
+}
+
+// Close stops accepting inputs and cancels the embedder calls in flight, inputs not embedded yet fail
+// with ErrPoolClosed. It returns once the workers stopped
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.queue)
+	p.mu.Unlock()
+	p.cancel()
+	p.wg.Wait()
+	return nil
+}
+
+// batch cuts the queue into batches for the workers, it blocks while every worker is busy
+func (p *Pool) batch() {
+	defer p.wg.Done()
+	defer close(p.batches)
+	for req := range p.queue {
+		batch := []*poolRequest{req}
+		timer := time.NewTimer(p.cfg.MaxLatency)
+		open := true
+		for open && len(batch) < p.cfg.BatchSize {
+			select {
+			case next, ok := <-p.queue:
+				if !ok {
+					open = false
+					break
+				}
+				batch = append(batch, next)
+			case <-timer.C:
+				open = false
+			}
+		}
+		timer.Stop()
+		p.batches <- batch
+	}
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+	for batch := range p.batches {
+		p.process(batch)
+	}
+}
+
+// process embeds and inserts one batch. Callers that gave up are skipped, the embedder call ends at Close
+// or once every caller of the batch gave up. When the batch call fails the inputs are retried one by one
+func (p *Pool) process(batch []*poolRequest) {
+	live := batch[:0]
+	for _, req := range batch {
+		switch {
+		case p.ctx.Err() != nil:
+			req.finish(InsertResult{}, ErrPoolClosed)
+		case req.ctx.Err() != nil:
+			req.finish(InsertResult{}, req.ctx.Err())
+		default:
+			live = append(live, req)
+		}
+	}
+	if len(live) == 0 {
+		return
+	}
+	inputs := make([]any, len(live))
+	for i, req := range live {
+		inputs[i] = req.input
+	}
+	ctx, cancel := p.embedContext(live)
+	vecs, err := embedder.EmbedAll(ctx, p.ing.embedder, inputs)
+	cut := ctx.Err() != nil
+	cancel()
+	if err != nil && len(live) > 1 && !cut {
+		// one bad input fails the whole batch call, embed one by one so it only fails its own caller
+		for _, req := range live {
+			ctx, cancel := p.embedContext([]*poolRequest{req})
+			vec, err := p.ing.embedder.Embed(ctx, req.input)
+			cancel()
+			if err != nil {
+				req.finish(InsertResult{}, p.embedError(req, err))
+				continue
+			}
+			embedded(req.ctx)
+			req.finish(p.ing.add(req.ctx, vec, nil))
+		}
+		return
+	}
+	if err != nil {
+		for _, req := range live {
+			req.finish(InsertResult{}, p.embedError(req, err))
+		}
+		return
+	}
+	for _, req := range live {
+		embedded(req.ctx)
+	}
+	for i, req := range live {
+		req.finish(p.ing.add(req.ctx, vecs[i], nil))
+	}
+}
+
+// embedContext is the context of an embedder call for reqs, it ends at Close or once every caller in reqs
+// gave up
+func (p *Pool) embedContext(reqs []*poolRequest) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(p.ctx)
+	var waiting atomic.Int32
+	waiting.Store(int32(len(reqs)))
+	stops := make([]func() bool, len(reqs))
+	for i, req := range reqs {
+		stops[i] = context.AfterFunc(req.ctx, func() {
+			if waiting.Add(-1) == 0 {
+				cancel()
+			}
+		})
+	}
+	return ctx, func() {
+		for _, stop := range stops {
+			stop()
+		}
+		cancel()
+	}
+}
+
+// embedError is what the caller of req gets for a failed embedder call
+func (p *Pool) embedError(req *poolRequest, err error) error {
+	switch {
+	case p.ctx.Err() != nil:
+		return ErrPoolClosed
+	case req.ctx.Err() != nil:
+		return req.ctx.Err()
+	}
+	return &EmbedError{err}
+}
+
+func (req *poolRequest) finish(result InsertResult, err error) {
+	req.result, req.err = result, err
+	close(req.done)
+}
+
+var _ Inserter = (*Pool)(nil)
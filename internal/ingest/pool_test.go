@@ -0,0 +1,261 @@
+package ingest
+
+import (
+	"VectorDatabase/internal/embedder"
+	"VectorDatabase/internal/vector"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// gatedEmbedder is a BatchEmbedder over a HashingEmbedder that records batch sizes and the most batches
+// in flight, every EmbedBatch call waits for gate when it is set
+type gatedEmbedder struct {
+	*embedder.HashingEmbedder
+	gate chan struct{}
+
+	mu          sync.Mutex
+	batches     []int
+	inFlight    int
+	maxInFlight int
+}
+
+func (g *gatedEmbedder) EmbedBatch(ctx context.Context, inputs []any) ([]*vector.Vector, error) {
+	g.mu.Lock()
+	g.batches = append(g.batches, len(inputs))
+	g.inFlight++
+	g.maxInFlight = max(g.maxInFlight, g.inFlight)
+	g.mu.Unlock()
+	defer func() {
+		g.mu.Lock()
+		g.inFlight--
+		g.mu.Unlock()
+	}()
+	if g.gate != nil {
+		<-g.gate
+	}
+	return embedder.EmbedAll(ctx, g.HashingEmbedder, inputs)
+}
+
+func newGatedPool(t *testing.T, cfg PoolConfig, gate chan struct{}) (*Pool, *gatedEmbedder) {
+	t.Helper()
+	g := &gatedEmbedder{HashingEmbedder: hashingEmbedder(t, 8), gate: gate}
+	ing, _ := newTestIngester(t, g)
+	p, err := NewPool(ing, cfg)
+	if err != nil {
+		t.Fatalf("NewPool failed: %v", err)
+	}
+	t.Cleanup(func() { p.Close() })
+	return p, g
+}
+
+// insertAll inserts n distinct texts concurrently and returns the errors
+func insertAll(p *Pool, n int) []error {
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = p.Insert(context.Background(), fmt.Sprintf("document number %d", i))
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+// Contract: concurrent inserts are embedded in batches of at most BatchSize, with at most Workers batches in
+// flight, and every input is inserted.
+func TestPool_Batches(t *testing.T) {
+	p, g := newGatedPool(t, PoolConfig{BatchSize: 8, MaxLatency: 50 * time.Millisecond, Workers: 2}, nil)
+	for i, err := range insertAll(p, 100) {
+		if err != nil {
+			t.Fatalf("insert %d failed: %v", i, err)
+		}
+	}
+	if size := p.ing.Index().Size(); size != 100 {
+		t.Errorf("expected 100 vectors, got %d", size)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	total := 0
+	for _, n := range g.batches {
+		if n > 8 {
+			t.Errorf("batch of %d exceeds BatchSize", n)
+		}
+		total += n
+	}
+	if total != 100 || len(g.batches) >= 100 {
+		t.Errorf("expected 100 inputs in fewer than 100 batches, got %v", g.batches)
+	}
+	if g.maxInFlight > 2 {
+		t.Errorf("%d batches in flight with 2 workers", g.maxInFlight)
+	}
+}
+
+// Contract: a lone insert doesn't wait for a full batch, MaxLatency closes the batch.
+func TestPool_MaxLatency(t *testing.T) {
+	p, _ := newGatedPool(t, PoolConfig{BatchSize: 1000, MaxLatency: 5 * time.Millisecond}, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := p.Insert(ctx, "alone"); err != nil {
+		t.Errorf("lone insert failed: %v", err)
+	}
+}
+
+// Contract: when the workers are stuck and the queue is full Insert blocks until its context ends, and
+// proceeds once the workers catch up.
+func TestPool_Backpressure(t *testing.T) {
+	gate := make(chan struct{})
+	p, g := newGatedPool(t, PoolConfig{BatchSize: 1, Workers: 1, QueueSize: 2}, gate)
+	done := make(chan error, 4)
+	// one input held by the worker, one by the batcher, two in the queue
+	for i := range 4 {
+		go func() {
+			_, err := p.Insert(context.Background(), fmt.Sprintf("held %d", i))
+			done <- err
+		}()
+	}
+	full := func() bool {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		return g.inFlight == 1 && len(p.queue) == 2
+	}
+	for !full() {
+		time.Sleep(time.Millisecond)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := p.Insert(ctx, "overflow"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the full queue to block until the deadline, got %v", err)
+	}
+	close(gate)
+	for range 4 {
+		if err := <-done; err != nil {
+			t.Errorf("held insert failed: %v", err)
+		}
+	}
+}
+
+// Contract: a bad input fails only its own insert, the rest of its batch is inserted.
+func TestPool_BadInput(t *testing.T) {
+	p, _ := newGatedPool(t, PoolConfig{BatchSize: 4, MaxLatency: 50 * time.Millisecond}, nil)
+	inputs := []any{"good one", "", "good two", 42}
+	errs := make([]error, len(inputs))
+	var wg sync.WaitGroup
+	for i, input := range inputs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = p.Insert(context.Background(), input)
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if wantErr := i%2 == 1; (err != nil) != wantErr {
+			t.Errorf("input %v: expected error %v, got %v", inputs[i], wantErr, err)
+		}
+	}
+	if size := p.ing.Index().Size(); size != 2 {
+		t.Errorf("expected 2 vectors, got %d", size)
+	}
+}
+
+// Post-condition: Close returns without waiting for MaxLatency, every queued insert either got in or fails
+// with ErrPoolClosed, and later inserts fail with ErrPoolClosed.
+func TestPool_Close(t *testing.T) {
+	ing, idx := newTestIngester(t, hashingEmbedder(t, 10))
+	p, _ := NewPool(ing, PoolConfig{BatchSize: 4, MaxLatency: time.Minute})
+	errs := make(chan error, 3)
+	for i := range 3 {
+		go func() {
+			_, err := p.Insert(context.Background(), fmt.Sprintf("queued %d", i))
+			errs <- err
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	start := time.Now()
+	p.Close()
+	if time.Since(start) > 10*time.Second {
+		t.Error("Close waited for MaxLatency")
+	}
+	inserted := 0
+	for range 3 {
+		err := <-errs
+		if err == nil {
+			inserted++
+		} else if !errors.Is(err, ErrPoolClosed) {
+			t.Errorf("queued insert failed: %v", err)
+		}
+	}
+	if idx.Size() != inserted {
+		t.Errorf("expected the %d queued inserts in the index, got %d", inserted, idx.Size())
+	}
+	if _, err := p.Insert(context.Background(), "late"); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("expected ErrPoolClosed, got %v", err)
+	}
+}
+
+// blockingEmbedder blocks every Embed until its context ends and counts the calls cut short
+type blockingEmbedder struct {
+	*embedder.HashingEmbedder
+	started   chan struct{}
+	cancelled atomic.Int32
+}
+
+func (b *blockingEmbedder) Embed(ctx context.Context, input any) (*vector.Vector, error) {
+	b.started <- struct{}{}
+	<-ctx.Done()
+	b.cancelled.Add(1)
+	return nil, ctx.Err()
+}
+
+// Contract: an embedder call in flight ends once every caller of its batch gave up, and at Close, whose
+// callers get ErrPoolClosed.
+func TestPool_CancelEmbed(t *testing.T) {
+	b := &blockingEmbedder{HashingEmbedder: hashingEmbedder(t, 12), started: make(chan struct{}, 1)}
+	ing, _ := newTestIngester(t, b)
+	p, _ := NewPool(ing, PoolConfig{BatchSize: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		_, err := p.Insert(ctx, "abandoned")
+		errs <- err
+	}()
+	<-b.started
+	cancel()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for b.cancelled.Load() != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if b.cancelled.Load() != 1 {
+		t.Fatal("embedder call kept running after its caller gave up")
+	}
+
+	go func() {
+		_, err := p.Insert(context.Background(), "in flight at close")
+		errs <- err
+	}()
+	<-b.started
+	done := make(chan struct{})
+	go func() {
+		p.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close waited for the embedder call")
+	}
+	if err := <-errs; !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("expected ErrPoolClosed, got %v", err)
+	}
+}
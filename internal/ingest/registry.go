@@ -19,16 +19,19 @@ func NewIndexRegistry(f index.IndexFactory) *indexRegistry {
 	}
 }
 
-func (ir *indexRegistry) GetOrCreateIndex(cfg index.IndexConfig) index.VectorIndex {
+func (ir *indexRegistry) GetOrCreateIndex(cfg index.IndexConfig) (index.VectorIndex, error) {
 	//write lock
 	ir.mu.Lock()
 	defer ir.mu.Unlock()
 	//Return if index Already exists with config locked
 	if idx, ok := ir.registry[cfg]; ok {
-		return idx
+		return idx, nil
 	}
 	//If index doesn't exit create and return new instance of a empty index, unlocked config and add to lookup register
-	idx := ir.factory.CreateIndex(cfg)
+	idx, err := ir.factory.CreateIndex(cfg)
+	if err != nil {
+		return nil, err
+	}
 	ir.registry[cfg] = idx
-	return idx
+	return idx, nil
 }
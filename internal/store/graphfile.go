@@ -0,0 +1,167 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+)
+
+// Graph file layout, one fixed size block per node so a node is a single positioned read:
+//
+//	header (64 bytes): magic "VGRF" | version u16 | reserved u16 | dimension u32 | max degree u32 | zero padding
+//	node block: dimension * float32 | degree u32 | max degree * u32 neighbor slots (unused ones zero)
+const (
+	graphFileMagic   = "VGRF"
+	graphFileVersion = 1
+)
+
+// GraphFile stores the nodes of a graph index on disk, each block holds the full precision vector
+// and the neighbor list of one node
+type GraphFile struct {
+	mu        sync.RWMutex
+	f         *os.File
+	dim       int
+	maxDegree int
+	blockSize int
+	nodes     int
+}
+
+// OpenGraphFile opens (or creates) the graph file at path
+func OpenGraphFile(path string, dim, maxDegree int) (*GraphFile, error) {
+	if dim <= 0 || maxDegree <= 0 {
+		return nil, errors.New("invalid graph file dimension or degree")
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := initFileHeader(f, graphFileMagic, graphFileVersion, dim, maxDegree); err != nil {
+		f.Close()
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	g := &GraphFile{f: f, dim: dim, maxDegree: maxDegree, blockSize: dim*4 + 4 + maxDegree*4}
+	g.nodes = int((info.Size() - vectorHeaderSize) / int64(g.blockSize))
+	return g, nil
+}
+
+func (g *GraphFile) offset(slot int) int64 {
+	return vectorHeaderSize + int64(slot)*int64(g.blockSize)
+}
+
+// WriteNode writes the whole block of slot, slots must be written in order or overwritten
+func (g *GraphFile) WriteNode(slot int, values []float32, neighbors []uint32) error {
+	if len(values) != g.dim {
+		return errors.New("dimension mismatch")
+	}
+	if len(neighbors) > g.maxDegree {
+		return fmt.Errorf("node degree %d exceeds max degree %d", len(neighbors), g.maxDegree)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.f == nil {
+		return errors.New("graph file closed")
+	}
+	if slot < 0 || slot > g.nodes {
+		return fmt.Errorf("graph slot %d out of range", slot)
+	}
+	block := appendValues(make([]byte, 0, g.blockSize), values)
+	block = appendNeighbors(block, neighbors, g.maxDegree)
+	if _, err := g.f.WriteAt(block, g.offset(slot)); err != nil {
+		return err
+	}
+	g.nodes = max(g.nodes, slot+1)
+	return nil
+}
+
+// WriteNeighbors replaces the neighbor list of an existing node
+func (g *GraphFile) WriteNeighbors(slot int, neighbors []uint32) error {
+	if len(neighbors) > g.maxDegree {
+		return fmt.Errorf("node degree %d exceeds max degree %d", len(neighbors), g.maxDegree)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.f == nil {
+		return errors.New("graph file closed")
+	}
+	if slot < 0 || slot >= g.nodes {
+		return fmt.Errorf("graph slot %d out of range", slot)
+	}
+	buf := appendNeighbors(make([]byte, 0, 4+g.maxDegree*4), neighbors, g.maxDegree)
+	_, err := g.f.WriteAt(buf, g.offset(slot)+int64(g.dim*4))
+	return err
+}
+
+func appendNeighbors(buf []byte, neighbors []uint32, maxDegree int) []byte {
+	buf = order.AppendUint32(buf, uint32(len(neighbors)))
+	for _, n := range neighbors {
+		buf = order.AppendUint32(buf, n)
+	}
+	for i := len(neighbors); i < maxDegree; i++ {
+		buf = order.AppendUint32(buf, 0)
+	}
+	return buf
+}
+
+// ReadNode reads the block of slot with one positioned read
+func (g *GraphFile) ReadNode(slot int) ([]float32, []uint32, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.f == nil {
+		return nil, nil, errors.New("graph file closed")
+	}
+	if slot < 0 || slot >= g.nodes {
+		return nil, nil, fmt.Errorf("graph slot %d out of range", slot)
+	}
+	block := make([]byte, g.blockSize)
+	if _, err := g.f.ReadAt(block, g.offset(slot)); err != nil {
+		return nil, nil, err
+	}
+	values := make([]float32, g.dim)
+	for i := range values {
+		values[i] = math.Float32frombits(order.Uint32(block[i*4:]))
+	}
+	rest := block[g.dim*4:]
+	degree := int(order.Uint32(rest))
+	if degree > g.maxDegree {
+		return nil, nil, fmt.Errorf("%w: node %d degree %d exceeds max degree", ErrCorrupt, slot, degree)
+	}
+	neighbors := make([]uint32, degree)
+	for i := range neighbors {
+		neighbors[i] = order.Uint32(rest[4+i*4:])
+	}
+	return values, neighbors, nil
+}
+
+// Nodes is the number of node blocks in the file
+func (g *GraphFile) Nodes() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.nodes
+}
+
+func (g *GraphFile) Sync() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.f == nil {
+		return errors.New("graph file closed")
+	}
+	return g.f.Sync()
+}
+
+func (g *GraphFile) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.f == nil {
+		return nil
+	}
+	err := errors.Join(g.f.Sync(), g.f.Close())
+	g.f = nil
+	return err
+}
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"slices"
+	"testing"
+)
+
+// Invariant: node blocks and neighbor rewrites survive a reopen, the degree is bounded.
+func TestGraphFile_WriteReadReopen(t *testing.T) {
+	path := t.TempDir() + "/graph.vgrf"
+	g, err := OpenGraphFile(path, 2, 3)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	if err := g.WriteNode(0, []float32{1, 0}, nil); err != nil {
+		t.Fatalf("write node 0 failed: %v", err)
+	}
+	if err := g.WriteNode(1, []float32{0, 1}, []uint32{0}); err != nil {
+		t.Fatalf("write node 1 failed: %v", err)
+	}
+	if err := g.WriteNode(3, []float32{0, 1}, nil); err == nil {
+		t.Error("expected error writing past the next slot")
+	}
+	if err := g.WriteNeighbors(0, []uint32{1, 2, 3, 4}); err == nil {
+		t.Error("expected error exceeding the max degree")
+	}
+	if err := g.WriteNeighbors(0, []uint32{1}); err != nil {
+		t.Fatalf("write neighbors failed: %v", err)
+	}
+	g.Close()
+
+	if _, err := OpenGraphFile(path, 2, 4); err == nil {
+		t.Error("expected error reopening with another max degree")
+	}
+	g, err = OpenGraphFile(path, 2, 3)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer g.Close()
+	if g.Nodes() != 2 {
+		t.Fatalf("expected 2 nodes, got %d", g.Nodes())
+	}
+	values, neighbors, err := g.ReadNode(0)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if !slices.Equal(values, []float32{1, 0}) || !slices.Equal(neighbors, []uint32{1}) {
+		t.Errorf("unexpected node 0: %v %v", values, neighbors)
+	}
+	if _, _, err := g.ReadNode(2); err == nil {
+		t.Error("expected error reading a missing node")
+	}
+}
@@ -0,0 +1,223 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"sync"
+)
+
+// IDLog persists the mapping between external ids and the fixed slots of a slot addressed file
+// (vector file, graph file) as an append only log of frames:
+//
+//	crc32 (IEEE) of the rest u32 | op u8 | id (u16 len + bytes) | slot u64
+//
+// Slots are handed out in order and never reused, a deleted slot keeps an empty id
+type IDLog struct {
+	mu sync.RWMutex
+	f  *os.File
+	// slot -> id, "" for deleted slots
+	ids   []string
+	slots map[string]int
+}
+
+const (
+	idLogAdd           = 1
+	idLogDelete        = 2
+	idLogFrameOverhead = 4 + 1 + 2 + 8
+)
+
+// OpenIDLog replays the log at path (creating it when missing), a torn last frame from a crash is cut off.
+// A bad frame with more of the log after it is ErrCorrupt, cutting it off would lose the later mappings
+func OpenIDLog(path string) (*IDLog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	l := &IDLog{slots: make(map[string]int)}
+	valid := 0
+	for rest := data; len(rest) > 0; {
+		frame, n, err := decodeIDFrame(rest)
+		if errors.Is(err, errTornIDFrame) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w at offset %d", err, valid)
+		}
+		if err := l.apply(frame); err != nil {
+			return nil, err
+		}
+		valid += n
+		rest = rest[n:]
+	}
+	l.f, err = os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.f.Truncate(int64(valid)); err != nil {
+		l.f.Close()
+		return nil, err
+	}
+	if _, err := l.f.Seek(int64(valid), io.SeekStart); err != nil {
+		l.f.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+type idFrame struct {
+	op   uint8
+	id   string
+	slot int
+}
+
+func encodeIDFrame(f idFrame) []byte {
+	body := []byte{f.op}
+	body = appendString(body, f.id)
+	body = order.AppendUint64(body, uint64(f.slot))
+	return append(order.AppendUint32(nil, crc32.ChecksumIEEE(body)), body...)
+}
+
+// errTornIDFrame marks an id log ending in an incomplete frame, what a crash in the middle of an append leaves
+var errTornIDFrame = errors.New("torn id log tail")
+
+// decodeIDFrame returns the frame at the start of buf and its size. A frame that runs to the end of buf
+// and doesn't check out (short, or not fully written) is errTornIDFrame, a bad one before the end ErrCorrupt
+func decodeIDFrame(buf []byte) (idFrame, int, error) {
+	if len(buf) < idLogFrameOverhead {
+		return idFrame{}, 0, errTornIDFrame
+	}
+	idLen := int(order.Uint16(buf[5:]))
+	size := idLogFrameOverhead + idLen
+	if len(buf) < size {
+		return idFrame{}, 0, errTornIDFrame
+	}
+	slot := order.Uint64(buf[7+idLen:])
+	if crc32.ChecksumIEEE(buf[4:size]) != order.Uint32(buf) || slot > math.MaxInt32 {
+		if len(buf) == size {
+			return idFrame{}, 0, errTornIDFrame
+		}
+		return idFrame{}, 0, fmt.Errorf("%w: id log checksum mismatch", ErrCorrupt)
+	}
+	return idFrame{op: buf[4], id: string(buf[7 : 7+idLen]), slot: int(slot)}, size, nil
+}
+
+func (l *IDLog) apply(f idFrame) error {
+	switch f.op {
+	case idLogAdd:
+		if f.slot != len(l.ids) {
+			return fmt.Errorf("%w: id log slot %d out of order", ErrCorrupt, f.slot)
+		}
+		l.ids = append(l.ids, f.id)
+		l.slots[f.id] = f.slot
+	case idLogDelete:
+		if f.slot >= len(l.ids) || l.ids[f.slot] != f.id {
+			return fmt.Errorf("%w: id log deletes unknown slot %d", ErrCorrupt, f.slot)
+		}
+		l.ids[f.slot] = ""
+		delete(l.slots, f.id)
+	default:
+		return fmt.Errorf("%w: unknown id log op %d", ErrCorrupt, f.op)
+	}
+	return nil
+}
+
+func (l *IDLog) write(f idFrame) error {
+	if l.f == nil {
+		return errors.New("id log closed")
+	}
+	if _, err := l.f.Write(encodeIDFrame(f)); err != nil {
+		return err
+	}
+	return l.apply(f)
+}
+
+// Add assigns the next slot to id
+func (l *IDLog) Add(id string) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := CheckID(id); err != nil {
+		return 0, err
+	}
+	if _, ok := l.slots[id]; ok {
+		return 0, fmt.Errorf("id %q already mapped", id)
+	}
+	slot := len(l.ids)
+	return slot, l.write(idFrame{op: idLogAdd, id: id, slot: slot})
+}
+
+// Delete unmaps id and returns the slot it had
+func (l *IDLog) Delete(id string) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	slot, ok := l.slots[id]
+	if !ok {
+		return 0, fmt.Errorf("id %q not mapped", id)
+	}
+	return slot, l.write(idFrame{op: idLogDelete, id: id, slot: slot})
+}
+
+func (l *IDLog) Slot(id string) (int, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	slot, ok := l.slots[id]
+	return slot, ok
+}
+
+// ID returns the id of slot, false for deleted or unknown slots
+func (l *IDLog) ID(slot int) (string, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if slot < 0 || slot >= len(l.ids) || l.ids[slot] == "" {
+		return "", false
+	}
+	return l.ids[slot], true
+}
+
+// Len is the number of mapped ids
+func (l *IDLog) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.slots)
+}
+
+// Slots is the number of slots ever handed out, deleted ones included
+func (l *IDLog) Slots() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.ids)
+}
+
+// Range visits mapped ids in slot order
+func (l *IDLog) Range(fn func(slot int, id string) bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for slot, id := range l.ids {
+		if id != "" && !fn(slot, id) {
+			return
+		}
+	}
+}
+
+func (l *IDLog) Sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return errors.New("id log closed")
+	}
+	return l.f.Sync()
+}
+
+func (l *IDLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return nil
+	}
+	err := errors.Join(l.f.Sync(), l.f.Close())
+	l.f = nil
+	return err
+}
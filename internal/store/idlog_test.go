@@ -0,0 +1,69 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Helper to write an id log mapping ids to slots 0.. and return its path and frame size
+func setupIDLog(t *testing.T, ids ...string) (string, int) {
+	path := filepath.Join(t.TempDir(), "graph.ids")
+	l, err := OpenIDLog(path)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	for _, id := range ids {
+		if _, err := l.Add(id); err != nil {
+			t.Fatalf("add %s failed: %v", id, err)
+		}
+	}
+	l.Close()
+	return path, idLogFrameOverhead + len(ids[0])
+}
+
+// Contract: only a bad last frame is a torn tail and cut off, a bad frame followed by complete frames
+// is ErrCorrupt and the log is left as it was.
+func TestIDLog_Corruption(t *testing.T) {
+	tests := []struct {
+		name    string
+		damage  func(data []byte, frame int) []byte
+		corrupt bool
+		want    int
+	}{
+		{"short last frame", func(data []byte, _ int) []byte { return data[:len(data)-3] }, false, 2},
+		{"bad last frame", func(data []byte, _ int) []byte { data[len(data)-1] ^= 0xff; return data }, false, 2},
+		{"bad middle frame", func(data []byte, frame int) []byte { data[frame+7] ^= 0xff; return data }, true, 0},
+		{"bad first frame", func(data []byte, _ int) []byte { data[0] ^= 0xff; return data }, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, frame := setupIDLog(t, "id-a", "id-b", "id-c")
+			data, _ := os.ReadFile(path)
+			damaged := tt.damage(data, frame)
+			os.WriteFile(path, damaged, 0o644)
+
+			l, err := OpenIDLog(path)
+			if tt.corrupt {
+				if !errors.Is(err, ErrCorrupt) {
+					t.Fatalf("expected ErrCorrupt, got %v", err)
+				}
+				if after, _ := os.ReadFile(path); len(after) != len(damaged) {
+					t.Errorf("corrupt log was cut to %d bytes", len(after))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("open failed: %v", err)
+			}
+			defer l.Close()
+			if l.Len() != tt.want {
+				t.Errorf("expected %d ids, got %d", tt.want, l.Len())
+			}
+			if info, _ := os.Stat(path); info.Size() != int64(tt.want*frame) {
+				t.Errorf("expected the torn tail cut off at %d, size %d", tt.want*frame, info.Size())
+			}
+		})
+	}
+}
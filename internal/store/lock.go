@@ -0,0 +1,42 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// LockFileName is the file a collection directory is locked through
+const LockFileName = "LOCK"
+
+// ErrLocked is returned by LockDir while another process (or another open in this one) holds the lock
+var ErrLocked = errors.New("collection is locked by another process")
+
+// DirLock is an exclusive advisory lock on a collection directory, only one owner may write its files
+type DirLock struct {
+	f *os.File
+}
+
+// LockDir takes the lock of dir without waiting, ErrLocked when it is held.
+// The lock goes away with the process, a crash never leaves it behind
+func LockDir(dir string) (*DirLock, error) {
+	f, err := os.OpenFile(filepath.Join(dir, LockFileName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &DirLock{f: f}, nil
+}
+
+// Unlock releases the lock, the LOCK file stays
+func (l *DirLock) Unlock() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package store
+
+import "os"
+
+// lockFile can't lock without flock, collections aren't protected from a second process there
+func lockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ManifestFileName is the manifest file name inside a collection directory
+const ManifestFileName = "MANIFEST"
+
+const manifestVersion = 1
+
+// SegmentInfo describes one live segment file of a collection
+type SegmentInfo struct {
+	ID         uint64 `json:"id"`
+	File       string `json:"file"`
+	MinLSN     uint64 `json:"min_lsn"`
+	MaxLSN     uint64 `json:"max_lsn"`
+	Entries    int    `json:"entries"`
+	Tombstones int    `json:"tombstones"`
+}
+
+// Manifest is the source of truth for which segments make up a collection, oldest segment first.
+// Every WAL record up to FlushedLSN is contained in the segments
+type Manifest struct {
+	Version     int             `json:"version"`
+	Config      json.RawMessage `json:"config"`
+	Segments    []SegmentInfo   `json:"segments"`
+	NextSegment uint64          `json:"next_segment"`
+	FlushedLSN  uint64          `json:"flushed_lsn"`
+}
+
+// ReadManifest loads the manifest of the collection in dir, os.ErrNotExist for a new collection
+func ReadManifest(dir string) (Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFileName))
+	if err != nil {
+		return Manifest{}, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return Manifest{}, fmt.Errorf("%w: invalid manifest: %v", ErrCorrupt, err)
+	}
+	if m.Version != manifestVersion {
+		return Manifest{}, fmt.Errorf("unsupported manifest version %d", m.Version)
+	}
+	return m, nil
+}
+
+// EncodeManifest returns the MANIFEST file content of m
+func EncodeManifest(m Manifest) ([]byte, error) {
+	m.Version = manifestVersion
+	return json.MarshalIndent(m, "", "  ")
+}
+
+// WriteManifest atomically replaces the manifest of the collection in dir
+func WriteManifest(dir string, m Manifest) error {
+	data, err := EncodeManifest(m)
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(filepath.Join(dir, ManifestFileName), data)
+}
+
+// RemoveOrphanSegments deletes segment files and segment index directories (and leftover temp files) the
+// manifest doesn't list, they come from a flush or compaction interrupted before its manifest update
+func RemoveOrphanSegments(dir string, m Manifest) error {
+	live := make(map[string]bool, 2*len(m.Segments))
+	for _, seg := range m.Segments {
+		live[seg.File] = true
+		live[SegmentIndexDir(seg.ID)] = true
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, e := range entries {
+		name := e.Name()
+		segFile := strings.HasSuffix(name, ".vseg") || strings.HasSuffix(name, ".index")
+		orphan := strings.HasPrefix(name, "seg-") && segFile && !live[name]
+		if orphan || strings.HasSuffix(name, ".tmp") {
+			if err := os.RemoveAll(filepath.Join(dir, name)); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
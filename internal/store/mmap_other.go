@@ -0,0 +1,27 @@
+//go:build !unix
+
+package store
+
+import (
+	"io"
+	"os"
+)
+
+// mapFile falls back to reading the file into memory where mmap isn't available
+func mapFile(f *os.File, size int) ([]byte, error) {
+	if size == 0 {
+		return nil, nil
+	}
+	data := make([]byte, size)
+	if _, err := f.ReadAt(data, 0); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return data, nil
+}
+
+func unmapFile([]byte) error {
+	return nil
+}
+
+// the fallback mapping is a private copy, writes must be mirrored into it
+const mapIsShared = false
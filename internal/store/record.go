@@ -0,0 +1,133 @@
+package store
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Record is one stored vector as persisted on disk, metadata is kept as encoded JSON
+// so the store doesn't need to know the index metadata type
+type Record struct {
+	ID       string
+	Values   []float32
+	Metadata []byte
+}
+
+// ErrCorrupt is wrapped by every error caused by bad bytes on disk (checksum, framing, bounds)
+var ErrCorrupt = errors.New("corrupt data")
+
+// MaxIDLen is the longest id the on-disk formats can hold, ids are stored with a u16 length
+const MaxIDLen = math.MaxUint16
+
+// ErrIDTooLong is returned for ids over MaxIDLen bytes, nothing is written for them
+var ErrIDTooLong = errors.New("vector id too long")
+
+// CheckID rejects ids the on-disk formats can't hold
+func CheckID(id string) error {
+	if id == "" {
+		return errors.New("vector id empty")
+	}
+	if len(id) > MaxIDLen {
+		return fmt.Errorf("%w: %d bytes, at most %d", ErrIDTooLong, len(id), MaxIDLen)
+	}
+	return nil
+}
+
+// all multi byte values are little endian
+var order = binary.LittleEndian
+
+func appendString(buf []byte, s string) []byte {
+	buf = order.AppendUint16(buf, uint16(len(s)))
+	return append(buf, s...)
+}
+
+func appendValues(buf []byte, values []float32) []byte {
+	for _, val := range values {
+		buf = order.AppendUint32(buf, math.Float32bits(val))
+	}
+	return buf
+}
+
+func appendBytes(buf []byte, b []byte) []byte {
+	buf = order.AppendUint32(buf, uint32(len(b)))
+	return append(buf, b...)
+}
+
+// decoder reads the encoding above from a byte slice, the first out of bounds read sets err
+// and every later read becomes a no-op
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(d.buf) {
+		d.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *decoder) uint8() uint8 {
+	b := d.take(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (d *decoder) uint16() uint16 {
+	b := d.take(2)
+	if b == nil {
+		return 0
+	}
+	return order.Uint16(b)
+}
+
+func (d *decoder) uint32() uint32 {
+	b := d.take(4)
+	if b == nil {
+		return 0
+	}
+	return order.Uint32(b)
+}
+
+func (d *decoder) uint64() uint64 {
+	b := d.take(8)
+	if b == nil {
+		return 0
+	}
+	return order.Uint64(b)
+}
+
+func (d *decoder) string() string {
+	return string(d.take(int(d.uint16())))
+}
+
+func (d *decoder) values(n int) []float32 {
+	b := d.take(n * 4)
+	if b == nil {
+		return nil
+	}
+	values := make([]float32, n)
+	for i := range values {
+		values[i] = math.Float32frombits(order.Uint32(b[i*4:]))
+	}
+	return values
+}
+
+func (d *decoder) bytes() []byte {
+	b := d.take(int(d.uint32()))
+	if len(b) == 0 {
+		return nil
+	}
+	return append([]byte(nil), b...)
+}
@@ -0,0 +1,169 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+)
+
+// Segment file layout:
+//
+//	magic "VSEG" | version u16 | dimension u32 | entries u32 | tombstones u32 | minLSN u64 | maxLSN u64
+//	entries:    id (u16 len + bytes) | dimension * float32 | metadata (u32 len + bytes)
+//	tombstones: id (u16 len + bytes)
+//	crc32 (IEEE) of everything above
+const (
+	segmentMagic   = "VSEG"
+	segmentVersion = 1
+)
+
+// SegmentData is the content of one sealed, immutable segment.
+// Tombstones hide the same ids in older segments, entries win over tombstones within a segment
+type SegmentData struct {
+	Dimension  int
+	MinLSN     uint64
+	MaxLSN     uint64
+	Entries    []Record
+	Tombstones []string
+}
+
+// SegmentFileName is the file name of segment id inside a collection directory
+func SegmentFileName(id uint64) string {
+	return fmt.Sprintf("seg-%06d.vseg", id)
+}
+
+// SegmentIndexDir is the directory of the disk resident sub-index of segment id
+func SegmentIndexDir(id uint64) string {
+	return fmt.Sprintf("seg-%06d.index", id)
+}
+
+func encodeSegment(seg SegmentData) ([]byte, error) {
+	if seg.Dimension <= 0 {
+		return nil, errors.New("invalid segment dimension")
+	}
+	buf := []byte(segmentMagic)
+	buf = order.AppendUint16(buf, segmentVersion)
+	buf = order.AppendUint32(buf, uint32(seg.Dimension))
+	buf = order.AppendUint32(buf, uint32(len(seg.Entries)))
+	buf = order.AppendUint32(buf, uint32(len(seg.Tombstones)))
+	buf = order.AppendUint64(buf, seg.MinLSN)
+	buf = order.AppendUint64(buf, seg.MaxLSN)
+	for _, rec := range seg.Entries {
+		if len(rec.Values) != seg.Dimension {
+			return nil, fmt.Errorf("record %q dimension mismatch", rec.ID)
+		}
+		if err := CheckID(rec.ID); err != nil {
+			return nil, err
+		}
+		buf = appendString(buf, rec.ID)
+		buf = appendValues(buf, rec.Values)
+		buf = appendBytes(buf, rec.Metadata)
+	}
+	for _, id := range seg.Tombstones {
+		if err := CheckID(id); err != nil {
+			return nil, err
+		}
+		buf = appendString(buf, id)
+	}
+	return order.AppendUint32(buf, crc32.ChecksumIEEE(buf)), nil
+}
+
+func decodeSegment(data []byte) (SegmentData, error) {
+	if len(data) < len(segmentMagic)+4 || string(data[:len(segmentMagic)]) != segmentMagic {
+		return SegmentData{}, fmt.Errorf("%w: not a segment file", ErrCorrupt)
+	}
+	body, sum := data[:len(data)-4], order.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(body) != sum {
+		return SegmentData{}, fmt.Errorf("%w: segment checksum mismatch", ErrCorrupt)
+	}
+	d := &decoder{buf: body[len(segmentMagic):]}
+	if version := d.uint16(); version != segmentVersion {
+		return SegmentData{}, fmt.Errorf("unsupported segment version %d", version)
+	}
+	seg := SegmentData{Dimension: int(d.uint32())}
+	entries, tombstones := int(d.uint32()), int(d.uint32())
+	seg.MinLSN, seg.MaxLSN = d.uint64(), d.uint64()
+	if d.err == nil && (seg.Dimension <= 0 || entries > len(d.buf) || tombstones > len(d.buf)) {
+		return SegmentData{}, fmt.Errorf("%w: invalid segment header", ErrCorrupt)
+	}
+	seg.Entries = make([]Record, 0, entries)
+	for i := 0; i < entries && d.err == nil; i++ {
+		seg.Entries = append(seg.Entries, Record{ID: d.string(), Values: d.values(seg.Dimension), Metadata: d.bytes()})
+	}
+	seg.Tombstones = make([]string, 0, tombstones)
+	for i := 0; i < tombstones && d.err == nil; i++ {
+		seg.Tombstones = append(seg.Tombstones, d.string())
+	}
+	if d.err != nil {
+		return SegmentData{}, fmt.Errorf("%w: truncated segment: %v", ErrCorrupt, d.err)
+	}
+	if len(d.buf) != 0 {
+		return SegmentData{}, fmt.Errorf("%w: trailing bytes in segment", ErrCorrupt)
+	}
+	return seg, nil
+}
+
+// WriteSegment durably writes seg to path, a crash never leaves a partial file under path
+func WriteSegment(path string, seg SegmentData) error {
+	data, err := encodeSegment(seg)
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(path, data)
+}
+
+// ReadSegment reads and verifies a segment file
+func ReadSegment(path string) (SegmentData, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return SegmentData{}, err
+	}
+	seg, err := decodeSegment(data)
+	if err != nil {
+		return SegmentData{}, fmt.Errorf("segment %s: %w", filepath.Base(path), err)
+	}
+	return seg, nil
+}
+
+// WriteFileAtomic writes to a temp file, syncs it and renames it over path, a crash leaves the old
+// file or the new one, never a partial one
+func WriteFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// persists the directory entry after a rename or create
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	// not every platform can fsync a directory, the rename itself already happened
+	d.Sync()
+	return nil
+}
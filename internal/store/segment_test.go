@@ -0,0 +1,83 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Invariant: a written segment reads back identically.
+func TestSegment_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), SegmentFileName(1))
+	seg := SegmentData{
+		Dimension: 2,
+		MinLSN:    3,
+		MaxLSN:    9,
+		Entries: []Record{
+			{ID: "vec-1", Values: []float32{0.6, 0.8}, Metadata: []byte(`{"doc":"a"}`)},
+			{ID: "vec-2", Values: []float32{1, 0}},
+		},
+		Tombstones: []string{"gone"},
+	}
+	if err := WriteSegment(path, seg); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	got, err := ReadSegment(path)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if got.Dimension != 2 || got.MinLSN != 3 || got.MaxLSN != 9 {
+		t.Errorf("header mismatch: %+v", got)
+	}
+	if len(got.Entries) != 2 || got.Entries[0].ID != "vec-1" || got.Entries[0].Values[1] != 0.8 {
+		t.Errorf("entries mismatch: %+v", got.Entries)
+	}
+	if string(got.Entries[0].Metadata) != `{"doc":"a"}` || got.Entries[1].Metadata != nil {
+		t.Errorf("metadata mismatch: %+v", got.Entries)
+	}
+	if len(got.Tombstones) != 1 || got.Tombstones[0] != "gone" {
+		t.Errorf("tombstones mismatch: %v", got.Tombstones)
+	}
+}
+
+// Contract: corrupted or truncated segments are rejected with ErrCorrupt.
+func TestSegment_Corruption(t *testing.T) {
+	path := filepath.Join(t.TempDir(), SegmentFileName(1))
+	WriteSegment(path, SegmentData{Dimension: 1, Entries: []Record{{ID: "vec-1", Values: []float32{1}}}})
+	intact, _ := os.ReadFile(path)
+
+	flipped := append([]byte(nil), intact...)
+	flipped[10] ^= 0xff
+	os.WriteFile(path, flipped, 0o644)
+	if _, err := ReadSegment(path); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("expected corruption error for flipped byte, got %v", err)
+	}
+	os.WriteFile(path, intact[:len(intact)-6], 0o644)
+	if _, err := ReadSegment(path); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("expected corruption error for truncated file, got %v", err)
+	}
+	if err := WriteSegment(path, SegmentData{Dimension: 2, Entries: []Record{{ID: "x", Values: []float32{1}}}}); err == nil {
+		t.Error("expected error writing record with wrong dimension")
+	}
+}
+
+// Post-condition: only files the manifest doesn't list are removed.
+func TestRemoveOrphanSegments(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{SegmentFileName(1), SegmentFileName(2), "MANIFEST.tmp", WALFileName} {
+		os.WriteFile(filepath.Join(dir, name), nil, 0o644)
+	}
+	m := Manifest{Segments: []SegmentInfo{{ID: 1, File: SegmentFileName(1)}}}
+	if err := RemoveOrphanSegments(dir, m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if len(names) != 2 || names[0] != SegmentFileName(1) || names[1] != WALFileName {
+		t.Errorf("unexpected files left: %v", names)
+	}
+}
@@ -0,0 +1,243 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Vector file layout, <name>.vecs:
+//
+//	header (64 bytes): magic "VVEC" | version u16 | reserved u16 | dimension u32 | zero padding
+//	records: dimension * float32 per slot, fixed stride, slot i at 64 + i*stride
+//
+// The file is preallocated in doubling steps and memory mapped, so only touched pages use RAM.
+// The id to slot mapping is persisted next to it in an IDLog, <name>.ids
+const (
+	vectorFileMagic   = "VVEC"
+	vectorFileVersion = 1
+	vectorHeaderSize  = 64
+	minVectorCapacity = 1024
+)
+
+// VectorFile is an on-disk store of fixed dimension float32 vectors addressed by id
+type VectorFile struct {
+	mu       sync.RWMutex
+	dim      int
+	stride   int
+	vecs     *os.File
+	ids      *IDLog
+	data     []byte
+	capacity int
+}
+
+// VectorFilePaths returns the vector and id mapping file paths of name inside dir
+func VectorFilePaths(dir, name string) (string, string) {
+	return filepath.Join(dir, name+".vecs"), filepath.Join(dir, name+".ids")
+}
+
+// OpenVectorFile opens (or creates) the vector file name in dir, an existing file must have dimension dim
+func OpenVectorFile(dir, name string, dim int) (*VectorFile, error) {
+	if dim <= 0 {
+		return nil, errors.New("invalid dimension")
+	}
+	vecPath, idPath := VectorFilePaths(dir, name)
+	vecs, err := os.OpenFile(vecPath, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	vf := &VectorFile{dim: dim, stride: dim * 4, vecs: vecs}
+	if err := initFileHeader(vecs, vectorFileMagic, vectorFileVersion, dim, 0); err != nil {
+		vecs.Close()
+		return nil, err
+	}
+	if vf.ids, err = OpenIDLog(idPath); err != nil {
+		vecs.Close()
+		return nil, err
+	}
+	info, err := vecs.Stat()
+	if err != nil {
+		vf.Close()
+		return nil, err
+	}
+	if err := vf.remap(int((info.Size() - vectorHeaderSize) / int64(vf.stride))); err != nil {
+		vf.Close()
+		return nil, err
+	}
+	if vf.ids.Slots() > vf.capacity {
+		vf.Close()
+		return nil, fmt.Errorf("%w: id mapping references %d slots, vector file holds %d", ErrCorrupt, vf.ids.Slots(), vf.capacity)
+	}
+	return vf, nil
+}
+
+// initFileHeader writes the 64 byte header of a new slot file or checks the one of an existing file:
+// magic | version u16 | reserved u16 | dimension u32 | extra u32 (file specific, 0 when unused)
+func initFileHeader(f *os.File, magic string, version uint16, dim, extra int) error {
+	header := make([]byte, vectorHeaderSize)
+	n, err := f.ReadAt(header, 0)
+	if err == io.EOF && n == 0 {
+		copy(header, magic)
+		order.PutUint16(header[4:], version)
+		order.PutUint32(header[8:], uint32(dim))
+		order.PutUint32(header[12:], uint32(extra))
+		_, err = f.WriteAt(header, 0)
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("%w: file header: %v", ErrCorrupt, err)
+	}
+	if string(header[:4]) != magic {
+		return fmt.Errorf("%w: bad file magic %q", ErrCorrupt, header[:4])
+	}
+	if stored := order.Uint16(header[4:]); stored != version {
+		return fmt.Errorf("unsupported file version %d", stored)
+	}
+	if stored := int(order.Uint32(header[8:])); stored != dim {
+		return fmt.Errorf("file dimension %d doesn't match %d", stored, dim)
+	}
+	if stored := int(order.Uint32(header[12:])); stored != extra {
+		return fmt.Errorf("file header value %d doesn't match %d", stored, extra)
+	}
+	return nil
+}
+
+// remap maps capacity slots of the vector file
+func (vf *VectorFile) remap(capacity int) error {
+	if err := unmapFile(vf.data); err != nil {
+		return err
+	}
+	vf.data = nil
+	data, err := mapFile(vf.vecs, vectorHeaderSize+capacity*vf.stride)
+	if err != nil {
+		return err
+	}
+	vf.data = data
+	vf.capacity = capacity
+	return nil
+}
+
+func (vf *VectorFile) offset(slot int) int {
+	return vectorHeaderSize + slot*vf.stride
+}
+
+// Append stores values under id in the next free slot
+func (vf *VectorFile) Append(id string, values []float32) error {
+	if err := CheckID(id); err != nil {
+		return err
+	}
+	if len(values) != vf.dim {
+		return errors.New("dimension mismatch")
+	}
+	vf.mu.Lock()
+	defer vf.mu.Unlock()
+	if vf.vecs == nil {
+		return errors.New("vector file closed")
+	}
+	if _, ok := vf.ids.Slot(id); ok {
+		return fmt.Errorf("vector %q already stored", id)
+	}
+	slot := vf.ids.Slots()
+	if slot >= vf.capacity {
+		capacity := max(minVectorCapacity, vf.capacity*2)
+		if err := vf.vecs.Truncate(int64(vf.offset(capacity))); err != nil {
+			return err
+		}
+		if err := vf.remap(capacity); err != nil {
+			return err
+		}
+	}
+	buf := appendValues(make([]byte, 0, vf.stride), values)
+	if _, err := vf.vecs.WriteAt(buf, int64(vf.offset(slot))); err != nil {
+		return err
+	}
+	if !mapIsShared {
+		copy(vf.data[vf.offset(slot):], buf)
+	}
+	// the id log entry commits the slot, a crash before it leaves an unused slot
+	_, err := vf.ids.Add(id)
+	return err
+}
+
+// Delete forgets id, its slot is not reused
+func (vf *VectorFile) Delete(id string) error {
+	vf.mu.Lock()
+	defer vf.mu.Unlock()
+	if vf.vecs == nil {
+		return errors.New("vector file closed")
+	}
+	if _, ok := vf.ids.Slot(id); !ok {
+		return errors.New("vector doesn't exist in vector file")
+	}
+	_, err := vf.ids.Delete(id)
+	return err
+}
+
+// decodes slot into dst, which must have room for dim values
+func (vf *VectorFile) read(slot int, dst []float32) {
+	rec := vf.data[vf.offset(slot):][:vf.stride]
+	for i := range dst {
+		dst[i] = math.Float32frombits(order.Uint32(rec[i*4:]))
+	}
+}
+
+// Get returns a copy of the values stored under id
+func (vf *VectorFile) Get(id string) ([]float32, bool) {
+	vf.mu.RLock()
+	defer vf.mu.RUnlock()
+	slot, ok := vf.ids.Slot(id)
+	if !ok {
+		return nil, false
+	}
+	values := make([]float32, vf.dim)
+	vf.read(slot, values)
+	return values, true
+}
+
+// Range visits every stored vector in slot order. values is a scratch buffer reused between calls,
+// so a scan keeps one vector in heap no matter how large the file is; copy it to keep it
+func (vf *VectorFile) Range(fn func(id string, values []float32) bool) {
+	vf.mu.RLock()
+	defer vf.mu.RUnlock()
+	values := make([]float32, vf.dim)
+	vf.ids.Range(func(slot int, id string) bool {
+		vf.read(slot, values)
+		return fn(id, values)
+	})
+}
+
+func (vf *VectorFile) Len() int {
+	return vf.ids.Len()
+}
+
+func (vf *VectorFile) Dimension() int {
+	return vf.dim
+}
+
+// Sync flushes vectors and the id mapping to stable storage
+func (vf *VectorFile) Sync() error {
+	vf.mu.Lock()
+	defer vf.mu.Unlock()
+	if vf.vecs == nil {
+		return errors.New("vector file closed")
+	}
+	return errors.Join(vf.vecs.Sync(), vf.ids.Sync())
+}
+
+func (vf *VectorFile) Close() error {
+	vf.mu.Lock()
+	defer vf.mu.Unlock()
+	if vf.vecs == nil {
+		return nil
+	}
+	errs := []error{unmapFile(vf.data), vf.vecs.Sync(), vf.vecs.Close()}
+	if vf.ids != nil {
+		errs = append(errs, vf.ids.Close())
+	}
+	vf.data, vf.vecs = nil, nil
+	return errors.Join(errs...)
+}
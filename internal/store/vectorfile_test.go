@@ -0,0 +1,83 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+// Invariant: vectors and the id mapping survive a reopen, including growth past the first mapping.
+func TestVectorFile_AppendGetReopen(t *testing.T) {
+	dir := t.TempDir()
+	vf, err := OpenVectorFile(dir, "vectors", 3)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	n := minVectorCapacity + 10
+	for i := 0; i < n; i++ {
+		if err := vf.Append(fmt.Sprintf("vec-%d", i), []float32{float32(i), 1, 2}); err != nil {
+			t.Fatalf("append %d failed: %v", i, err)
+		}
+	}
+	if err := vf.Append("vec-0", []float32{1, 2, 3}); err == nil {
+		t.Error("expected error appending a duplicate id")
+	}
+	if err := vf.Delete("vec-1"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if err := vf.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	vf, err = OpenVectorFile(dir, "vectors", 3)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer vf.Close()
+	if vf.Len() != n-1 {
+		t.Errorf("expected %d vectors, got %d", n-1, vf.Len())
+	}
+	values, ok := vf.Get(fmt.Sprintf("vec-%d", n-1))
+	if !ok || values[0] != float32(n-1) || values[2] != 2 {
+		t.Errorf("unexpected values after reopen: %v", values)
+	}
+	if _, ok := vf.Get("vec-1"); ok {
+		t.Error("deleted vector came back after reopen")
+	}
+	visited := 0
+	vf.Range(func(id string, values []float32) bool {
+		visited++
+		return true
+	})
+	if visited != n-1 {
+		t.Errorf("range visited %d vectors, expected %d", visited, n-1)
+	}
+}
+
+// Contract: the dimension is fixed at creation and a torn id log tail is dropped.
+func TestVectorFile_Recovery(t *testing.T) {
+	dir := t.TempDir()
+	vf, _ := OpenVectorFile(dir, "vectors", 2)
+	vf.Append("vec-1", []float32{1, 0})
+	vf.Append("vec-2", []float32{0, 1})
+	vf.Close()
+
+	if _, err := OpenVectorFile(dir, "vectors", 3); err == nil {
+		t.Error("expected dimension mismatch error")
+	}
+	_, idPath := VectorFilePaths(dir, "vectors")
+	data, _ := os.ReadFile(idPath)
+	os.WriteFile(idPath, data[:len(data)-2], 0o644)
+
+	vf, err := OpenVectorFile(dir, "vectors", 2)
+	if err != nil {
+		t.Fatalf("reopen after torn id log failed: %v", err)
+	}
+	defer vf.Close()
+	if vf.Len() != 1 {
+		t.Errorf("expected the torn entry dropped, got %d vectors", vf.Len())
+	}
+	if err := vf.Append("vec-2", []float32{0, 1}); err != nil {
+		t.Errorf("expected re-append of dropped id to succeed: %v", err)
+	}
+}
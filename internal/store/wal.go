@@ -0,0 +1,220 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+)
+
+// WAL file layout, a sequence of frames:
+//
+//	payload length u32 | crc32 (IEEE) of payload u32 | payload
+//	payload: lsn u64 | op u8 | id | value count u32 | values float32 | metadata (u32 len + bytes)
+const walHeaderSize = 8
+
+// WALFileName is the write ahead log file name inside a collection directory
+const WALFileName = "wal.log"
+
+// WALOp is the kind of mutation a WAL record describes
+type WALOp uint8
+
+const (
+	WALAdd WALOp = iota + 1
+	WALDelete
+)
+
+// WALRecord is one logged mutation, deletes only carry Record.ID
+type WALRecord struct {
+	LSN    uint64
+	Op     WALOp
+	Record Record
+}
+
+// ErrTornWAL marks a WAL ending in an incomplete frame, what a crash in the middle of an append leaves
+var ErrTornWAL = errors.New("torn wal tail")
+
+// WAL is an append only log of mutations not yet flushed to a segment,
+// every record gets the next log sequence number (LSN)
+type WAL struct {
+	mu      sync.Mutex
+	f       *os.File
+	size    int64
+	nextLSN uint64
+	sync    bool
+}
+
+func encodeWALRecord(rec WALRecord) []byte {
+	payload := order.AppendUint64(nil, rec.LSN)
+	payload = append(payload, byte(rec.Op))
+	payload = appendString(payload, rec.Record.ID)
+	payload = order.AppendUint32(payload, uint32(len(rec.Record.Values)))
+	payload = appendValues(payload, rec.Record.Values)
+	payload = appendBytes(payload, rec.Record.Metadata)
+	frame := order.AppendUint32(make([]byte, 0, walHeaderSize+len(payload)), uint32(len(payload)))
+	frame = order.AppendUint32(frame, crc32.ChecksumIEEE(payload))
+	return append(frame, payload...)
+}
+
+func decodeWALPayload(payload []byte) (WALRecord, error) {
+	d := &decoder{buf: payload}
+	rec := WALRecord{LSN: d.uint64(), Op: WALOp(d.uint8())}
+	rec.Record.ID = d.string()
+	n := int(d.uint32())
+	if d.err == nil && n*4 > len(d.buf) {
+		return WALRecord{}, fmt.Errorf("%w: wal value count out of bounds", ErrCorrupt)
+	}
+	rec.Record.Values = d.values(n)
+	rec.Record.Metadata = d.bytes()
+	if d.err != nil || len(d.buf) != 0 {
+		return WALRecord{}, fmt.Errorf("%w: malformed wal record", ErrCorrupt)
+	}
+	if rec.Op != WALAdd && rec.Op != WALDelete {
+		return WALRecord{}, fmt.Errorf("%w: unknown wal op %d", ErrCorrupt, rec.Op)
+	}
+	return rec, nil
+}
+
+// ScanWAL decodes every intact record of the log at path and returns them with the byte offset where
+// the intact prefix ends. A missing file is an empty log. The error wraps ErrTornWAL when the log ends
+// in an incomplete frame and ErrCorrupt when a complete frame fails its checksum
+func ScanWAL(path string) ([]WALRecord, int64, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	var (
+		records []WALRecord
+		offset  int64
+	)
+	for rest := data; len(rest) > 0; {
+		if len(rest) < walHeaderSize {
+			return records, offset, fmt.Errorf("%w at offset %d", ErrTornWAL, offset)
+		}
+		size, sum := order.Uint32(rest), order.Uint32(rest[4:])
+		if int64(size) > int64(len(rest)-walHeaderSize) {
+			return records, offset, fmt.Errorf("%w at offset %d", ErrTornWAL, offset)
+		}
+		payload := rest[walHeaderSize : walHeaderSize+int(size)]
+		if crc32.ChecksumIEEE(payload) != sum {
+			return records, offset, fmt.Errorf("%w: wal checksum mismatch at offset %d", ErrCorrupt, offset)
+		}
+		rec, err := decodeWALPayload(payload)
+		if err != nil {
+			return records, offset, fmt.Errorf("%w at offset %d", err, offset)
+		}
+		if n := len(records); n > 0 && rec.LSN <= records[n-1].LSN {
+			return records, offset, fmt.Errorf("%w: wal lsn %d out of order at offset %d", ErrCorrupt, rec.LSN, offset)
+		}
+		records = append(records, rec)
+		frame := int64(walHeaderSize) + int64(size)
+		offset += frame
+		rest = rest[frame:]
+	}
+	return records, offset, nil
+}
+
+// OpenWAL opens (or creates) the log at path and returns the records it holds.
+// A torn tail left by a crash is cut off, any other corruption is returned as an error.
+// LSNs continue after the last record, or after minLSN when the log is empty
+func OpenWAL(path string, minLSN uint64, syncWrites bool) (*WAL, []WALRecord, error) {
+	records, size, err := ScanWAL(path)
+	if err != nil && !errors.Is(err, ErrTornWAL) {
+		return nil, nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := f.Truncate(size); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if _, err := f.Seek(size, io.SeekStart); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	next := minLSN + 1
+	if n := len(records); n > 0 && records[n-1].LSN >= next {
+		next = records[n-1].LSN + 1
+	}
+	return &WAL{f: f, size: size, nextLSN: next, sync: syncWrites}, records, nil
+}
+
+// Append logs one mutation and returns its LSN
+func (w *WAL) Append(op WALOp, rec Record) (uint64, error) {
+	if err := CheckID(rec.ID); err != nil {
+		return 0, err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return 0, errors.New("wal closed")
+	}
+	lsn := w.nextLSN
+	frame := encodeWALRecord(WALRecord{LSN: lsn, Op: op, Record: rec})
+	if _, err := w.f.Write(frame); err != nil {
+		// drop whatever part of the frame made it to the file
+		w.f.Truncate(w.size)
+		w.f.Seek(w.size, io.SeekStart)
+		return 0, err
+	}
+	if w.sync {
+		if err := w.f.Sync(); err != nil {
+			return 0, err
+		}
+	}
+	w.size += int64(len(frame))
+	w.nextLSN++
+	return lsn, nil
+}
+
+// LastLSN is the LSN of the most recent append (or the starting point of an empty log)
+func (w *WAL) LastLSN() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.nextLSN - 1
+}
+
+// Reset empties the log once every record is safely in a segment, LSNs keep increasing
+func (w *WAL) Reset() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return errors.New("wal closed")
+	}
+	if err := w.f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := w.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	w.size = 0
+	return w.f.Sync()
+}
+
+// Sync flushes appended records to stable storage
+func (w *WAL) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return errors.New("wal closed")
+	}
+	return w.f.Sync()
+}
+
+func (w *WAL) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return nil
+	}
+	err := errors.Join(w.f.Sync(), w.f.Close())
+	w.f = nil
+	return err
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Invariant: records survive a reopen in order, LSNs keep increasing.
+func TestWAL_AppendAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), WALFileName)
+	wal, records, err := OpenWAL(path, 0, false)
+	if err != nil || len(records) != 0 {
+		t.Fatalf("expected empty wal, got %v records, err %v", len(records), err)
+	}
+	lsn1, _ := wal.Append(WALAdd, Record{ID: "vec-1", Values: []float32{1, 0}, Metadata: []byte(`{"a":1}`)})
+	lsn2, _ := wal.Append(WALDelete, Record{ID: "vec-1"})
+	if lsn1 != 1 || lsn2 != 2 {
+		t.Errorf("expected lsn 1 and 2, got %d and %d", lsn1, lsn2)
+	}
+	wal.Close()
+
+	wal, records, err = OpenWAL(path, 0, false)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer wal.Close()
+	if len(records) != 2 || records[0].Op != WALAdd || records[1].Op != WALDelete {
+		t.Fatalf("unexpected replay: %+v", records)
+	}
+	if records[0].Record.ID != "vec-1" || len(records[0].Record.Values) != 2 || string(records[0].Record.Metadata) != `{"a":1}` {
+		t.Errorf("record not restored: %+v", records[0].Record)
+	}
+	if next, _ := wal.Append(WALDelete, Record{ID: "x"}); next != 3 {
+		t.Errorf("expected lsn 3 after reopen, got %d", next)
+	}
+}
+
+// Post-condition: a reset log starts numbering after the flushed LSN.
+func TestWAL_ResetKeepsLSN(t *testing.T) {
+	path := filepath.Join(t.TempDir(), WALFileName)
+	wal, _, _ := OpenWAL(path, 0, true)
+	wal.Append(WALAdd, Record{ID: "vec-1", Values: []float32{1}})
+	if err := wal.Reset(); err != nil {
+		t.Fatalf("reset failed: %v", err)
+	}
+	if lsn, _ := wal.Append(WALAdd, Record{ID: "vec-2", Values: []float32{1}}); lsn != 2 {
+		t.Errorf("expected lsn 2 after reset, got %d", lsn)
+	}
+	wal.Close()
+
+	wal, records, _ := OpenWAL(path, 1, false)
+	defer wal.Close()
+	if len(records) != 1 || records[0].LSN != 2 {
+		t.Errorf("expected only record 2 after reset, got %+v", records)
+	}
+	empty := filepath.Join(t.TempDir(), WALFileName)
+	w2, _, _ := OpenWAL(empty, 41, false)
+	defer w2.Close()
+	if lsn, _ := w2.Append(WALDelete, Record{ID: "x"}); lsn != 42 {
+		t.Errorf("expected empty log to continue after min lsn, got %d", lsn)
+	}
+}
+
+// Contract: a torn tail is cut off on open, a checksum mismatch is an error.
+func TestWAL_Corruption(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, WALFileName)
+	wal, _, _ := OpenWAL(path, 0, false)
+	wal.Append(WALAdd, Record{ID: "vec-1", Values: []float32{1}})
+	wal.Append(WALAdd, Record{ID: "vec-2", Values: []float32{1}})
+	wal.Close()
+	intact, _ := os.ReadFile(path)
+
+	t.Run("Torn Tail", func(t *testing.T) {
+		os.WriteFile(path, intact[:len(intact)-3], 0o644)
+		_, off, err := ScanWAL(path)
+		if !errors.Is(err, ErrTornWAL) {
+			t.Fatalf("expected torn wal error, got %v", err)
+		}
+		w, records, err := OpenWAL(path, 0, false)
+		if err != nil || len(records) != 1 {
+			t.Fatalf("expected 1 record after torn tail, got %d, err %v", len(records), err)
+		}
+		w.Close()
+		if info, _ := os.Stat(path); info.Size() != off {
+			t.Errorf("expected wal truncated to %d, got %d", off, info.Size())
+		}
+	})
+
+	t.Run("Checksum Mismatch", func(t *testing.T) {
+		bad := append([]byte(nil), intact...)
+		bad[len(bad)-1] ^= 0xff
+		os.WriteFile(path, bad, 0o644)
+		if _, _, err := OpenWAL(path, 0, false); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("expected corruption error, got %v", err)
+		}
+	})
+}
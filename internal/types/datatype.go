@@ -1,15 +1,40 @@
 package types
 
+// DataType is the modality of the raw input a vector was embedded from
 type DataType int
 
 const (
-	Text DataType = iota
+	Text DataType = iota + 1
 	Image
 	Audio
 	Video
 )
 
-// To make enum values human-readable stings implementing Stringer interface of fmt
-// func (d DataType) String() string {
-// 	return [...]string{"Text, Image, Audio, Videa"}[d]
-// }
+var dataTypeNames = []string{Text: "text", Image: "image", Audio: "audio", Video: "video"}
+
+// ParseDataType parses the name String returns
+func ParseDataType(s string) (DataType, error) {
+	return parseEnum[DataType]("data type", s, dataTypeNames)
+}
+
+func (d DataType) Valid() bool {
+	return enumValid(d, dataTypeNames)
+}
+
+func (d DataType) String() string {
+	return enumString("DataType", d, dataTypeNames)
+}
+
+// MarshalText makes data types JSON strings ("text") rather than numbers
+func (d DataType) MarshalText() ([]byte, error) {
+	return marshalEnum("data type", d, dataTypeNames)
+}
+
+func (d *DataType) UnmarshalText(text []byte) error {
+	parsed, err := ParseDataType(string(text))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
@@ -0,0 +1,37 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// enums start at 1 so a zero value (a field nobody set) is never mistaken for the first constant.
+// names holds the canonical lowercase name of every value, indexed by value
+
+func enumValid[T ~int](v T, names []string) bool {
+	return v > 0 && int(v) < len(names)
+}
+
+func enumString[T ~int](kind string, v T, names []string) string {
+	if enumValid(v, names) {
+		return names[v]
+	}
+	return fmt.Sprintf("%s(%d)", kind, int(v))
+}
+
+// parseEnum accepts the canonical names in any case
+func parseEnum[T ~int](kind, s string, names []string) (T, error) {
+	for i := 1; i < len(names); i++ {
+		if strings.EqualFold(s, names[i]) {
+			return T(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown %s %q, want one of %s", kind, s, strings.Join(names[1:], ", "))
+}
+
+func marshalEnum[T ~int](kind string, v T, names []string) ([]byte, error) {
+	if !enumValid(v, names) {
+		return nil, fmt.Errorf("invalid %s %d", kind, int(v))
+	}
+	return []byte(names[v]), nil
+}
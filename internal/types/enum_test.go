@@ -0,0 +1,70 @@
+package types
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// Contract: every named enum value round-trips through String/Parse and JSON, names parse in any case.
+func TestEnums_RoundTrip(t *testing.T) {
+	type named interface {
+		String() string
+		Valid() bool
+	}
+	tests := []struct {
+		value named
+		name  string
+		parse func(string) (named, error)
+	}{
+		{Text, "text", func(s string) (named, error) { return ParseDataType(s) }},
+		{Video, "video", func(s string) (named, error) { return ParseDataType(s) }},
+		{Cosine, "cosine", func(s string) (named, error) { return ParseSimilarityMetric(s) }},
+		{Euclidean, "euclidean", func(s string) (named, error) { return ParseSimilarityMetric(s) }},
+		{LinearIndex, "linear", func(s string) (named, error) { return ParseIndexType(s) }},
+		{DiskANNIndex, "diskann", func(s string) (named, error) { return ParseIndexType(s) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !tt.value.Valid() || tt.value.String() != tt.name {
+				t.Errorf("expected valid %q, got %q (valid %v)", tt.name, tt.value.String(), tt.value.Valid())
+			}
+			for _, s := range []string{tt.name, strings.ToUpper(tt.name)} {
+				if got, err := tt.parse(s); err != nil || got != tt.value {
+					t.Errorf("parse %q: got %v, %v", s, got, err)
+				}
+			}
+			data, err := json.Marshal(tt.value)
+			if err != nil || string(data) != `"`+tt.name+`"` {
+				t.Errorf("expected JSON %q, got %s (err %v)", tt.name, data, err)
+			}
+		})
+	}
+}
+
+// Invariant: the zero value and out of range values are invalid and refuse to marshal.
+func TestEnums_Invalid(t *testing.T) {
+	var zero DataType
+	if zero.Valid() {
+		t.Error("zero data type is valid")
+	}
+	if SimilarityMetric(0).Valid() || IndexType(99).Valid() {
+		t.Error("out of range value is valid")
+	}
+	if got := DataType(7).String(); got != "DataType(7)" {
+		t.Errorf("unexpected name of an invalid value: %s", got)
+	}
+	if _, err := json.Marshal(struct{ M SimilarityMetric }{}); err == nil {
+		t.Error("expected marshaling the zero metric to fail")
+	}
+	if _, err := ParseSimilarityMetric("manhattan"); err == nil {
+		t.Error("expected an unknown metric to fail to parse")
+	}
+	var dt DataType
+	if err := json.Unmarshal([]byte(`"Image"`), &dt); err != nil || dt != Image {
+		t.Errorf("expected image, got %v (err %v)", dt, err)
+	}
+	if err := json.Unmarshal([]byte(`"smell"`), &dt); err == nil {
+		t.Error("expected an unknown data type to fail to unmarshal")
+	}
+}
@@ -3,23 +3,37 @@ package types
 type IndexType int
 
 const (
-	LinearIndex IndexType = iota
+	LinearIndex IndexType = iota + 1
 	HNSWIndex
 	IVFIndex
 	PQIndex
+	DiskANNIndex
 )
 
-// func (it IndexType) String() string {
-// 	switch it {
-// 	case LinearIndex:
-// 		return "LinearIndex"
-// 	case HNSWIndex:
-// 		return "HNSWIndex"
-// 	case IVFIndex:
-// 		return "IVFIndex"
-// 	case PQIndex:
-// 		return "PQIndex"
-// 	default:
-// 		panic("unsupported Index Type")
-// 	}
-// }
+var indexTypeNames = []string{LinearIndex: "linear", HNSWIndex: "hnsw", IVFIndex: "ivf", PQIndex: "pq", DiskANNIndex: "diskann"}
+
+// ParseIndexType parses the name String returns
+func ParseIndexType(s string) (IndexType, error) {
+	return parseEnum[IndexType]("index type", s, indexTypeNames)
+}
+
+func (it IndexType) Valid() bool {
+	return enumValid(it, indexTypeNames)
+}
+
+func (it IndexType) String() string {
+	return enumString("IndexType", it, indexTypeNames)
+}
+
+func (it IndexType) MarshalText() ([]byte, error) {
+	return marshalEnum("index type", it, indexTypeNames)
+}
+
+func (it *IndexType) UnmarshalText(text []byte) error {
+	parsed, err := ParseIndexType(string(text))
+	if err != nil {
+		return err
+	}
+	*it = parsed
+	return nil
+}
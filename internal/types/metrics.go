@@ -1,11 +1,38 @@
 package types
 
+// SimilarityMetric is how vectors are compared, an embedding model is trained for one
 type SimilarityMetric int
 
 const (
-	Cosine SimilarityMetric = iota
+	Cosine SimilarityMetric = iota + 1
 	Dot
 	Euclidean
 )
 
-//Todo implement stringer interface on similaritymetric
+var metricNames = []string{Cosine: "cosine", Dot: "dot", Euclidean: "euclidean"}
+
+// ParseSimilarityMetric parses the name String returns
+func ParseSimilarityMetric(s string) (SimilarityMetric, error) {
+	return parseEnum[SimilarityMetric]("similarity metric", s, metricNames)
+}
+
+func (m SimilarityMetric) Valid() bool {
+	return enumValid(m, metricNames)
+}
+
+func (m SimilarityMetric) String() string {
+	return enumString("SimilarityMetric", m, metricNames)
+}
+
+func (m SimilarityMetric) MarshalText() ([]byte, error) {
+	return marshalEnum("similarity metric", m, metricNames)
+}
+
+func (m *SimilarityMetric) UnmarshalText(text []byte) error {
+	parsed, err := ParseSimilarityMetric(string(text))
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,152 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ModelType names an embedding model registered in a ModelRegistry, it is the Name() of the
+// embedder that runs the model
+type ModelType string
+
+const (
+	// Testmodel accepts any dimension, data type and metric, for tests
+	Testmodel ModelType = "test"
+	// ExternalModel stands for vectors embedded outside the database (imported files, pre-embedded
+	// inserts), it accepts any dimension, data type and metric too
+	ExternalModel ModelType = "external"
+)
+
+// ModelInfo describes a registered embedding model. A zero Dimension, DataType or Metric leaves
+// that property open: a model with a configurable output size, or a placeholder like ExternalModel
+type ModelInfo struct {
+	Name      ModelType        `json:"name"`
+	Version   string           `json:"version,omitempty"`
+	Dimension int              `json:"dimension,omitempty"`
+	DataType  DataType         `json:"data_type,omitzero"`
+	Metric    SimilarityMetric `json:"metric,omitzero"`
+}
+
+// Validate fails when m has no name or holds a property out of range
+func (m ModelInfo) Validate() error {
+	if strings.TrimSpace(string(m.Name)) == "" {
+		return errors.New("model name is required")
+	}
+	if m.Dimension < 0 {
+		return fmt.Errorf("model %s: negative dimension", m.Name)
+	}
+	if m.DataType != 0 && !m.DataType.Valid() {
+		return fmt.Errorf("model %s: invalid data type", m.Name)
+	}
+	if m.Metric != 0 && !m.Metric.Valid() {
+		return fmt.Errorf("model %s: invalid metric", m.Name)
+	}
+	return nil
+}
+
+// Accepts reports why vectors of dimension values for dataType input and metric can't come from
+// the model, nil when they can
+func (m ModelInfo) Accepts(dataType DataType, metric SimilarityMetric, dimension int) error {
+	if m.Dimension != 0 && dimension != m.Dimension {
+		return fmt.Errorf("model %s produces dimension %d, not %d", m.Name, m.Dimension, dimension)
+	}
+	if m.DataType != 0 && dataType != m.DataType {
+		return fmt.Errorf("model %s embeds %s, not %s", m.Name, m.DataType, dataType)
+	}
+	if m.Metric != 0 && metric != m.Metric {
+		return fmt.Errorf("model %s is trained for %s, not %s", m.Name, m.Metric, metric)
+	}
+	return nil
+}
+
+// ModelRegistry holds the embedding models collections can be created for
+type ModelRegistry struct {
+	mu     sync.RWMutex
+	models map[ModelType]ModelInfo
+}
+
+// NewModelRegistry returns a registry holding Testmodel and ExternalModel
+func NewModelRegistry() *ModelRegistry {
+	r := &ModelRegistry{models: make(map[ModelType]ModelInfo)}
+	r.models[Testmodel] = ModelInfo{Name: Testmodel}
+	r.models[ExternalModel] = ModelInfo{Name: ExternalModel}
+	return r
+}
+
+// Register adds a model. Registering it again with the same info is a no-op, with different info an
+// error: vectors of two versions of a model aren't comparable, a new version needs a new name
+func (r *ModelRegistry) Register(info ModelInfo) error {
+	if err := info.Validate(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.models[info.Name]; ok && existing != info {
+		return fmt.Errorf("model %s is already registered with different properties", info.Name)
+	}
+	r.models[info.Name] = info
+	return nil
+}
+
+func (r *ModelRegistry) Lookup(name ModelType) (ModelInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	info, ok := r.models[name]
+	return info, ok
+}
+
+// Models returns every registered model sorted by name
+func (r *ModelRegistry) Models() []ModelInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	models := make([]ModelInfo, 0, len(r.models))
+	for _, info := range r.models {
+		models = append(models, info)
+	}
+	slices.SortFunc(models, func(a, b ModelInfo) int { return strings.Compare(string(a.Name), string(b.Name)) })
+	return models
+}
+
+// Load registers every model of a JSON array of ModelInfo, e.g.
+//
+//	[{"name": "text-embedding-3-small", "version": "1", "dimension": 1536, "data_type": "text", "metric": "cosine"}]
+func (r *ModelRegistry) Load(rd io.Reader) error {
+	var models []ModelInfo
+	if err := json.NewDecoder(rd).Decode(&models); err != nil {
+		return fmt.Errorf("invalid model list: %w", err)
+	}
+	var errs []error
+	for _, info := range models {
+		errs = append(errs, r.Register(info))
+	}
+	return errors.Join(errs...)
+}
+
+// LoadFile registers the models of the JSON file at path, see Load
+func (r *ModelRegistry) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return r.Load(f)
+}
+
+// DefaultModels is the registry NewIndexConfig and ingestion validate models against
+var DefaultModels = NewModelRegistry()
+
+// RegisterModel adds a model to DefaultModels
+func RegisterModel(info ModelInfo) error {
+	return DefaultModels.Register(info)
+}
+
+// LookupModel finds a model in DefaultModels
+func LookupModel(name ModelType) (ModelInfo, bool) {
+	return DefaultModels.Lookup(name)
+}
@@ -0,0 +1,96 @@
+package types
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Contract: a registered model constrains exactly the properties it sets, zero values leave them open.
+func TestModelInfo_Accepts(t *testing.T) {
+	fixed := ModelInfo{Name: "fixed", Dimension: 8, DataType: Text, Metric: Cosine}
+	open := ModelInfo{Name: "open", DataType: Image}
+	tests := []struct {
+		name     string
+		model    ModelInfo
+		dataType DataType
+		metric   SimilarityMetric
+		dim      int
+		errorMsg string
+	}{
+		{"match", fixed, Text, Cosine, 8, ""},
+		{"dimension", fixed, Text, Cosine, 4, "model fixed produces dimension 8, not 4"},
+		{"data type", fixed, Image, Cosine, 8, "model fixed embeds text, not image"},
+		{"metric", fixed, Text, Dot, 8, "model fixed is trained for cosine, not dot"},
+		{"open dimension and metric", open, Image, Euclidean, 3, ""},
+		{"open model data type", open, Text, Euclidean, 3, "model open embeds image, not text"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.model.Accepts(tt.dataType, tt.metric, tt.dim)
+			if (err == nil) != (tt.errorMsg == "") || (err != nil && err.Error() != tt.errorMsg) {
+				t.Errorf("expected %q, got %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+// Contract: registering is idempotent, a conflicting registration or invalid model is rejected.
+func TestModelRegistry_Register(t *testing.T) {
+	r := NewModelRegistry()
+	if _, ok := r.Lookup(Testmodel); !ok {
+		t.Fatal("new registry lacks the test model")
+	}
+	info := ModelInfo{Name: "minilm", Version: "2", Dimension: 384, DataType: Text, Metric: Cosine}
+	if err := r.Register(info); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	if err := r.Register(info); err != nil {
+		t.Errorf("registering the same model again failed: %v", err)
+	}
+	changed := info
+	changed.Version = "3"
+	if err := r.Register(changed); err == nil {
+		t.Error("expected a conflicting registration to fail")
+	}
+	for _, bad := range []ModelInfo{{Name: " "}, {Name: "neg", Dimension: -1}, {Name: "dt", DataType: 9}} {
+		if err := r.Register(bad); err == nil {
+			t.Errorf("expected %+v to be rejected", bad)
+		}
+	}
+	if got, _ := r.Lookup("minilm"); got != info {
+		t.Errorf("lookup returned %+v", got)
+	}
+	names := []string{}
+	for _, m := range r.Models() {
+		names = append(names, string(m.Name))
+	}
+	if strings.Join(names, ",") != "external,minilm,test" {
+		t.Errorf("unexpected models %v", names)
+	}
+}
+
+// Post-condition: models listed in a JSON file are registered without code changes.
+func TestModelRegistry_LoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "models.json")
+	os.WriteFile(path, []byte(`[
+		{"name": "clip", "version": "1", "dimension": 512, "data_type": "image", "metric": "dot"},
+		{"name": "e5", "dimension": 1024, "data_type": "text"}
+	]`), 0o644)
+	r := NewModelRegistry()
+	if err := r.LoadFile(path); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	clip, ok := r.Lookup("clip")
+	if !ok || clip.Dimension != 512 || clip.DataType != Image || clip.Metric != Dot {
+		t.Errorf("unexpected clip model %+v", clip)
+	}
+	if e5, _ := r.Lookup("e5"); e5.Metric != 0 {
+		t.Errorf("expected e5 to leave the metric open, got %v", e5.Metric)
+	}
+	os.WriteFile(path, []byte(`[{"name": "bad", "metric": "manhattan"}]`), 0o644)
+	if err := r.LoadFile(path); err == nil {
+		t.Error("expected an unknown metric to fail")
+	}
+}